@@ -0,0 +1,144 @@
+// Command import-bible loads a public-domain Bible translation from USFM,
+// OSIS or USX files into the database, so the API can serve it without an
+// upstream scripture service.
+//
+//	go run ./cmd/import-bible -id KJV -name "King James Version" eng-kjv_usfm/
+//	go run ./cmd/import-bible -id WEB -name "World English Bible" eng-web.osis.xml
+//
+// Arguments are files or directories; directories are read in name order.
+// It connects with the same DB_* variables as the server.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"theword/Backend/lib/bible"
+	"theword/Backend/lib/models"
+)
+
+func main() {
+	id := flag.String("id", "", "translation ID, e.g. KJV (required)")
+	name := flag.String("name", "", "translation name, e.g. \"King James Version\"")
+	abbreviation := flag.String("abbr", "", "abbreviation shown to readers (defaults to -id)")
+	language := flag.String("lang", "eng", "ISO 639-3 language code")
+	format := flag.String("format", "", "usfm, osis or usx (defaults to guessing from each file's extension)")
+	versification := flag.String("versification", "english", "verse numbering: english or hebrew")
+	flag.Parse()
+
+	if *id == "" || flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: import-bible -id ID [-name NAME] [-abbr ABBR] [-lang LANG] [-format FORMAT] [-versification SCHEME] PATH...")
+		os.Exit(2)
+	}
+	scheme, ok := bible.ParseVersification(*versification)
+	if !ok {
+		log.Fatalf("unknown versification %q", *versification)
+	}
+	if *name == "" {
+		*name = *id
+	}
+	if *abbreviation == "" {
+		*abbreviation = *id
+	}
+
+	files, err := sourceFiles(flag.Args(), *format)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var text bible.Text
+	formats := map[string]bool{}
+	for _, f := range files {
+		if err := parseFile(f.path, f.format, &text); err != nil {
+			log.Fatalf("%s: %v", f.path, err)
+		}
+		formats[f.format] = true
+	}
+	if len(formats) > 1 {
+		log.Fatal("source files must all be in one format")
+	}
+
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=5432 sslmode=disable",
+		os.Getenv("DB_HOST"), os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_NAME"))
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("failed to connect to database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.BibleTranslation{}, &models.BibleBook{}, &models.BibleVerse{}); err != nil {
+		log.Fatalf("failed to migrate: %v", err)
+	}
+
+	translation := models.BibleTranslation{
+		TranslationID: strings.ToUpper(*id),
+		Name:          *name,
+		Abbreviation:  *abbreviation,
+		Language:      *language,
+		Format:        files[0].format,
+		Versification: string(scheme),
+	}
+	if err := bible.Import(db, translation, &text); err != nil {
+		log.Fatalf("import failed: %v", err)
+	}
+	log.Printf("Imported %s: %d books, %d verses", translation.TranslationID, len(text.Books), len(text.Verses))
+}
+
+type sourceFile struct {
+	path   string
+	format string
+}
+
+// sourceFiles expands the arguments into the files to read, skipping files
+// in directories whose format is not recognised.
+func sourceFiles(args []string, format string) ([]sourceFile, error) {
+	var files []sourceFile
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			f := format
+			if f == "" {
+				f = bible.FormatOf(arg)
+			}
+			if f == "" {
+				return nil, fmt.Errorf("%s: unknown format; pass -format", arg)
+			}
+			files = append(files, sourceFile{arg, f})
+			continue
+		}
+
+		entries, err := os.ReadDir(arg)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			path := filepath.Join(arg, e.Name())
+			f := bible.FormatOf(path)
+			if e.IsDir() || f == "" || (format != "" && f != format) {
+				continue
+			}
+			files = append(files, sourceFile{path, f})
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no source files found")
+	}
+	return files, nil
+}
+
+func parseFile(path, format string, text *bible.Text) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return bible.Parse(format, f, text)
+}
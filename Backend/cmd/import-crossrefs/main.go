@@ -0,0 +1,60 @@
+// Command import-crossrefs loads an open cross-reference dataset, or a
+// topical index, into the database for the related-verse and topic
+// endpoints.
+//
+//	go run ./cmd/import-crossrefs cross_references.txt
+//	go run ./cmd/import-crossrefs -source tsk tsk_cross_references.txt
+//	go run ./cmd/import-crossrefs -topics topic_votes.txt
+//
+// Files are tab-separated in OpenBible.info's layout. It connects with the
+// same DB_* variables as the server.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"theword/Backend/lib/bible"
+)
+
+func main() {
+	source := flag.String("source", "openbible", "name of the cross-reference dataset; importing it again replaces it")
+	topics := flag.Bool("topics", false, "the file is a topical index rather than cross-references")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: import-crossrefs [-source NAME | -topics] FILE")
+		os.Exit(2)
+	}
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=5432 sslmode=disable",
+		os.Getenv("DB_HOST"), os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_NAME"))
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("failed to connect to database: %v", err)
+	}
+
+	if *topics {
+		n, err := bible.ImportTopics(db, f)
+		if err != nil {
+			log.Fatalf("import failed: %v", err)
+		}
+		log.Printf("Imported %d topics", n)
+		return
+	}
+	n, err := bible.ImportCrossReferences(db, *source, f)
+	if err != nil {
+		log.Fatalf("import failed: %v", err)
+	}
+	log.Printf("Imported %d cross-references from %s", n, *source)
+}
@@ -0,0 +1,61 @@
+// Command import-strongs loads original-language word data from an
+// interlinear file, or Strong's dictionary entries, into the database for
+// the word, lexicon and concordance endpoints.
+//
+//	go run ./cmd/import-strongs tagnt_words.tsv tahot_words.tsv
+//	go run ./cmd/import-strongs -lexicon strongs-greek-dictionary.js strongs-hebrew-dictionary.js
+//
+// Interlinear files are tab-separated with a header naming their columns;
+// see bible.ImportWords. It connects with the same DB_* variables as the
+// server.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"theword/Backend/lib/bible"
+)
+
+func main() {
+	lexicon := flag.Bool("lexicon", false, "the files are Strong's dictionaries rather than interlinear words")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: import-strongs [-lexicon] FILE...")
+		os.Exit(2)
+	}
+
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=5432 sslmode=disable",
+		os.Getenv("DB_HOST"), os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_NAME"))
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("failed to connect to database: %v", err)
+	}
+
+	for _, path := range flag.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if *lexicon {
+			n, err := bible.ImportLexicon(db, f)
+			if err != nil {
+				log.Fatalf("%s: import failed: %v", path, err)
+			}
+			log.Printf("Imported %d lexicon entries from %s", n, path)
+		} else {
+			n, err := bible.ImportWords(db, f)
+			if err != nil {
+				log.Fatalf("%s: import failed: %v", path, err)
+			}
+			log.Printf("Imported %d words from %s", n, path)
+		}
+		f.Close()
+	}
+}
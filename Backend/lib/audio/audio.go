@@ -0,0 +1,148 @@
+// Package audio serves audio Bible recordings, one file per chapter, from a
+// local directory or an S3 bucket, with verse timestamps where a recording
+// has them.
+package audio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"theword/Backend/lib/bible"
+)
+
+// DefaultTemplate lays recordings out as KJV/JHN/3.mp3.
+const DefaultTemplate = "{translation}/{book}/{chapter}.mp3"
+
+var ErrNotFound = errors.New("audio not found")
+
+// Object is an open audio file.
+type Object struct {
+	io.ReadSeekCloser
+	Size    int64
+	ModTime time.Time
+}
+
+// Source reads audio files by key, a slash-separated path.
+type Source interface {
+	// Open opens the file at key, returning ErrNotFound if there is none.
+	Open(ctx context.Context, key string) (*Object, error)
+}
+
+// Timestamp is where a verse starts and ends in a chapter's recording, in
+// seconds.
+type Timestamp struct {
+	Verse   int     `json:"verse"`
+	VerseID string  `json:"verse_id"` // e.g. "JHN.3.16"
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+}
+
+// Library maps chapters to recordings in a Source.
+type Library struct {
+	Source Source
+	// Template builds a chapter's key from {translation}, {book} (its USFM
+	// code, e.g. JHN), {osis} (e.g. John) and {chapter}, which may be
+	// zero-padded as {chapter:3}. Its verse timestamps, if any, are in a
+	// JSON file of the same name ending .json instead.
+	Template string
+}
+
+var (
+	translationPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	chapterField       = regexp.MustCompile(`\{chapter(?::(\d))?\}`)
+)
+
+// Key returns where a chapter's recording is. It returns ErrNotFound for an
+// invalid translation or chapter ID.
+func (l *Library) Key(translationID, chapterID string) (string, error) {
+	book, chapter, ok := bible.ParseChapterID(chapterID)
+	if !ok || !translationPattern.MatchString(translationID) {
+		return "", ErrNotFound
+	}
+	template := l.Template
+	if template == "" {
+		template = DefaultTemplate
+	}
+
+	key := strings.NewReplacer("{translation}", translationID, "{book}", book.USFM, "{osis}", book.OSIS).Replace(template)
+	return chapterField.ReplaceAllStringFunc(key, func(field string) string {
+		width := chapterField.FindStringSubmatch(field)[1]
+		if width == "" {
+			return strconv.Itoa(chapter)
+		}
+		return fmt.Sprintf("%0"+width+"d", chapter)
+	}), nil
+}
+
+// Open opens a chapter's recording and returns it with its content type.
+func (l *Library) Open(ctx context.Context, translationID, chapterID string) (*Object, string, error) {
+	key, err := l.Key(translationID, chapterID)
+	if err != nil {
+		return nil, "", err
+	}
+	obj, err := l.Source.Open(ctx, key)
+	if err != nil {
+		return nil, "", err
+	}
+	return obj, ContentType(key), nil
+}
+
+// Timestamps returns a chapter's verse timestamps, or nil if its recording
+// has none. The timestamps file is a JSON array of objects with verse,
+// start and end.
+func (l *Library) Timestamps(ctx context.Context, translationID, chapterID string) ([]Timestamp, error) {
+	key, err := l.Key(translationID, chapterID)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := l.Source.Open(ctx, strings.TrimSuffix(key, path.Ext(key))+".json")
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Close()
+
+	book, chapter, _ := bible.ParseChapterID(chapterID)
+	var timestamps []Timestamp
+	if err := json.NewDecoder(obj).Decode(&timestamps); err != nil {
+		return nil, fmt.Errorf("timestamps for %s %s: %w", translationID, chapterID, err)
+	}
+	for i := range timestamps {
+		timestamps[i].VerseID = book.USFM + "." + strconv.Itoa(chapter) + "." + strconv.Itoa(timestamps[i].Verse)
+	}
+	return timestamps, nil
+}
+
+var audioTypes = map[string]string{
+	".mp3":  "audio/mpeg",
+	".m4a":  "audio/mp4",
+	".aac":  "audio/aac",
+	".ogg":  "audio/ogg",
+	".opus": "audio/ogg",
+	".wav":  "audio/wav",
+	".webm": "audio/webm",
+	".flac": "audio/flac",
+}
+
+// ContentType guesses an audio file's type from its extension.
+func ContentType(key string) string {
+	ext := strings.ToLower(path.Ext(key))
+	if t, ok := audioTypes[ext]; ok {
+		return t
+	}
+	if t := mime.TypeByExtension(ext); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
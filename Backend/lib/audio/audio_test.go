@@ -0,0 +1,140 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		template, translation, chapter, want string
+	}{
+		{"", "KJV", "JHN.3", "KJV/JHN/3.mp3"},
+		{"audio/{translation}/{osis}_{chapter:3}.m4a", "WEB", "1SA.12", "audio/WEB/1Sam_012.m4a"},
+		{"{book}{chapter:2}/{chapter}.ogg", "x", "psa 119", "PSA119/119.ogg"},
+	}
+	for _, tt := range tests {
+		l := Library{Template: tt.template}
+		if got, err := l.Key(tt.translation, tt.chapter); err != nil || got != tt.want {
+			t.Errorf("Key(%q, %q) with %q = %q, %v; want %q", tt.translation, tt.chapter, tt.template, got, err, tt.want)
+		}
+	}
+	for _, bad := range [][2]string{{"KJV", "JHN.22"}, {"KJV", "XYZ.1"}, {"../KJV", "JHN.3"}, {"", "JHN.3"}} {
+		if _, err := (&Library{}).Key(bad[0], bad[1]); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Key(%q, %q) = %v, want ErrNotFound", bad[0], bad[1], err)
+		}
+	}
+}
+
+func TestLocal(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "KJV", "JHN"), 0o755)
+	os.WriteFile(filepath.Join(dir, "KJV", "JHN", "3.mp3"), []byte("0123456789"), 0o644)
+	os.WriteFile(filepath.Join(dir, "KJV", "JHN", "3.json"), []byte(`[{"verse":1,"start":0,"end":4.5},{"verse":2,"start":4.5,"end":9.25}]`), 0o644)
+	l := &Library{Source: &Local{Dir: dir}}
+
+	obj, contentType, err := l.Open(context.Background(), "KJV", "JHN.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer obj.Close()
+	if obj.Size != 10 || contentType != "audio/mpeg" {
+		t.Errorf("size %d, type %q", obj.Size, contentType)
+	}
+
+	timestamps, err := l.Timestamps(context.Background(), "KJV", "jhn 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Timestamp{{1, "JHN.3.1", 0, 4.5}, {2, "JHN.3.2", 4.5, 9.25}}
+	if !reflect.DeepEqual(timestamps, want) {
+		t.Errorf("timestamps = %+v, want %+v", timestamps, want)
+	}
+
+	if _, _, err := l.Open(context.Background(), "KJV", "JHN.4"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing chapter: %v, want ErrNotFound", err)
+	}
+	if ts, err := l.Timestamps(context.Background(), "KJV", "JHN.4"); ts != nil || err != nil {
+		t.Errorf("timestamps without file = %v, %v", ts, err)
+	}
+}
+
+// fakeS3 serves objects from memory, honouring open-ended byte ranges.
+type fakeS3 struct {
+	objects map[string][]byte
+	gets    []string
+}
+
+func (f *fakeS3) HeadObjectWithContext(_ aws.Context, in *s3.HeadObjectInput, _ ...request.Option) (*s3.HeadObjectOutput, error) {
+	data, ok := f.objects[*in.Bucket+"/"+*in.Key]
+	if !ok {
+		return nil, awserr.NewRequestFailure(awserr.New("NotFound", "Not Found", nil), http.StatusNotFound, "")
+	}
+	modified := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &s3.HeadObjectOutput{ContentLength: aws.Int64(int64(len(data))), LastModified: &modified}, nil
+}
+
+func (f *fakeS3) GetObjectWithContext(_ aws.Context, in *s3.GetObjectInput, _ ...request.Option) (*s3.GetObjectOutput, error) {
+	data := f.objects[*in.Bucket+"/"+*in.Key]
+	f.gets = append(f.gets, aws.StringValue(in.Range))
+	from, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(aws.StringValue(in.Range), "bytes="), "-"))
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(data[from:]))}, nil
+}
+
+func TestS3Range(t *testing.T) {
+	client := &fakeS3{objects: map[string][]byte{"bucket/audio/KJV/JHN/3.mp3": []byte("0123456789")}}
+	l := &Library{Source: &S3{Client: client, Bucket: "bucket", Prefix: "audio/"}}
+
+	serve := func(rangeHeader string) *httptest.ResponseRecorder {
+		obj, contentType, err := l.Open(context.Background(), "KJV", "JHN.3")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer obj.Close()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+		w := httptest.NewRecorder()
+		w.Header().Set("Content-Type", contentType)
+		http.ServeContent(w, req, "", obj.ModTime, obj)
+		return w
+	}
+
+	w := serve("bytes=4-6")
+	if w.Code != http.StatusPartialContent || w.Body.String() != "456" || w.Header().Get("Content-Range") != "bytes 4-6/10" {
+		t.Errorf("range: %d %q %q", w.Code, w.Body.String(), w.Header().Get("Content-Range"))
+	}
+	if got := fmt.Sprint(client.gets); got != "[bytes=4-]" {
+		t.Errorf("S3 requests = %s, want one from byte 4", got)
+	}
+
+	w = serve("")
+	if w.Code != http.StatusOK || w.Body.String() != "0123456789" || w.Header().Get("Accept-Ranges") != "bytes" {
+		t.Errorf("whole file: %d %q", w.Code, w.Body.String())
+	}
+	if w = serve("bytes=20-"); w.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf("unsatisfiable range: %d", w.Code)
+	}
+
+	if _, _, err := l.Open(context.Background(), "KJV", "JHN.4"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing object: %v, want ErrNotFound", err)
+	}
+}
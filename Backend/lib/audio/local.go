@@ -0,0 +1,34 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Local reads audio files from a directory.
+type Local struct {
+	Dir string
+}
+
+func (l *Local) Open(ctx context.Context, key string) (*Object, error) {
+	f, err := os.Open(filepath.Join(l.Dir, filepath.FromSlash(key)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		f.Close()
+		return nil, ErrNotFound
+	}
+	return &Object{ReadSeekCloser: f, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
@@ -0,0 +1,101 @@
+package bible
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const apiBibleURL = "https://api.scripture.api.bible/v1"
+
+// APIBible serves translations from api.scripture.api.bible.
+type APIBible struct {
+	BaseURL string
+	Key     string
+	Client  *http.Client
+}
+
+func NewAPIBible(key string) *APIBible {
+	return &APIBible{BaseURL: apiBibleURL, Key: key, Client: http.DefaultClient}
+}
+
+func (a *APIBible) Translations(ctx context.Context) ([]interface{}, error) {
+	result, err := a.get(ctx, "/bibles")
+	if err != nil {
+		return nil, err
+	}
+	data, ok := result["data"].([]interface{})
+	if !ok {
+		return nil, &UpstreamError{Service: "API.Bible", Status: http.StatusOK, Err: fmt.Errorf("invalid response format")}
+	}
+	return data, nil
+}
+
+func (a *APIBible) Books(ctx context.Context, translationID string) (map[string]interface{}, error) {
+	return a.get(ctx, "/bibles/"+url.PathEscape(translationID)+"/books")
+}
+
+// Chapters lists a book's chapters, leaving out the "intro" pseudo-chapter.
+func (a *APIBible) Chapters(ctx context.Context, translationID, bookID string) (map[string]interface{}, error) {
+	result, err := a.get(ctx, "/bibles/"+url.PathEscape(translationID)+"/books/"+url.PathEscape(bookID)+"/chapters")
+	if err != nil {
+		return nil, err
+	}
+
+	data, _ := result["data"].([]interface{})
+	filtered := []interface{}{}
+	for _, ch := range data {
+		if chMap, ok := ch.(map[string]interface{}); ok && chMap["number"] != "intro" {
+			filtered = append(filtered, chMap)
+		}
+	}
+	result["data"] = filtered
+	return result, nil
+}
+
+// Passage checks the chapter against the canon before spending a request
+// on it.
+func (a *APIBible) Passage(ctx context.Context, translationID, reference string) (map[string]interface{}, error) {
+	book, chapter, ok := ParseChapterID(reference)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	chapterID := book.USFM + "." + strconv.Itoa(chapter)
+	return a.get(ctx, "/bibles/"+url.PathEscape(translationID)+"/chapters/"+chapterID+"?content-type=json")
+}
+
+func (a *APIBible) get(ctx context.Context, path string) (map[string]interface{}, error) {
+	if a.Key == "" {
+		return nil, ErrMissingKey
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.BaseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("api-key", a.Key)
+
+	resp, err := a.Client.Do(req)
+	if err != nil {
+		return nil, &UpstreamError{Service: "API.Bible", Err: err}
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &UpstreamError{Service: "API.Bible", Status: resp.StatusCode, Body: string(body)}
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, &UpstreamError{Service: "API.Bible", Status: resp.StatusCode, Err: fmt.Errorf("invalid JSON: %w", err)}
+	}
+	return result, nil
+}
@@ -0,0 +1,177 @@
+package bible
+
+import (
+	"strconv"
+	"strings"
+)
+
+type Testament int
+
+const (
+	OldTestament Testament = iota
+	NewTestament
+	Deuterocanon
+)
+
+// BookInfo describes a book of the Bible under the names the various
+// formats and services use for it.
+type BookInfo struct {
+	USFM          string   // e.g. "1SA"; the ID used throughout the API
+	OSIS          string   // e.g. "1Sam"
+	Name          string   // e.g. "1 Samuel"; also what the ESV API accepts
+	Abbreviations []string // common short forms, e.g. "1 Sam", "1Sa"
+	Chapters      int
+	Testament     Testament
+}
+
+// Canon lists the books in canonical order: the Protestant Old and New
+// Testaments, then the deuterocanonical books.
+var Canon = []BookInfo{
+	{"GEN", "Gen", "Genesis", []string{"Gen", "Ge", "Gn"}, 50, OldTestament},
+	{"EXO", "Exod", "Exodus", []string{"Exod", "Exo", "Ex"}, 40, OldTestament},
+	{"LEV", "Lev", "Leviticus", []string{"Lev", "Le", "Lv"}, 27, OldTestament},
+	{"NUM", "Num", "Numbers", []string{"Num", "Nu", "Nm", "Nb"}, 36, OldTestament},
+	{"DEU", "Deut", "Deuteronomy", []string{"Deut", "Deu", "De", "Dt"}, 34, OldTestament},
+	{"JOS", "Josh", "Joshua", []string{"Josh", "Jos", "Jsh"}, 24, OldTestament},
+	{"JDG", "Judg", "Judges", []string{"Judg", "Jdg", "Jg", "Jdgs"}, 21, OldTestament},
+	{"RUT", "Ruth", "Ruth", []string{"Ruth", "Rut", "Ru", "Rth"}, 4, OldTestament},
+	{"1SA", "1Sam", "1 Samuel", []string{"1 Sam", "1 Sa", "1 Sm", "1 Kingdoms"}, 31, OldTestament},
+	{"2SA", "2Sam", "2 Samuel", []string{"2 Sam", "2 Sa", "2 Sm", "2 Kingdoms"}, 24, OldTestament},
+	{"1KI", "1Kgs", "1 Kings", []string{"1 Kgs", "1 Ki", "1 Kin", "3 Kingdoms"}, 22, OldTestament},
+	{"2KI", "2Kgs", "2 Kings", []string{"2 Kgs", "2 Ki", "2 Kin", "4 Kingdoms"}, 25, OldTestament},
+	{"1CH", "1Chr", "1 Chronicles", []string{"1 Chr", "1 Ch", "1 Chron"}, 29, OldTestament},
+	{"2CH", "2Chr", "2 Chronicles", []string{"2 Chr", "2 Ch", "2 Chron"}, 36, OldTestament},
+	{"EZR", "Ezra", "Ezra", []string{"Ezra", "Ezr", "Ez"}, 10, OldTestament},
+	{"NEH", "Neh", "Nehemiah", []string{"Neh", "Ne"}, 13, OldTestament},
+	{"EST", "Esth", "Esther", []string{"Esth", "Est", "Es"}, 10, OldTestament},
+	{"JOB", "Job", "Job", []string{"Job", "Jb"}, 42, OldTestament},
+	{"PSA", "Ps", "Psalms", []string{"Ps", "Psa", "Pss", "Psalm", "Psm"}, 150, OldTestament},
+	{"PRO", "Prov", "Proverbs", []string{"Prov", "Pro", "Prv", "Pr"}, 31, OldTestament},
+	{"ECC", "Eccl", "Ecclesiastes", []string{"Eccl", "Ecc", "Eccles", "Ec", "Qoh"}, 12, OldTestament},
+	{"SNG", "Song", "Song of Solomon", []string{"Song", "Song of Songs", "Sng", "SS", "Canticles", "Cant"}, 8, OldTestament},
+	{"ISA", "Isa", "Isaiah", []string{"Isa", "Is"}, 66, OldTestament},
+	{"JER", "Jer", "Jeremiah", []string{"Jer", "Je", "Jr"}, 52, OldTestament},
+	{"LAM", "Lam", "Lamentations", []string{"Lam", "La"}, 5, OldTestament},
+	{"EZK", "Ezek", "Ezekiel", []string{"Ezek", "Eze", "Ezk"}, 48, OldTestament},
+	{"DAN", "Dan", "Daniel", []string{"Dan", "Da", "Dn"}, 12, OldTestament},
+	{"HOS", "Hos", "Hosea", []string{"Hos", "Ho"}, 14, OldTestament},
+	{"JOL", "Joel", "Joel", []string{"Joel", "Jl", "Joe"}, 3, OldTestament},
+	{"AMO", "Amos", "Amos", []string{"Amos", "Am"}, 9, OldTestament},
+	{"OBA", "Obad", "Obadiah", []string{"Obad", "Oba", "Ob"}, 1, OldTestament},
+	{"JON", "Jonah", "Jonah", []string{"Jonah", "Jon", "Jnh"}, 4, OldTestament},
+	{"MIC", "Mic", "Micah", []string{"Mic", "Mc"}, 7, OldTestament},
+	{"NAM", "Nah", "Nahum", []string{"Nah", "Nam", "Na"}, 3, OldTestament},
+	{"HAB", "Hab", "Habakkuk", []string{"Hab", "Hb"}, 3, OldTestament},
+	{"ZEP", "Zeph", "Zephaniah", []string{"Zeph", "Zep", "Zp"}, 3, OldTestament},
+	{"HAG", "Hag", "Haggai", []string{"Hag", "Hg"}, 2, OldTestament},
+	{"ZEC", "Zech", "Zechariah", []string{"Zech", "Zec", "Zc"}, 14, OldTestament},
+	{"MAL", "Mal", "Malachi", []string{"Mal", "Ml"}, 4, OldTestament},
+
+	{"MAT", "Matt", "Matthew", []string{"Matt", "Mat", "Mt"}, 28, NewTestament},
+	{"MRK", "Mark", "Mark", []string{"Mark", "Mrk", "Mar", "Mk", "Mr"}, 16, NewTestament},
+	{"LUK", "Luke", "Luke", []string{"Luke", "Luk", "Lk"}, 24, NewTestament},
+	{"JHN", "John", "John", []string{"John", "Jhn", "Joh", "Jn"}, 21, NewTestament},
+	{"ACT", "Acts", "Acts", []string{"Acts", "Act", "Ac"}, 28, NewTestament},
+	{"ROM", "Rom", "Romans", []string{"Rom", "Ro", "Rm"}, 16, NewTestament},
+	{"1CO", "1Cor", "1 Corinthians", []string{"1 Cor", "1 Co"}, 16, NewTestament},
+	{"2CO", "2Cor", "2 Corinthians", []string{"2 Cor", "2 Co"}, 13, NewTestament},
+	{"GAL", "Gal", "Galatians", []string{"Gal", "Ga"}, 6, NewTestament},
+	{"EPH", "Eph", "Ephesians", []string{"Eph", "Ephes"}, 6, NewTestament},
+	{"PHP", "Phil", "Philippians", []string{"Phil", "Php", "Pp"}, 4, NewTestament},
+	{"COL", "Col", "Colossians", []string{"Col"}, 4, NewTestament},
+	{"1TH", "1Thess", "1 Thessalonians", []string{"1 Thess", "1 Thes", "1 Th"}, 5, NewTestament},
+	{"2TH", "2Thess", "2 Thessalonians", []string{"2 Thess", "2 Thes", "2 Th"}, 3, NewTestament},
+	{"1TI", "1Tim", "1 Timothy", []string{"1 Tim", "1 Ti"}, 6, NewTestament},
+	{"2TI", "2Tim", "2 Timothy", []string{"2 Tim", "2 Ti"}, 4, NewTestament},
+	{"TIT", "Titus", "Titus", []string{"Titus", "Tit", "Ti"}, 3, NewTestament},
+	{"PHM", "Phlm", "Philemon", []string{"Phlm", "Philem", "Phm", "Pm"}, 1, NewTestament},
+	{"HEB", "Heb", "Hebrews", []string{"Heb"}, 13, NewTestament},
+	{"JAS", "Jas", "James", []string{"Jas", "Jm", "Jam"}, 5, NewTestament},
+	{"1PE", "1Pet", "1 Peter", []string{"1 Pet", "1 Pe", "1 Pt"}, 5, NewTestament},
+	{"2PE", "2Pet", "2 Peter", []string{"2 Pet", "2 Pe", "2 Pt"}, 3, NewTestament},
+	{"1JN", "1John", "1 John", []string{"1 Jn", "1 Jhn", "1 Joh"}, 5, NewTestament},
+	{"2JN", "2John", "2 John", []string{"2 Jn", "2 Jhn", "2 Joh"}, 1, NewTestament},
+	{"3JN", "3John", "3 John", []string{"3 Jn", "3 Jhn", "3 Joh"}, 1, NewTestament},
+	{"JUD", "Jude", "Jude", []string{"Jude", "Jud", "Jd"}, 1, NewTestament},
+	{"REV", "Rev", "Revelation", []string{"Rev", "Re", "Rv", "Apocalypse", "Revelations"}, 22, NewTestament},
+
+	{"TOB", "Tob", "Tobit", []string{"Tob", "Tb"}, 14, Deuterocanon},
+	{"JDT", "Jdt", "Judith", []string{"Jdt", "Jdth"}, 16, Deuterocanon},
+	{"ESG", "EsthGr", "Esther (Greek)", []string{"Greek Esther", "Add Esth", "AddEsth"}, 10, Deuterocanon},
+	{"WIS", "Wis", "Wisdom of Solomon", []string{"Wis", "Wisd", "Wisdom"}, 19, Deuterocanon},
+	{"SIR", "Sir", "Sirach", []string{"Sir", "Ecclesiasticus", "Ecclus"}, 51, Deuterocanon},
+	{"BAR", "Bar", "Baruch", []string{"Bar"}, 5, Deuterocanon},
+	{"LJE", "EpJer", "Letter of Jeremiah", []string{"Ep Jer", "EpJer", "Let Jer"}, 1, Deuterocanon},
+	{"S3Y", "PrAzar", "Song of the Three Young Men", []string{"Pr Azar", "Song of Three", "Sg Three"}, 1, Deuterocanon},
+	{"SUS", "Sus", "Susanna", []string{"Sus"}, 1, Deuterocanon},
+	{"BEL", "Bel", "Bel and the Dragon", []string{"Bel"}, 1, Deuterocanon},
+	{"1MA", "1Macc", "1 Maccabees", []string{"1 Macc", "1 Mac", "1 Ma"}, 16, Deuterocanon},
+	{"2MA", "2Macc", "2 Maccabees", []string{"2 Macc", "2 Mac", "2 Ma"}, 15, Deuterocanon},
+	{"3MA", "3Macc", "3 Maccabees", []string{"3 Macc", "3 Mac", "3 Ma"}, 7, Deuterocanon},
+	{"4MA", "4Macc", "4 Maccabees", []string{"4 Macc", "4 Mac", "4 Ma"}, 18, Deuterocanon},
+	{"1ES", "1Esd", "1 Esdras", []string{"1 Esd", "1 Es"}, 9, Deuterocanon},
+	{"2ES", "2Esd", "2 Esdras", []string{"2 Esd", "2 Es"}, 16, Deuterocanon},
+	{"MAN", "PrMan", "Prayer of Manasseh", []string{"Pr Man", "PrMan", "Manasseh"}, 1, Deuterocanon},
+	{"PS2", "AddPs", "Psalm 151", []string{"AddPs"}, 1, Deuterocanon},
+}
+
+var (
+	booksByUSFM = map[string]BookInfo{}
+	booksByOSIS = map[string]BookInfo{}
+)
+
+func init() {
+	for _, b := range Canon {
+		booksByUSFM[b.USFM] = b
+		booksByOSIS[strings.ToUpper(b.OSIS)] = b
+	}
+}
+
+// BookByUSFM looks a book up by its USFM code, ignoring case.
+func BookByUSFM(code string) (BookInfo, bool) {
+	b, ok := booksByUSFM[strings.ToUpper(strings.TrimSpace(code))]
+	return b, ok
+}
+
+// BookByOSIS looks a book up by its OSIS name, ignoring case.
+func BookByOSIS(name string) (BookInfo, bool) {
+	b, ok := booksByOSIS[strings.ToUpper(strings.TrimSpace(name))]
+	return b, ok
+}
+
+// ProtestantCanon returns the 66 books of the Old and New Testaments.
+func ProtestantCanon() []BookInfo {
+	books := make([]BookInfo, 0, 66)
+	for _, b := range Canon {
+		if b.Testament != Deuterocanon {
+			books = append(books, b)
+		}
+	}
+	return books
+}
+
+// position returns a book's place in Canon, or len(Canon) for books not in
+// it, so unknown books sort last.
+func position(usfm string) int {
+	for i, b := range Canon {
+		if b.USFM == usfm {
+			return i
+		}
+	}
+	return len(Canon)
+}
+
+// ParseChapterID splits a chapter ID such as "JHN.3" or "jhn 3", checking
+// the book exists and has that chapter.
+func ParseChapterID(id string) (BookInfo, int, bool) {
+	code, chapter, ok := strings.Cut(strings.ReplaceAll(strings.TrimSpace(id), " ", "."), ".")
+	if !ok {
+		return BookInfo{}, 0, false
+	}
+	b, ok := BookByUSFM(code)
+	n := leadingInt(chapter)
+	if !ok || n < 1 || n > b.Chapters || chapter != strconv.Itoa(n) {
+		return BookInfo{}, 0, false
+	}
+	return b, n, true
+}
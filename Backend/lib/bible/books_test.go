@@ -0,0 +1,185 @@
+package bible
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// wantBooks is every book as USFM|OSIS|name|chapters, in canonical order.
+const wantBooks = `GEN|Gen|Genesis|50
+EXO|Exod|Exodus|40
+LEV|Lev|Leviticus|27
+NUM|Num|Numbers|36
+DEU|Deut|Deuteronomy|34
+JOS|Josh|Joshua|24
+JDG|Judg|Judges|21
+RUT|Ruth|Ruth|4
+1SA|1Sam|1 Samuel|31
+2SA|2Sam|2 Samuel|24
+1KI|1Kgs|1 Kings|22
+2KI|2Kgs|2 Kings|25
+1CH|1Chr|1 Chronicles|29
+2CH|2Chr|2 Chronicles|36
+EZR|Ezra|Ezra|10
+NEH|Neh|Nehemiah|13
+EST|Esth|Esther|10
+JOB|Job|Job|42
+PSA|Ps|Psalms|150
+PRO|Prov|Proverbs|31
+ECC|Eccl|Ecclesiastes|12
+SNG|Song|Song of Solomon|8
+ISA|Isa|Isaiah|66
+JER|Jer|Jeremiah|52
+LAM|Lam|Lamentations|5
+EZK|Ezek|Ezekiel|48
+DAN|Dan|Daniel|12
+HOS|Hos|Hosea|14
+JOL|Joel|Joel|3
+AMO|Amos|Amos|9
+OBA|Obad|Obadiah|1
+JON|Jonah|Jonah|4
+MIC|Mic|Micah|7
+NAM|Nah|Nahum|3
+HAB|Hab|Habakkuk|3
+ZEP|Zeph|Zephaniah|3
+HAG|Hag|Haggai|2
+ZEC|Zech|Zechariah|14
+MAL|Mal|Malachi|4
+MAT|Matt|Matthew|28
+MRK|Mark|Mark|16
+LUK|Luke|Luke|24
+JHN|John|John|21
+ACT|Acts|Acts|28
+ROM|Rom|Romans|16
+1CO|1Cor|1 Corinthians|16
+2CO|2Cor|2 Corinthians|13
+GAL|Gal|Galatians|6
+EPH|Eph|Ephesians|6
+PHP|Phil|Philippians|4
+COL|Col|Colossians|4
+1TH|1Thess|1 Thessalonians|5
+2TH|2Thess|2 Thessalonians|3
+1TI|1Tim|1 Timothy|6
+2TI|2Tim|2 Timothy|4
+TIT|Titus|Titus|3
+PHM|Phlm|Philemon|1
+HEB|Heb|Hebrews|13
+JAS|Jas|James|5
+1PE|1Pet|1 Peter|5
+2PE|2Pet|2 Peter|3
+1JN|1John|1 John|5
+2JN|2John|2 John|1
+3JN|3John|3 John|1
+JUD|Jude|Jude|1
+REV|Rev|Revelation|22
+TOB|Tob|Tobit|14
+JDT|Jdt|Judith|16
+ESG|EsthGr|Esther (Greek)|10
+WIS|Wis|Wisdom of Solomon|19
+SIR|Sir|Sirach|51
+BAR|Bar|Baruch|5
+LJE|EpJer|Letter of Jeremiah|1
+S3Y|PrAzar|Song of the Three Young Men|1
+SUS|Sus|Susanna|1
+BEL|Bel|Bel and the Dragon|1
+1MA|1Macc|1 Maccabees|16
+2MA|2Macc|2 Maccabees|15
+3MA|3Macc|3 Maccabees|7
+4MA|4Macc|4 Maccabees|18
+1ES|1Esd|1 Esdras|9
+2ES|2Esd|2 Esdras|16
+MAN|PrMan|Prayer of Manasseh|1
+PS2|AddPs|Psalm 151|1`
+
+type wantBook struct {
+	usfm, osis, name string
+	chapters         int
+}
+
+func parseWantBooks(t *testing.T) []wantBook {
+	var books []wantBook
+	for _, line := range strings.Split(wantBooks, "\n") {
+		f := strings.Split(line, "|")
+		n, err := strconv.Atoi(f[3])
+		if err != nil {
+			t.Fatal(line)
+		}
+		books = append(books, wantBook{f[0], f[1], f[2], n})
+	}
+	return books
+}
+
+func TestCanon(t *testing.T) {
+	want := parseWantBooks(t)
+	if len(Canon) != len(want) {
+		t.Fatalf("Canon has %d books, want %d", len(Canon), len(want))
+	}
+	if n := len(ProtestantCanon()); n != 66 {
+		t.Errorf("ProtestantCanon() has %d books, want 66", n)
+	}
+
+	chapters := 0
+	for i, w := range want {
+		t.Run(w.usfm, func(t *testing.T) {
+			if Canon[i].USFM != w.usfm {
+				t.Errorf("Canon[%d] = %s, want %s", i, Canon[i].USFM, w.usfm)
+			}
+
+			b, ok := BookByUSFM(strings.ToLower(w.usfm))
+			if !ok || b.OSIS != w.osis || b.Name != w.name || b.Chapters != w.chapters {
+				t.Errorf("BookByUSFM(%q) = %+v, %v", w.usfm, b, ok)
+			}
+			if b, ok := BookByOSIS(w.osis); !ok || b.USFM != w.usfm {
+				t.Errorf("BookByOSIS(%q) = %s, %v; want %s", w.osis, b.USFM, ok, w.usfm)
+			}
+			if len(b.Abbreviations) == 0 {
+				t.Errorf("%s has no abbreviations", w.usfm)
+			}
+
+			last := w.usfm + "." + strconv.Itoa(w.chapters)
+			if got, n, ok := ParseChapterID(last); !ok || got.USFM != w.usfm || n != w.chapters {
+				t.Errorf("ParseChapterID(%q) = %s, %d, %v", last, got.USFM, n, ok)
+			}
+			for _, bad := range []string{w.usfm + ".0", w.usfm + "." + strconv.Itoa(w.chapters+1), w.usfm + ".01"} {
+				if _, _, ok := ParseChapterID(bad); ok {
+					t.Errorf("ParseChapterID(%q) accepted", bad)
+				}
+			}
+		})
+		if i < 66 {
+			chapters += w.chapters
+		}
+	}
+	if chapters != 1189 {
+		t.Errorf("Protestant canon has %d chapters, want 1189", chapters)
+	}
+}
+
+// TestESVBookNames checks every book the ESV serves is requested by its
+// full name, which the ESV API accepts for all of them.
+func TestESVBookNames(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("q")
+		w.Write([]byte(`{"passages":["[1] In the beginning"]}`))
+	}))
+	defer srv.Close()
+	e := &ESV{BaseURL: srv.URL, Key: "k", Client: srv.Client()}
+
+	for _, w := range parseWantBooks(t) {
+		_, err := e.Passage(context.Background(), "ESV", w.usfm+".1")
+		if w.usfm == "TOB" {
+			if err == nil {
+				t.Error("ESV served a deuterocanonical book")
+			}
+			break
+		}
+		if err != nil || query != w.name+" 1" {
+			t.Errorf("%s.1: queried %q, %v; want %q", w.usfm, query, err, w.name+" 1")
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package bible
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"maps"
+	"strings"
+	"sync"
+	"theword/Backend/lib/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CachePolicy says how long a response may be kept and where.
+type CachePolicy struct {
+	TTL       time.Duration // 0 disables caching
+	MaxVerses int           // most verses of one translation held at once, 0 for no limit
+	Persist   bool          // may also be kept in the database table
+}
+
+// Cache keeps upstream responses in memory, evicting the least recently used
+// once MaxBytes is reached, and optionally in a database table so they
+// survive restarts. Concurrent requests for the same response share one
+// upstream call.
+type Cache struct {
+	MaxBytes int
+	Static   CachePolicy            // translation, book and chapter lists
+	Default  CachePolicy            // passages of translations without a policy of their own
+	Policies map[string]CachePolicy // passages, by translation ID
+	DB       *gorm.DB               // nil keeps the cache in memory only
+
+	mu        sync.Mutex
+	lru       *list.List // of *cacheEntry, most recently used first
+	entries   map[string]*list.Element
+	bytes     int
+	verses    map[string]int // cached verses per translation
+	calls     map[string]*cacheCall
+	lastPrune time.Time
+}
+
+// DefaultCachePolicies keeps within the ESV API's terms, which cap stored
+// text at 500 verses. Passages are never persisted by default, since
+// publishers' terms generally forbid building a local copy of their text.
+var DefaultCachePolicies = map[string]CachePolicy{
+	esvID: {TTL: 24 * time.Hour, MaxVerses: 500},
+}
+
+func NewCache(maxBytes int, db *gorm.DB) *Cache {
+	return &Cache{
+		MaxBytes: maxBytes,
+		Static:   CachePolicy{TTL: 7 * 24 * time.Hour, Persist: true},
+		Default:  CachePolicy{TTL: time.Hour},
+		Policies: DefaultCachePolicies,
+		DB:       db,
+	}
+}
+
+type cacheEntry struct {
+	key           string
+	translationID string
+	value         []byte
+	verses        int
+	expiresAt     time.Time
+}
+
+type cacheCall struct {
+	done  chan struct{}
+	value []byte
+	err   error
+}
+
+// PassagePolicy returns the policy for a translation's passages.
+func (c *Cache) PassagePolicy(translationID string) CachePolicy {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if p, ok := c.Policies[strings.ToUpper(translationID)]; ok {
+		return p
+	}
+	return c.Default
+}
+
+// SetPassagePolicy changes the policy for a translation's passages while the
+// cache is in use. Responses already cached keep their expiry.
+func (c *Cache) SetPassagePolicy(translationID string, policy CachePolicy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Policies may be DefaultCachePolicies, so copy rather than write to it.
+	policies := maps.Clone(c.Policies)
+	if policies == nil {
+		policies = map[string]CachePolicy{}
+	}
+	policies[strings.ToUpper(translationID)] = policy
+	c.Policies = policies
+}
+
+// Wrap returns a provider that answers from the cache where it can.
+func (c *Cache) Wrap(p Provider) Provider {
+	if c == nil {
+		return p
+	}
+	return cachedProvider{p, c}
+}
+
+// get returns the cached response for kind of data about item in a
+// translation, calling fetch on a miss. The translation ID is upper-cased so
+// every spelling of one shares its entries and verse limit.
+func (c *Cache) get(ctx context.Context, kind, translationID, item string, policy CachePolicy,
+	fetch func(context.Context) (map[string]interface{}, error)) (map[string]interface{}, error) {
+
+	cacheID := strings.ToUpper(translationID)
+	key := kind
+	if cacheID != "" {
+		key += ":" + cacheID
+	}
+	if item != "" {
+		key += ":" + strings.ToUpper(item)
+	}
+
+	c.mu.Lock()
+	if c.entries == nil {
+		c.lru = list.New()
+		c.entries = map[string]*list.Element{}
+		c.verses = map[string]int{}
+		c.calls = map[string]*cacheCall{}
+	}
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*cacheEntry)
+		if time.Now().Before(entry.expiresAt) {
+			c.lru.MoveToFront(el)
+			c.mu.Unlock()
+			return decodeCached(entry.value)
+		}
+		c.remove(el)
+	}
+
+	call, inFlight := c.calls[key]
+	if !inFlight {
+		call = &cacheCall{done: make(chan struct{})}
+		c.calls[key] = call
+	}
+	c.mu.Unlock()
+
+	if !inFlight {
+		// The shared call outlives any one caller giving up.
+		go c.load(context.WithoutCancel(ctx), call, key, cacheID, translationID, policy, fetch)
+	}
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if call.err != nil {
+		return nil, call.err
+	}
+	return decodeCached(call.value)
+}
+
+// load fills a shared call. Entries are counted under cacheID; translationID
+// is the ID as sent, which cached passages are indexed for search under.
+func (c *Cache) load(ctx context.Context, call *cacheCall, key, cacheID, translationID string, policy CachePolicy,
+	fetch func(context.Context) (map[string]interface{}, error)) {
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.calls, key)
+		c.mu.Unlock()
+		close(call.done)
+	}()
+
+	if policy.TTL > 0 && policy.Persist && c.DB != nil {
+		var row models.BibleCacheEntry
+		err := c.DB.WithContext(ctx).Where("cache_key = ? AND expires_at > ?", key, time.Now()).First(&row).Error
+		if err == nil {
+			call.value = row.Value
+			c.store(&cacheEntry{key, cacheID, row.Value, row.Verses, row.ExpiresAt}, policy)
+			return
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Bible cache lookup failed: %v", err)
+		}
+	}
+
+	result, err := fetch(ctx)
+	if err != nil {
+		call.err = err
+		return
+	}
+	call.value, call.err = json.Marshal(result)
+	if call.err != nil || policy.TTL <= 0 {
+		return
+	}
+
+	entry := &cacheEntry{key, cacheID, call.value, countVerses(result), time.Now().Add(policy.TTL)}
+	c.store(entry, policy)
+	if policy.Persist && c.DB != nil {
+		c.persist(ctx, entry, translationID)
+	}
+}
+
+// store adds an entry to memory, evicting to stay within the size bound and
+// the translation's verse limit.
+func (c *Cache) store(entry *cacheEntry, policy CachePolicy) {
+	if len(entry.value) > c.MaxBytes || (policy.MaxVerses > 0 && entry.verses > policy.MaxVerses) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[entry.key]; ok {
+		c.remove(el)
+	}
+	for el := c.lru.Back(); el != nil && c.bytes+len(entry.value) > c.MaxBytes; {
+		prev := el.Prev()
+		c.remove(el)
+		el = prev
+	}
+	if policy.MaxVerses > 0 {
+		for el := c.lru.Back(); el != nil && c.verses[entry.translationID]+entry.verses > policy.MaxVerses; {
+			prev := el.Prev()
+			if el.Value.(*cacheEntry).translationID == entry.translationID {
+				c.remove(el)
+			}
+			el = prev
+		}
+	}
+
+	c.entries[entry.key] = c.lru.PushFront(entry)
+	c.bytes += len(entry.value)
+	c.verses[entry.translationID] += entry.verses
+}
+
+// remove drops an entry from memory. c.mu must be held.
+func (c *Cache) remove(el *list.Element) {
+	entry := c.lru.Remove(el).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= len(entry.value)
+	c.verses[entry.translationID] -= entry.verses
+}
+
+func (c *Cache) persist(ctx context.Context, entry *cacheEntry, translationID string) {
+	db := c.DB.WithContext(ctx)
+	row := models.BibleCacheEntry{
+		CacheKey:      entry.key,
+		TranslationID: entry.translationID,
+		Value:         entry.value,
+		Verses:        entry.verses,
+		ExpiresAt:     entry.expiresAt,
+	}
+	if err := db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&row).Error; err != nil {
+		log.Printf("Failed to persist Bible cache entry: %v", err)
+		return
+	}
+	if chapterID, ok := strings.CutPrefix(entry.key, "passage:"+entry.translationID+":"); ok && validChapterID(chapterID) {
+		if err := indexCachedChapter(ctx, c.DB, translationID, chapterID, entry.value, entry.expiresAt); err != nil {
+			log.Printf("Failed to index cached passage for search: %v", err)
+		}
+	}
+
+	c.mu.Lock()
+	prune := time.Since(c.lastPrune) > time.Hour
+	if prune {
+		c.lastPrune = time.Now()
+	}
+	c.mu.Unlock()
+	if prune {
+		db.Where("expires_at <= ?", time.Now()).Delete(&models.BibleCacheEntry{})
+		db.Where("expires_at <= ?", time.Now()).Delete(&models.BibleSearchVerse{})
+	}
+}
+
+func decodeCached(value []byte) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := json.Unmarshal(value, &result)
+	return result, err
+}
+
+// countVerses counts the verse tags in a response, which is what licence
+// limits are expressed in.
+func countVerses(v interface{}) int {
+	n := 0
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if v["name"] == "verse" {
+			n++
+		}
+		for _, child := range v {
+			n += countVerses(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			n += countVerses(child)
+		}
+	}
+	return n
+}
+
+type cachedProvider struct {
+	Provider
+	cache *Cache
+}
+
+func (p cachedProvider) Translations(ctx context.Context) ([]interface{}, error) {
+	result, err := p.cache.get(ctx, "translations", "", "", p.cache.Static, func(ctx context.Context) (map[string]interface{}, error) {
+		data, err := p.Provider.Translations(ctx)
+		return map[string]interface{}{"data": data}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	data, _ := result["data"].([]interface{})
+	return data, nil
+}
+
+func (p cachedProvider) Books(ctx context.Context, translationID string) (map[string]interface{}, error) {
+	return p.cache.get(ctx, "books", translationID, "", p.cache.Static, func(ctx context.Context) (map[string]interface{}, error) {
+		return p.Provider.Books(ctx, translationID)
+	})
+}
+
+func (p cachedProvider) Chapters(ctx context.Context, translationID, bookID string) (map[string]interface{}, error) {
+	return p.cache.get(ctx, "chapters", translationID, bookID, p.cache.Static, func(ctx context.Context) (map[string]interface{}, error) {
+		return p.Provider.Chapters(ctx, translationID, bookID)
+	})
+}
+
+// Passage caches the plain ESV apart from the full text, and does not index
+// it for search.
+func (p cachedProvider) Passage(ctx context.Context, translationID, reference string) (map[string]interface{}, error) {
+	kind := "passage"
+	if esv, ok := p.Provider.(*ESV); ok && esv.Plain {
+		kind = "plain passage"
+	}
+	return p.cache.get(ctx, kind, translationID, reference, p.cache.PassagePolicy(translationID), func(ctx context.Context) (map[string]interface{}, error) {
+		return p.Provider.Passage(ctx, translationID, reference)
+	})
+}
+
+func validChapterID(id string) bool {
+	_, _, ok := ParseChapterID(id)
+	return ok
+}
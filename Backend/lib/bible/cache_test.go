@@ -0,0 +1,157 @@
+package bible
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"theword/Backend/lib/models"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// countingProvider serves passages of n verses and counts upstream calls.
+type countingProvider struct {
+	Provider
+	calls atomic.Int32
+	n     int
+	wait  chan struct{}
+}
+
+func (p *countingProvider) Passage(ctx context.Context, translationID, reference string) (map[string]interface{}, error) {
+	p.calls.Add(1)
+	if p.wait != nil {
+		<-p.wait
+	}
+	verses := []interface{}{}
+	for i := 1; i <= p.n; i++ {
+		verses = append(verses, map[string]interface{}{"name": "verse", "attrs": map[string]interface{}{"sid": reference + "." + strconv.Itoa(i)}})
+	}
+	return map[string]interface{}{"data": map[string]interface{}{"content": verses}}, nil
+}
+
+func (p *countingProvider) Books(ctx context.Context, translationID string) (map[string]interface{}, error) {
+	p.calls.Add(1)
+	return map[string]interface{}{"data": []interface{}{map[string]interface{}{"id": "GEN"}}}, nil
+}
+
+func TestCacheCoalescesConcurrentRequests(t *testing.T) {
+	upstream := &countingProvider{n: 3, wait: make(chan struct{})}
+	p := NewCache(1<<20, nil).Wrap(upstream)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := p.Passage(context.Background(), "KJV", "JHN.3"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(upstream.wait)
+	wg.Wait()
+
+	if _, err := p.Passage(context.Background(), "KJV", "JHN.3"); err != nil {
+		t.Fatal(err)
+	}
+	if n := upstream.calls.Load(); n != 1 {
+		t.Errorf("upstream called %d times, want 1", n)
+	}
+}
+
+func TestCacheVerseLimit(t *testing.T) {
+	upstream := &countingProvider{n: 200}
+	cache := NewCache(1<<20, nil)
+	p := cache.Wrap(upstream)
+	ctx := context.Background()
+
+	// ESV allows 500 verses, so the third chapter evicts the first.
+	for _, ch := range []string{"PSA.1", "PSA.2", "PSA.3"} {
+		p.Passage(ctx, "ESV", ch)
+	}
+	if cache.verses["ESV"] > 500 {
+		t.Errorf("holding %d ESV verses", cache.verses["ESV"])
+	}
+	p.Passage(ctx, "ESV", "PSA.3")
+	if n := upstream.calls.Load(); n != 3 {
+		t.Errorf("upstream called %d times, want 3", n)
+	}
+	p.Passage(ctx, "ESV", "PSA.1")
+	if n := upstream.calls.Load(); n != 4 {
+		t.Errorf("evicted chapter served from cache; upstream called %d times, want 4", n)
+	}
+
+	// Every spelling of a translation ID shares its entries and limit.
+	for _, id := range []string{"esv", "Esv", "ESV"} {
+		p.Passage(ctx, id, "PSA.4")
+	}
+	if n := upstream.calls.Load(); n != 5 {
+		t.Errorf("upstream called %d times for one chapter in three spellings, want 5", n)
+	}
+	for _, id := range []string{"esv", "Esv"} {
+		p.Passage(ctx, id, "PSA.5")
+	}
+	if cache.verses["ESV"] > 500 || cache.verses["esv"] != 0 || cache.verses["Esv"] != 0 {
+		t.Errorf("verse counts %v, want at most 500 under ESV", cache.verses)
+	}
+
+	// Translations without a limit keep everything.
+	for _, ch := range []string{"PSA.1", "PSA.2", "PSA.3"} {
+		p.Passage(ctx, "KJV", ch)
+	}
+	if cache.verses["KJV"] != 600 {
+		t.Errorf("holding %d KJV verses, want 600", cache.verses["KJV"])
+	}
+}
+
+func TestCacheSizeBoundAndExpiry(t *testing.T) {
+	upstream := &countingProvider{n: 1}
+	cache := NewCache(300, nil)
+	cache.Default.TTL = 50 * time.Millisecond
+	p := cache.Wrap(upstream)
+	ctx := context.Background()
+
+	for i := 1; i <= 10; i++ {
+		p.Passage(ctx, "KJV", "GEN."+strconv.Itoa(i))
+	}
+	if cache.bytes > cache.MaxBytes {
+		t.Errorf("holding %d bytes, bound is %d", cache.bytes, cache.MaxBytes)
+	}
+
+	p.Passage(ctx, "KJV", "GEN.10")
+	calls := upstream.calls.Load()
+	time.Sleep(60 * time.Millisecond)
+	p.Passage(ctx, "KJV", "GEN.10")
+	if upstream.calls.Load() != calls+1 {
+		t.Error("expired entry served from cache")
+	}
+}
+
+func TestCachePersists(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "cache.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.AutoMigrate(&models.BibleCacheEntry{})
+	upstream := &countingProvider{n: 1}
+	ctx := context.Background()
+
+	NewCache(1<<20, db).Wrap(upstream).Books(ctx, "KJV")
+	NewCache(1<<20, db).Wrap(upstream).Books(ctx, "KJV")
+	if n := upstream.calls.Load(); n != 1 {
+		t.Errorf("upstream called %d times across restarts, want 1", n)
+	}
+
+	NewCache(1<<20, db).Wrap(upstream).Passage(ctx, "KJV", "GEN.1")
+	var count int64
+	db.Model(&models.BibleCacheEntry{}).Where("cache_key LIKE ?", "passage:%").Count(&count)
+	if count != 0 {
+		t.Error("passage text was persisted")
+	}
+}
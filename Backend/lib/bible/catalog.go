@@ -0,0 +1,290 @@
+package bible
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+
+	"theword/Backend/lib/models"
+
+	"gorm.io/gorm"
+)
+
+// Attribution is the notice a translation's text must be shown with.
+type Attribution struct {
+	TranslationID string `json:"translation_id"`
+	Name          string `json:"name,omitempty"`
+	Copyright     string `json:"copyright"`
+	LicenseURL    string `json:"license_url,omitempty"`
+}
+
+// Providers that catalog entries record.
+const (
+	ProviderLocal    = "local"
+	ProviderESV      = "esv"
+	ProviderAPIBible = "api.bible"
+)
+
+// knownTerms are the terms of translations whose publishers' conditions we
+// know, added to the catalog when it lacks them. Crossway allows at most 500
+// verses per view or cached, with its copyright notice.
+var knownTerms = []models.BibleCatalogEntry{{
+	TranslationID: esvID,
+	Name:          "English Standard Version",
+	Abbreviation:  "ESV",
+	Language:      "eng",
+	Provider:      ProviderESV,
+	Copyright: "Scripture quotations are from the ESV® Bible (The Holy Bible, English Standard Version®), " +
+		"© 2001 by Crossway, a publishing ministry of Good News Publishers. Used by permission. All rights reserved.",
+	LicenseURL:     "https://api.esv.org/docs/#conditions",
+	MaxVerses:      500,
+	CacheAllowed:   true,
+	CacheMaxVerses: 500,
+	Enabled:        true,
+}}
+
+// Catalog is the translation catalog held in memory, by upper-case ID. A
+// translation it doesn't list is served on default terms.
+type Catalog struct {
+	mu      sync.RWMutex
+	entries map[string]models.BibleCatalogEntry
+}
+
+// Entry returns a translation's catalog entry.
+func (c *Catalog) Entry(translationID string) (models.BibleCatalogEntry, bool) {
+	if c == nil {
+		return models.BibleCatalogEntry{}, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.entries[strings.ToUpper(translationID)]
+	return e, ok
+}
+
+// Enabled reports whether a translation may be listed and served.
+func (c *Catalog) Enabled(translationID string) bool {
+	e, ok := c.Entry(translationID)
+	return !ok || e.Enabled
+}
+
+// Disabled lists the upper-case IDs of the translations admins have
+// disabled.
+func (c *Catalog) Disabled() []string {
+	if c == nil {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var ids []string
+	for id, e := range c.entries {
+		if !e.Enabled {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// LoadCatalog reads the catalog from the database and applies each entry's
+// caching terms.
+func (p *Providers) LoadCatalog(ctx context.Context) error {
+	var rows []models.BibleCatalogEntry
+	if err := p.Local.DB.WithContext(ctx).Find(&rows).Error; err != nil {
+		return err
+	}
+	entries := make(map[string]models.BibleCatalogEntry, len(rows))
+	for _, e := range rows {
+		entries[strings.ToUpper(e.TranslationID)] = e
+		if p.Cache != nil && e.Provider != ProviderLocal {
+			p.Cache.SetPassagePolicy(e.TranslationID, p.cachePolicy(e))
+		}
+	}
+
+	if p.Catalog == nil {
+		p.Catalog = &Catalog{}
+	}
+	p.Catalog.mu.Lock()
+	p.Catalog.entries = entries
+	p.Catalog.mu.Unlock()
+	return nil
+}
+
+// cachePolicy turns an entry's caching terms into a cache policy, keeping
+// the built-in policy's TTL.
+func (p *Providers) cachePolicy(e models.BibleCatalogEntry) CachePolicy {
+	policy, ok := DefaultCachePolicies[strings.ToUpper(e.TranslationID)]
+	if !ok {
+		policy = p.Cache.Default
+	}
+	if !e.CacheAllowed {
+		policy.TTL = 0
+	}
+	policy.MaxVerses = e.CacheMaxVerses
+	policy.Persist = e.CachePersist
+	return policy
+}
+
+// SyncCatalog adds the translations the providers serve that the catalog
+// lacks, enabled, with known terms where there are any, then reloads it.
+// Entries already there are left as admins set them.
+func (p *Providers) SyncCatalog(ctx context.Context) error {
+	db := p.Local.DB.WithContext(ctx)
+	var existing []string
+	if err := db.Model(&models.BibleCatalogEntry{}).Pluck("translation_id", &existing).Error; err != nil {
+		return err
+	}
+	have := map[string]bool{}
+	for _, id := range existing {
+		have[strings.ToUpper(id)] = true
+	}
+	add := func(e models.BibleCatalogEntry) {
+		e.TranslationID = strings.ToUpper(e.TranslationID)
+		if e.TranslationID == "" || have[e.TranslationID] {
+			return
+		}
+		have[e.TranslationID] = true
+		if err := db.Create(&e).Error; err != nil {
+			log.Printf("Failed to add %s to the translation catalog: %v", e.TranslationID, err)
+		}
+	}
+
+	for _, e := range knownTerms {
+		add(e)
+	}
+
+	var stored []models.BibleTranslation
+	if err := db.Find(&stored).Error; err != nil {
+		return err
+	}
+	for _, t := range stored {
+		add(models.BibleCatalogEntry{
+			TranslationID: t.TranslationID,
+			Name:          t.Name,
+			Abbreviation:  t.Abbreviation,
+			Language:      t.Language,
+			Provider:      ProviderLocal,
+			CacheAllowed:  true,
+			Enabled:       true,
+		})
+	}
+
+	remote, err := p.Cache.Wrap(p.APIBible).Translations(ctx)
+	if err != nil {
+		log.Printf("Adding only stored translations to the catalog: %v", err)
+	}
+	for _, item := range remote {
+		entry, _ := item.(map[string]interface{})
+		id, _ := entry["id"].(string)
+		name, _ := entry["name"].(string)
+		abbreviation, _ := entry["abbreviation"].(string)
+		language, _ := entry["language"].(map[string]interface{})
+		languageID, _ := language["id"].(string)
+		add(models.BibleCatalogEntry{
+			TranslationID: id,
+			Name:          name,
+			Abbreviation:  abbreviation,
+			Language:      languageID,
+			Provider:      ProviderAPIBible,
+			CacheAllowed:  true,
+			Enabled:       true,
+		})
+	}
+
+	return p.LoadCatalog(ctx)
+}
+
+// SaveCatalogEntry stores an entry under its upper-case ID, replacing any
+// other spelling of the ID, and reloads the catalog.
+func (p *Providers) SaveCatalogEntry(ctx context.Context, e *models.BibleCatalogEntry) error {
+	e.TranslationID = strings.ToUpper(e.TranslationID)
+	err := p.Local.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("UPPER(translation_id) = ? AND translation_id <> ?", e.TranslationID, e.TranslationID).
+			Delete(&models.BibleCatalogEntry{}).Error; err != nil {
+			return err
+		}
+		return tx.Save(e).Error
+	})
+	if err != nil {
+		return err
+	}
+	return p.LoadCatalog(ctx)
+}
+
+// Attribution returns the notice for a translation's text: the catalog's
+// copyright, or else the one the provider sent with the passage in result.
+func (p *Providers) Attribution(translationID string, result map[string]interface{}) *Attribution {
+	a := &Attribution{TranslationID: translationID}
+	if e, ok := p.Catalog.Entry(translationID); ok {
+		a.Name, a.Copyright, a.LicenseURL = e.Name, e.Copyright, e.LicenseURL
+	}
+	if a.Copyright == "" {
+		data, _ := result["data"].(map[string]interface{})
+		a.Copyright, _ = data["copyright"].(string)
+	}
+	return a
+}
+
+// checkLength refuses to show more verses at once than a translation's
+// licence allows.
+func (p *Providers) checkLength(translationID string, verses int) error {
+	if e, ok := p.Catalog.Entry(translationID); ok && e.MaxVerses > 0 && verses > e.MaxVerses {
+		return ErrPassageTooLong
+	}
+	return nil
+}
+
+// catalogEntries applies the catalog to a translations list: disabled
+// translations are dropped and the rest carry the catalog's name and terms.
+func (p *Providers) catalogEntries(entries []interface{}) []interface{} {
+	listed := entries[:0]
+	for _, item := range entries {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			listed = append(listed, item)
+			continue
+		}
+		id, _ := entry["id"].(string)
+		e, ok := p.Catalog.Entry(id)
+		if !ok {
+			listed = append(listed, entry)
+			continue
+		}
+		if !e.Enabled {
+			continue
+		}
+		if e.Name != "" {
+			entry["name"] = e.Name
+		}
+		if e.Abbreviation != "" {
+			entry["abbreviation"] = e.Abbreviation
+		}
+		if _, ok := entry["language"]; !ok && e.Language != "" {
+			entry["language"] = map[string]interface{}{"id": e.Language}
+		}
+		if e.Copyright != "" {
+			entry["copyright"] = e.Copyright
+		}
+		if e.MaxVerses > 0 {
+			entry["maxVerses"] = e.MaxVerses
+		}
+		listed = append(listed, entry)
+	}
+	return listed
+}
+
+// unavailable stands in for the provider of a disabled translation.
+type unavailable struct{}
+
+func (unavailable) Translations(ctx context.Context) ([]interface{}, error) { return nil, nil }
+
+func (unavailable) Books(ctx context.Context, translationID string) (map[string]interface{}, error) {
+	return nil, ErrNotFound
+}
+
+func (unavailable) Chapters(ctx context.Context, translationID, bookID string) (map[string]interface{}, error) {
+	return nil, ErrNotFound
+}
+
+func (unavailable) Passage(ctx context.Context, translationID, reference string) (map[string]interface{}, error) {
+	return nil, ErrNotFound
+}
@@ -0,0 +1,124 @@
+package bible
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"theword/Backend/lib/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCatalog(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "bible.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.AutoMigrate(&models.BibleTranslation{}, &models.BibleBook{}, &models.BibleVerse{}, &models.BibleCatalogEntry{})
+
+	var text Text
+	src := "\\id JHN\n\\c 3\n\\p\n\\v 16 For God so loved the world.\n\\v 17 For God did not send his Son.\n\\v 18 Whoever believes is not condemned.\n"
+	if err := ParseUSFM(strings.NewReader(src), &text); err != nil {
+		t.Fatal(err)
+	}
+	if err := Import(db, models.BibleTranslation{TranslationID: "WEB", Name: "World English Bible"}, &text); err != nil {
+		t.Fatal(err)
+	}
+	p := NewProviders(db, "", "", NewCache(1<<20, nil))
+	ctx := context.Background()
+
+	// API.Bible has no key here, so only ESV and the stored WEB are added.
+	if err := p.SyncCatalog(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var entries []models.BibleCatalogEntry
+	db.Order("translation_id").Find(&entries)
+	if len(entries) != 2 || entries[0].TranslationID != "ESV" || entries[1].Provider != ProviderLocal || !entries[1].Enabled {
+		t.Fatalf("synced catalog = %+v", entries)
+	}
+	if policy := p.Cache.PassagePolicy("ESV"); policy.TTL != 24*time.Hour || policy.MaxVerses != 500 {
+		t.Errorf("ESV cache policy = %+v", policy)
+	}
+
+	web := entries[1]
+	web.Copyright, web.MaxVerses = "Public domain.", 2
+	if err := p.SaveCatalogEntry(ctx, &web); err != nil {
+		t.Fatal(err)
+	}
+	ranges, _ := ParseReference("John 3:16-17")
+	passage, err := p.Range(ctx, "WEB", ranges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := passage.Attribution; a == nil || a.Copyright != "Public domain." || a.Name != "World English Bible" {
+		t.Errorf("attribution = %+v", a)
+	}
+	if !strings.HasSuffix(passage.Text(), "\n\nPublic domain.\n") ||
+		!strings.Contains(passage.HTML(), `<p class="copyright">Public domain.</p>`) {
+		t.Errorf("rendered passage lacks the copyright:\n%s", passage.Text())
+	}
+	ranges, _ = ParseReference("John 3:16-18")
+	if _, err := p.Range(ctx, "WEB", ranges); !errors.Is(err, ErrPassageTooLong) {
+		t.Errorf("3 verses of at most 2: %v, want ErrPassageTooLong", err)
+	}
+
+	// Attribution falls back to the notice the provider sent.
+	if a := p.Attribution("XYZ", map[string]interface{}{"data": map[string]interface{}{"copyright": "© Someone"}}); a.Copyright != "© Someone" {
+		t.Errorf("fallback attribution = %+v", a)
+	}
+
+	esv := entries[0]
+	esv.Enabled, esv.CacheAllowed = false, false
+	if err := p.SaveCatalogEntry(ctx, &esv); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.For("esv").Passage(ctx, "esv", "JHN.3"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("disabled translation: %v, want ErrNotFound", err)
+	}
+	if policy := p.Cache.PassagePolicy("ESV"); policy.TTL != 0 {
+		t.Errorf("ESV cache policy with caching disallowed = %+v", policy)
+	}
+	translations, err := p.Translations(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(translations) != 1 || translations[0].(map[string]interface{})["copyright"] != "Public domain." {
+		t.Errorf("Translations() = %v, want WEB alone", translations)
+	}
+	if disabled := p.Catalog.Disabled(); len(disabled) != 1 || disabled[0] != "ESV" {
+		t.Errorf("Disabled() = %v", disabled)
+	}
+	if DefaultCachePolicies["ESV"].TTL != 24*time.Hour {
+		t.Error("SaveCatalogEntry changed DefaultCachePolicies")
+	}
+
+	// IDs are stored upper-case whatever case they arrive in, and saving
+	// replaces an entry stored under another spelling.
+	db.Create(&models.BibleCatalogEntry{TranslationID: "nasb", Provider: ProviderAPIBible, Enabled: true})
+	db.Create(&models.BibleTranslation{TranslationID: "kjv", Name: "King James Version"})
+	if err := p.SyncCatalog(ctx); err != nil {
+		t.Fatal(err)
+	}
+	esv.Enabled = true
+	esv.TranslationID = "esv"
+	if err := p.SaveCatalogEntry(ctx, &esv); err != nil {
+		t.Fatal(err)
+	}
+	nasb := models.BibleCatalogEntry{TranslationID: "Nasb", Provider: ProviderAPIBible, MaxVerses: 100, Enabled: true}
+	if err := p.SaveCatalogEntry(ctx, &nasb); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	db.Model(&models.BibleCatalogEntry{}).Order("translation_id").Pluck("translation_id", &ids)
+	if strings.Join(ids, ",") != "ESV,KJV,NASB,WEB" {
+		t.Errorf("catalog IDs = %v, want ESV,KJV,NASB,WEB", ids)
+	}
+	if e, ok := p.Catalog.Entry("nasb"); !ok || e.MaxVerses != 100 {
+		t.Errorf("NASB entry = %+v", e)
+	}
+}
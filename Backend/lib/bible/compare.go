@@ -0,0 +1,242 @@
+package bible
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"theword/Backend/lib/models"
+)
+
+// MaxCompareTranslations bounds how many translations one comparison asks
+// for.
+const MaxCompareTranslations = 8
+
+// CompareTimeout bounds how long a comparison waits for each translation.
+var CompareTimeout = 8 * time.Second
+
+var ErrTooManyTranslations = errors.New("too many translations")
+
+// Comparison lines up a passage in several translations verse by verse.
+type Comparison struct {
+	Reference    string               `json:"reference"`
+	Translations []CompareTranslation `json:"translations"`
+	Rows         []CompareRow         `json:"rows"`
+}
+
+// CompareTranslation is one column of a comparison. Error is set when the
+// translation could not be fetched; the other columns are still filled.
+type CompareTranslation struct {
+	ID            string        `json:"id"`
+	Versification Versification `json:"versification"`
+	Attribution   *Attribution  `json:"attribution,omitempty"`
+	Error         string        `json:"error,omitempty"`
+}
+
+// CompareRow is one verse, by its English ID, with each translation's text
+// in column order. A translation's verse is nil when it has no such verse,
+// as where it omits a verse or has bridged it into an earlier one.
+type CompareRow struct {
+	ID      string          `json:"id"` // e.g. "JHN.3.16"
+	Chapter int             `json:"chapter"`
+	Number  int             `json:"number"`
+	Verses  []*PassageVerse `json:"verses"`
+}
+
+// Compare fetches the ranges in each translation at once, each within
+// CompareTimeout, and aligns their verses by English verse number.
+func (p *Providers) Compare(ctx context.Context, translationIDs []string, ranges []Range) (*Comparison, error) {
+	if len(translationIDs) > MaxCompareTranslations {
+		return nil, ErrTooManyTranslations
+	}
+	chapters := 0
+	for _, r := range ranges {
+		chapters += r.EndChapter - r.StartChapter + 1
+	}
+	if chapters > MaxPassageChapters {
+		return nil, ErrPassageTooLong
+	}
+
+	c := &Comparison{
+		Reference:    FormatReference(ranges, RefFull),
+		Translations: make([]CompareTranslation, len(translationIDs)),
+		Rows:         []CompareRow{},
+	}
+	columns := make([]map[string]*PassageVerse, len(translationIDs))
+	var wg sync.WaitGroup
+	for i, id := range translationIDs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, CompareTimeout)
+			defer cancel()
+
+			v := p.Versification(ctx, id)
+			c.Translations[i] = CompareTranslation{ID: id, Versification: v}
+			verses, attribution, err := p.compareColumn(ctx, id, v, ranges)
+			if err != nil {
+				c.Translations[i].Error = compareError(ctx, id, err)
+				return
+			}
+			c.Translations[i].Attribution = attribution
+			columns[i] = verses
+		}()
+	}
+	wg.Wait()
+
+	// Columns only hold whole chapters, so licence limits are checked on
+	// the verses actually compared.
+	for i, column := range columns {
+		shown := 0
+		for id, v := range column {
+			book, _, _ := strings.Cut(id, ".")
+			if spansHold(ranges, book, v) {
+				shown++
+			}
+		}
+		if err := p.checkLength(translationIDs[i], shown); err != nil {
+			c.Translations[i].Error = compareError(ctx, translationIDs[i], err)
+			c.Translations[i].Attribution = nil
+			columns[i] = nil
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, r := range ranges {
+		for _, span := range r.Spans() {
+			// Columns hold the verses of every range; take this range's book
+			// and chapter only.
+			prefix := r.Book + "." + strconv.Itoa(span.Chapter) + "."
+			var numbers []int
+			for _, column := range columns {
+				for id, v := range column {
+					if strings.HasPrefix(id, prefix) && inSpan(span, v.Number) && !seen[id] {
+						seen[id] = true
+						numbers = append(numbers, v.Number)
+					}
+				}
+			}
+			sort.Ints(numbers)
+
+			for _, n := range numbers {
+				row := CompareRow{
+					ID:      r.Book + "." + strconv.Itoa(span.Chapter) + "." + strconv.Itoa(n),
+					Chapter: span.Chapter,
+					Number:  n,
+					Verses:  make([]*PassageVerse, len(columns)),
+				}
+				for i, column := range columns {
+					row.Verses[i] = column[row.ID]
+				}
+				c.Rows = append(c.Rows, row)
+			}
+		}
+	}
+	return c, nil
+}
+
+// compareColumn fetches the chapters holding the ranges in one translation
+// and returns its verses in them by English verse ID, with the notice they
+// must be shown with. Each verse keeps its own ID, so renumbered verses can
+// be shown as the translation numbers them; Chapter and Number are set to
+// the English ones.
+func (p *Providers) compareColumn(ctx context.Context, translationID string, v Versification, ranges []Range) (map[string]*PassageVerse, *Attribution, error) {
+	provider := p.For(translationID)
+	var attribution *Attribution
+	verses := map[string]*PassageVerse{}
+	fetched := map[string]bool{}
+	for _, r := range ranges {
+		for _, span := range r.Spans() {
+			for _, chapter := range v.Chapters(r.Book, span.Chapter) {
+				chapterID := r.Book + "." + strconv.Itoa(chapter)
+				if fetched[chapterID] {
+					continue
+				}
+				fetched[chapterID] = true
+
+				result, err := provider.Passage(ctx, translationID, chapterID)
+				if errors.Is(err, ErrNotFound) {
+					continue
+				}
+				if err != nil {
+					return nil, nil, err
+				}
+				if attribution == nil {
+					attribution = p.Attribution(translationID, result)
+				}
+
+				var titles []string
+				for _, verse := range NormalizeChapter(result, chapterID) {
+					verse.Chapter, verse.Number = v.Canonical(r.Book, verse.Chapter, verse.Number)
+					if verse.Number == 0 {
+						// Psalm titles numbered as verses head the first verse.
+						titles = append(titles, verse.Text)
+						continue
+					}
+					verse.Headings = append(titles, verse.Headings...)
+					titles = nil
+					id := r.Book + "." + strconv.Itoa(verse.Chapter) + "." + strconv.Itoa(verse.Number)
+					verses[id] = &verse
+				}
+			}
+		}
+	}
+	if len(verses) == 0 {
+		return nil, nil, ErrNotFound
+	}
+	return verses, attribution, nil
+}
+
+// spansHold reports whether a verse of book, numbered in English, is one
+// ranges ask for.
+func spansHold(ranges []Range, book string, v *PassageVerse) bool {
+	for _, r := range ranges {
+		if r.Book != book {
+			continue
+		}
+		for _, span := range r.Spans() {
+			if span.Chapter == v.Chapter && inSpan(span, v.Number) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Versification returns how a translation numbers its verses: as recorded
+// for stored translations, English otherwise.
+func (p *Providers) Versification(ctx context.Context, translationID string) Versification {
+	var t models.BibleTranslation
+	if err := p.Local.DB.WithContext(ctx).Where("translation_id = ?", translationID).Limit(1).Find(&t).Error; err == nil {
+		if v, ok := ParseVersification(t.Versification); ok {
+			return v
+		}
+	}
+	return VersificationEnglish
+}
+
+func inSpan(span Span, verse int) bool {
+	return verse >= span.StartVerse && (span.EndVerse == 0 || verse <= span.EndVerse)
+}
+
+// compareError describes why a translation is missing from a comparison,
+// logging failures that are not the reader's doing.
+func compareError(ctx context.Context, translationID string, err error) string {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return "Not found"
+	case errors.Is(err, ErrPassageTooLong):
+		return "Passage too long"
+	case errors.Is(err, ErrUnsupported):
+		return "Not available for this translation"
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return "Timed out"
+	}
+	log.Printf("Failed to fetch %s for comparison: %v", translationID, err)
+	return "Failed to fetch passage"
+}
@@ -0,0 +1,150 @@
+package bible
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"theword/Backend/lib/models"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCompare(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "bible.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.AutoMigrate(&models.BibleTranslation{}, &models.BibleBook{}, &models.BibleVerse{})
+
+	stored := map[models.BibleTranslation]string{
+		{TranslationID: "WEB"}: "\\id MAL\n\\c 3\n\\p\n\\v 18 Then you shall return.\n\\c 4\n\\p\n\\v 1 The day comes.\n\\v 2 The sun shall rise.\n" +
+			"\\id PSA\n\\c 3\n\\d A Psalm of David.\n\\q1\n\\v 1 Yahweh, how my adversaries have increased!\n",
+		{TranslationID: "HEB", Versification: string(VersificationHebrew)}: "\\id MAL\n\\c 3\n\\p\n\\v 18 You shall return.\n\\v 19 The day comes.\n\\v 20 The sun shall rise.\n" +
+			"\\id PSA\n\\c 3\n\\q1\n\\v 1 A Psalm of David.\n\\v 2 Lord, how many are my foes!\n",
+	}
+	for translation, src := range stored {
+		var text Text
+		if err := ParseUSFM(strings.NewReader(src), &text); err != nil {
+			t.Fatal(err)
+		}
+		if err := Import(db, translation, &text); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// KJV bridges 4:1-2, SLOW misses the deadline and NONE has no Malachi.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bibles/KJV/chapters/MAL.4":
+			w.Write([]byte(`{"data":{"content":[{"name":"para","type":"tag","attrs":{"style":"p"},"items":[
+				{"name":"verse","type":"tag","attrs":{"number":"1-2","style":"v","sid":"MAL 4:1-2"},"items":[{"text":"1-2","type":"text"}]},
+				{"text":"For, behold, the day cometh.","type":"text","attrs":{"verseId":"MAL.4.1"}}]}]}}`))
+		case "/bibles/SLOW/chapters/MAL.4":
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	p := NewProviders(db, "", "", nil)
+	p.APIBible = &APIBible{BaseURL: srv.URL, Key: "k", Client: srv.Client()}
+	defer func(d time.Duration) { CompareTimeout = d }(CompareTimeout)
+	CompareTimeout = 100 * time.Millisecond
+
+	ranges, _ := ParseReference("Mal 4:1-2")
+	c, err := p.Compare(context.Background(), []string{"WEB", "HEB", "KJV", "SLOW", "NONE"}, ranges)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantErrors := []string{"", "", "", "Timed out", "Not found"}
+	for i, tr := range c.Translations {
+		if tr.Error != wantErrors[i] {
+			t.Errorf("%s: error %q, want %q", tr.ID, tr.Error, wantErrors[i])
+		}
+	}
+	if c.Translations[1].Versification != VersificationHebrew {
+		t.Errorf("HEB versification = %q", c.Translations[1].Versification)
+	}
+	if len(c.Rows) != 2 || c.Rows[0].ID != "MAL.4.1" || c.Rows[1].ID != "MAL.4.2" {
+		t.Fatalf("rows = %+v", c.Rows)
+	}
+	first, second := c.Rows[0].Verses, c.Rows[1].Verses
+	if first[0].Text != "The day comes." || first[1].ID != "MAL.3.19" || first[1].Text != "The day comes." {
+		t.Errorf("Mal 4:1 = %+v, %+v", first[0], first[1])
+	}
+	if second[1].ID != "MAL.3.20" || first[2].Through != 2 || second[2] != nil || first[3] != nil {
+		t.Errorf("Mal 4:2 = %+v; KJV 4:1 = %+v", second, first[2])
+	}
+
+	ranges, _ = ParseReference("Ps 3:1")
+	c, err = p.Compare(context.Background(), []string{"WEB", "HEB"}, ranges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Rows) != 1 {
+		t.Fatalf("rows = %+v", c.Rows)
+	}
+	heb := c.Rows[0].Verses[1]
+	if heb.ID != "PSA.3.2" || len(heb.Headings) != 1 || heb.Headings[0] != "A Psalm of David." {
+		t.Errorf("HEB Ps 3:1 = %+v", heb)
+	}
+
+	// Each range gets rows for its own book only: HEB's Malachi 3:18,
+	// fetched for Mal 4, is not a verse of Psalm 3.
+	ranges, _ = ParseReference("Mal 4; Ps 3")
+	c, err = p.Compare(context.Background(), []string{"WEB", "HEB"}, ranges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, row := range c.Rows {
+		ids = append(ids, row.ID)
+		if row.Verses[0] == nil || row.Verses[1] == nil {
+			t.Errorf("row %s is missing a translation: %+v", row.ID, row.Verses)
+		}
+	}
+	if strings.Join(ids, ",") != "MAL.4.1,MAL.4.2,PSA.3.1" {
+		t.Errorf("rows = %v, want MAL.4.1,MAL.4.2,PSA.3.1", ids)
+	}
+}
+
+func TestVersificationCanonical(t *testing.T) {
+	tests := []struct {
+		book             string
+		chapter, verse   int
+		wantCh, wantVers int
+	}{
+		{"MAL", 3, 18, 3, 18},
+		{"MAL", 3, 24, 4, 6},
+		{"JOL", 3, 1, 2, 28},
+		{"JOL", 4, 21, 3, 21},
+		{"PSA", 51, 2, 51, 0},
+		{"PSA", 51, 3, 51, 1},
+		{"PSA", 23, 1, 23, 1},
+		{"EXO", 7, 26, 8, 1},
+		{"EXO", 8, 1, 8, 5},
+		{"JHN", 3, 16, 3, 16},
+	}
+	for _, tt := range tests {
+		ch, v := VersificationHebrew.Canonical(tt.book, tt.chapter, tt.verse)
+		if ch != tt.wantCh || v != tt.wantVers {
+			t.Errorf("%s %d:%d = %d:%d, want %d:%d", tt.book, tt.chapter, tt.verse, ch, v, tt.wantCh, tt.wantVers)
+		}
+		if ch, v := VersificationEnglish.Canonical(tt.book, tt.chapter, tt.verse); ch != tt.chapter || v != tt.verse {
+			t.Errorf("English %s %d:%d moved to %d:%d", tt.book, tt.chapter, tt.verse, ch, v)
+		}
+	}
+	if got := VersificationHebrew.Chapters("JOL", 3); len(got) != 2 || got[0] != 3 || got[1] != 4 {
+		t.Errorf("Chapters(JOL 3) = %v, want [3 4]", got)
+	}
+}
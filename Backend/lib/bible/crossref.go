@@ -0,0 +1,334 @@
+package bible
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"theword/Backend/lib/models"
+
+	"gorm.io/gorm"
+)
+
+// RankedVerse is a verse or passage related to a reference or a topic,
+// most voted first.
+type RankedVerse struct {
+	Reference string `json:"reference"` // e.g. "Romans 5:8"
+	VerseID   string `json:"verse_id"`  // e.g. "ROM.5.8"
+	Votes     int    `json:"votes"`
+	Text      string `json:"text,omitempty"`
+}
+
+// ImportCrossReferences replaces the cross-references from source with the
+// ones read from r, in the tab-separated layout of OpenBible.info's
+// cross_references.txt:
+//
+//	From Verse	To Verse	Votes
+//	Gen.1.1	Heb.11.3	436
+//
+// Other datasets, such as the Treasury of Scripture Knowledge, load once
+// converted to it; a missing Votes column counts as one vote. Rows voted
+// below zero, and rows whose references don't parse, are skipped. It
+// returns how many cross-references were stored.
+func ImportCrossReferences(db *gorm.DB, source string, r io.Reader) (int, error) {
+	if err := db.AutoMigrate(&models.BibleCrossReference{}); err != nil {
+		return 0, err
+	}
+	imported := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("source = ?", source).Delete(&models.BibleCrossReference{}).Error; err != nil {
+			return err
+		}
+
+		var batch []models.BibleCrossReference
+		flush := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+			imported += len(batch)
+			err := tx.Create(&batch).Error
+			batch = batch[:0]
+			return err
+		}
+		err := readVoteRows(r, "from verse", func(fields []string, votes int) error {
+			from, err := ParseReference(fields[0])
+			if err != nil || len(from) != 1 || from[0].StartVerse == 0 {
+				return nil
+			}
+			to, err := ParseReference(fields[1])
+			if err != nil {
+				return nil
+			}
+			batch = append(batch, models.BibleCrossReference{
+				Source:    source,
+				BookID:    from[0].Book,
+				Chapter:   from[0].StartChapter,
+				Verse:     from[0].StartVerse,
+				Reference: FormatReference(to, RefID),
+				Votes:     votes,
+			})
+			if len(batch) == 1000 {
+				return flush()
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return flush()
+	})
+	return imported, err
+}
+
+// ImportTopics replaces the topical index with the one read from r, in the
+// tab-separated layout of OpenBible.info's topic votes:
+//
+//	Topic	OSIS	Votes
+//	love	1John.4.8	212
+//
+// As with cross-references, rows voted below zero or whose reference
+// doesn't parse are skipped. It returns how many topics were stored.
+func ImportTopics(db *gorm.DB, r io.Reader) (int, error) {
+	if err := db.AutoMigrate(&models.BibleTopic{}, &models.BibleTopicVerse{}); err != nil {
+		return 0, err
+	}
+
+	topics := map[string]*models.BibleTopic{}
+	var order []string
+	verses := map[string][]models.BibleTopicVerse{}
+	err := readVoteRows(r, "topic", func(fields []string, votes int) error {
+		name := strings.TrimSpace(fields[0])
+		slug := topicSlug(name)
+		ranges, err := ParseReference(fields[1])
+		if slug == "" || err != nil || ranges[0].StartVerse == 0 {
+			return nil
+		}
+		if topics[slug] == nil {
+			topics[slug] = &models.BibleTopic{Slug: slug, Name: name}
+			order = append(order, slug)
+		}
+		topics[slug].Verses++
+		verses[slug] = append(verses[slug], models.BibleTopicVerse{
+			BookID:    ranges[0].Book,
+			Chapter:   ranges[0].StartChapter,
+			Verse:     ranges[0].StartVerse,
+			Reference: FormatReference(ranges, RefID),
+			Votes:     votes,
+		})
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(order), db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.BibleTopicVerse{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("1 = 1").Delete(&models.BibleTopic{}).Error; err != nil {
+			return err
+		}
+		for _, slug := range order {
+			topic := topics[slug]
+			if err := tx.Create(topic).Error; err != nil {
+				return err
+			}
+			rows := verses[slug]
+			for i := range rows {
+				rows[i].TopicID = topic.TopicID
+			}
+			if err := tx.CreateInBatches(rows, 1000).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// readVoteRows calls row with the first two fields and the votes of each
+// data row of a tab-separated file, skipping the header, whose first field
+// is header, and comment lines.
+func readVoteRows(r io.Reader, header string, row func(fields []string, votes int) error) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if strings.EqualFold(strings.TrimSpace(fields[0]), header) {
+			continue
+		}
+		if len(fields) < 2 {
+			return fmt.Errorf("line %d: want at least 2 tab-separated fields", line)
+		}
+		votes := 1
+		if len(fields) > 2 {
+			n, err := strconv.Atoi(strings.TrimSpace(fields[2]))
+			if err != nil {
+				return fmt.Errorf("line %d: votes: %w", line, err)
+			}
+			votes = n
+		}
+		if votes < 0 {
+			continue
+		}
+		if err := row(fields, votes); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+func topicSlug(name string) string {
+	return strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// CrossReferences returns the verses related to any verse in ranges, most
+// voted first, merging the votes of every source. Verses inside ranges
+// themselves are left out. A limit of 0 means no limit.
+func CrossReferences(ctx context.Context, db *gorm.DB, ranges []Range, limit, offset int) ([]RankedVerse, error) {
+	match, args := versesIn(ranges)
+	var rows []struct {
+		Reference string
+		Votes     int
+	}
+	err := db.WithContext(ctx).Model(&models.BibleCrossReference{}).
+		Select("reference, SUM(votes) AS votes").
+		Where(match, args...).
+		Group("reference").
+		Order("votes DESC, MIN(id)").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	related := []RankedVerse{}
+	for _, row := range rows {
+		to, err := ParseReference(row.Reference)
+		if err != nil || overlaps(ranges, to[0]) {
+			continue
+		}
+		related = append(related, rankedVerse(to, row.Votes))
+	}
+	if offset >= len(related) {
+		return []RankedVerse{}, nil
+	}
+	related = related[offset:]
+	if limit > 0 && limit < len(related) {
+		related = related[:limit]
+	}
+	return related, nil
+}
+
+// Topics lists the topical index by name, only the topics whose name starts
+// with prefix when it is given. A limit of 0 means no limit.
+func Topics(ctx context.Context, db *gorm.DB, prefix string, limit, offset int) ([]models.BibleTopic, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	query := db.WithContext(ctx).Order("name")
+	if prefix = topicSlug(prefix); prefix != "" {
+		query = query.Where("slug LIKE ?", prefix+"%")
+	}
+	topics := []models.BibleTopic{}
+	err := query.Limit(limit).Offset(offset).Find(&topics).Error
+	return topics, err
+}
+
+// TopicsFor lists the topics with a key verse starting in ranges, those
+// where the verse is most voted first.
+func TopicsFor(ctx context.Context, db *gorm.DB, ranges []Range) ([]models.BibleTopic, error) {
+	match, args := versesIn(ranges)
+	topics := []models.BibleTopic{}
+	err := db.WithContext(ctx).Model(&models.BibleTopic{}).
+		Select("bible_topics.*").
+		Joins("JOIN (?) AS ranked ON ranked.topic_id = bible_topics.topic_id",
+			db.Model(&models.BibleTopicVerse{}).Select("topic_id, MAX(votes) AS votes").Where(match, args...).Group("topic_id")).
+		Order("ranked.votes DESC, bible_topics.name").
+		Find(&topics).Error
+	return topics, err
+}
+
+// TopicVerses returns a topic and its key verses, most voted first. It
+// returns ErrNotFound for an unknown slug. A limit of 0 means no limit.
+func TopicVerses(ctx context.Context, db *gorm.DB, slug string, limit, offset int) (*models.BibleTopic, []RankedVerse, error) {
+	db = db.WithContext(ctx)
+	var topic models.BibleTopic
+	if err := db.Where("slug = ?", topicSlug(slug)).Limit(1).Find(&topic).Error; err != nil {
+		return nil, nil, err
+	}
+	if topic.TopicID == 0 {
+		return nil, nil, ErrNotFound
+	}
+
+	if limit <= 0 {
+		limit = -1
+	}
+	var rows []models.BibleTopicVerse
+	err := db.Where("topic_id = ?", topic.TopicID).Order("votes DESC, id").Limit(limit).Offset(offset).Find(&rows).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	verses := make([]RankedVerse, 0, len(rows))
+	for _, row := range rows {
+		if ranges, err := ParseReference(row.Reference); err == nil {
+			verses = append(verses, rankedVerse(ranges, row.Votes))
+		}
+	}
+	return &topic, verses, nil
+}
+
+func rankedVerse(ranges []Range, votes int) RankedVerse {
+	return RankedVerse{
+		Reference: FormatReference(ranges, RefFull),
+		VerseID:   FormatReference(ranges, RefID),
+		Votes:     votes,
+	}
+}
+
+// versesIn builds a condition matching rows whose book_id, chapter and verse
+// fall in ranges.
+func versesIn(ranges []Range) (string, []interface{}) {
+	var match []string
+	var args []interface{}
+	for _, r := range ranges {
+		for _, span := range r.Spans() {
+			cond := "(book_id = ? AND chapter = ?"
+			args = append(args, r.Book, span.Chapter)
+			if span.StartVerse > 0 {
+				cond += " AND verse >= ?"
+				args = append(args, span.StartVerse)
+			}
+			if span.EndVerse > 0 {
+				cond += " AND verse <= ?"
+				args = append(args, span.EndVerse)
+			}
+			match = append(match, cond+")")
+		}
+	}
+	return "(" + strings.Join(match, " OR ") + ")", args
+}
+
+// overlaps reports whether r starts inside ranges.
+func overlaps(ranges []Range, r Range) bool {
+	for _, q := range ranges {
+		if q.Book != r.Book {
+			continue
+		}
+		for _, span := range q.Spans() {
+			if span.Chapter == r.StartChapter && (r.StartVerse == 0 || inSpan(span, r.StartVerse)) {
+				return true
+			}
+		}
+	}
+	return false
+}
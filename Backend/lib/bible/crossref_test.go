@@ -0,0 +1,123 @@
+package bible
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCrossReferences(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "bible.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	openBible := "From Verse\tTo Verse\tVotes\t#www.openbible.info CC-BY 2024-01-01\n" +
+		"John.3.16\tRom.5.8\t300\n" +
+		"John.3.16\t1John.4.9-1John.4.10\t250\n" +
+		"John.3.16\tJohn.3.17\t90\n" +
+		"John.3.17\tJohn.12.47\t40\n" +
+		"John.3.16\tGen.22.2\t-4\n" +
+		"John.3.16\tNot.A.Book\t10\n" +
+		"Rom.5.8\tJohn.3.16\t120\n"
+	n, err := ImportCrossReferences(db, "openbible", strings.NewReader(openBible))
+	if err != nil || n != 5 {
+		t.Fatalf("imported %d, %v; want 5", n, err)
+	}
+	// Another source adds its votes to the same link.
+	if _, err := ImportCrossReferences(db, "tsk", strings.NewReader("John.3.16\tRom.5.8\nJohn.3.16\tJohn.1.14\n")); err != nil {
+		t.Fatal(err)
+	}
+	// Importing a source again replaces it.
+	if _, err := ImportCrossReferences(db, "tsk", strings.NewReader("John.3.16\tRom.5.8\nJohn.3.16\tJohn.1.14\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ref           string
+		limit, offset int
+		want          []string
+	}{
+		{"John 3:16", 0, 0, []string{"ROM.5.8 301", "1JN.4.9-1JN.4.10 250", "JHN.3.17 90", "JHN.1.14 1"}},
+		{"John 3:16-17", 0, 0, []string{"ROM.5.8 301", "1JN.4.9-1JN.4.10 250", "JHN.12.47 40", "JHN.1.14 1"}},
+		{"John 3", 2, 1, []string{"1JN.4.9-1JN.4.10 250", "JHN.12.47 40"}},
+		{"Rom 5:8", 0, 0, []string{"JHN.3.16 120"}},
+		{"Gen 1:1", 0, 0, nil},
+	}
+	for _, tt := range tests {
+		ranges, err := ParseReference(tt.ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		related, err := CrossReferences(context.Background(), db, ranges, tt.limit, tt.offset)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.ref, err)
+		}
+		var got []string
+		for _, r := range related {
+			got = append(got, r.VerseID+" "+strconv.Itoa(r.Votes))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestTopics(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "bible.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := "Topic\tOSIS\tVotes\n" +
+		"love\t1John.4.8\t212\n" +
+		"love\t1Cor.13.4-1Cor.13.7\t340\n" +
+		"love\tJohn.3.16\t150\n" +
+		"God's Love\tJohn.3.16\t400\n" +
+		"gospel\tJohn.3.16\t80\n" +
+		"gospel\tRom.1.16\t-1\n"
+	n, err := ImportTopics(db, strings.NewReader(src))
+	if err != nil || n != 3 {
+		t.Fatalf("imported %d topics, %v; want 3", n, err)
+	}
+
+	topic, verses, err := TopicVerses(context.Background(), db, "Love", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if topic.Name != "love" || topic.Verses != 3 || len(verses) != 3 ||
+		verses[0].Reference != "1 Corinthians 13:4–7" || verses[0].Votes != 340 || verses[2].VerseID != "JHN.3.16" {
+		t.Errorf("love = %+v, %+v", topic, verses)
+	}
+	if _, verses, _ := TopicVerses(context.Background(), db, "love", 1, 1); len(verses) != 1 || verses[0].VerseID != "1JN.4.8" {
+		t.Errorf("love page 2 = %+v", verses)
+	}
+	if _, _, err := TopicVerses(context.Background(), db, "hope", 0, 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown topic: %v, want ErrNotFound", err)
+	}
+
+	ranges, _ := ParseReference("John 3:16")
+	topics, err := TopicsFor(context.Background(), db, ranges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var slugs []string
+	for _, topic := range topics {
+		slugs = append(slugs, topic.Slug)
+	}
+	if want := []string{"god-s-love", "love", "gospel"}; !reflect.DeepEqual(slugs, want) {
+		t.Errorf("topics for John 3:16 = %q, want %q", slugs, want)
+	}
+
+	topics, err = Topics(context.Background(), db, "go", 0, 0)
+	if err != nil || len(topics) != 2 || topics[0].Name != "God's Love" || topics[1].Name != "gospel" {
+		t.Errorf("topics starting go = %+v, %v", topics, err)
+	}
+}
@@ -0,0 +1,210 @@
+package bible
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	esvID  = "ESV"
+	esvURL = "https://api.esv.org/v3"
+)
+
+// ESV serves the English Standard Version from api.esv.org, which only
+// offers passage text.
+type ESV struct {
+	BaseURL string
+	Key     string
+	Client  *http.Client
+	Plain   bool // leave out headings and footnotes, as the legacy passage endpoint does
+}
+
+func NewESV(key string) *ESV {
+	return &ESV{BaseURL: esvURL, Key: key, Client: http.DefaultClient}
+}
+
+func (e *ESV) Translations(ctx context.Context) ([]interface{}, error) {
+	return []interface{}{map[string]interface{}{
+		"id":   esvID,
+		"name": "English Standard Version",
+	}}, nil
+}
+
+// Books lists the Protestant canon, which is all the ESV API serves.
+func (e *ESV) Books(ctx context.Context, translationID string) (map[string]interface{}, error) {
+	data := []interface{}{}
+	for _, b := range ProtestantCanon() {
+		data = append(data, bookEntry(esvID, b.USFM, b.Name))
+	}
+	return map[string]interface{}{"data": data}, nil
+}
+
+func (e *ESV) Chapters(ctx context.Context, translationID, bookID string) (map[string]interface{}, error) {
+	b, ok := BookByUSFM(bookID)
+	if !ok || b.Testament == Deuterocanon {
+		return nil, ErrNotFound
+	}
+	return chapterList(esvID, b.USFM, b.Name, b.Chapters), nil
+}
+
+// Passage fetches a chapter as plain text and reads its layout, [n] verse
+// numbers and (n) footnote markers into tags shaped like an API.Bible
+// chapter.
+func (e *ESV) Passage(ctx context.Context, translationID, reference string) (map[string]interface{}, error) {
+	if e.Key == "" {
+		return nil, ErrMissingKey
+	}
+	book, chapter, ok := ParseChapterID(reference)
+	if !ok || book.Testament == Deuterocanon {
+		return nil, ErrNotFound
+	}
+	chapterID := book.USFM + "." + strconv.Itoa(chapter)
+
+	extras := "&include-footnotes=true&include-footnote-body=true&include-headings=true"
+	if e.Plain {
+		extras = "&include-footnotes=false&include-footnote-body=false&include-headings=false"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		e.BaseURL+"/passage/text/?q="+url.QueryEscape(book.Name+" "+strconv.Itoa(chapter))+
+			"&include-passage-references=false&include-verse-numbers=true"+
+			extras+"&include-short-copyright=true",
+		nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Token "+e.Key)
+
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return nil, &UpstreamError{Service: "ESV API", Err: err}
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, &UpstreamError{Service: "ESV API", Status: resp.StatusCode, Body: string(body)}
+	}
+
+	var result struct {
+		Passages []string `json:"passages"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, &UpstreamError{Service: "ESV API", Status: resp.StatusCode, Err: fmt.Errorf("invalid JSON: %w", err)}
+	}
+	if len(result.Passages) == 0 {
+		return nil, ErrNotFound
+	}
+
+	passage := strings.TrimSpace(result.Passages[0])
+	copyright := ""
+	if strings.HasSuffix(passage, "(ESV)") {
+		passage, copyright = strings.TrimSuffix(passage, "(ESV)"), "(ESV)"
+	}
+	return map[string]interface{}{"data": map[string]interface{}{
+		"content":   esvContent(passage, chapterID+"."),
+		"copyright": copyright,
+	}}, nil
+}
+
+var (
+	esvVerseNumber = regexp.MustCompile(`\[\s*(\d+)\s*]`)
+	esvNoteCaller  = regexp.MustCompile(`\((\d+)\)`)
+	esvNote        = regexp.MustCompile(`(?m)^\((\d+)\) (?:\d+:\d+(?:[-–]\d+)? )?(.+)$`)
+)
+
+// esvContent reads passage text into paragraphs of verse tags, giving each
+// verse a sid of prefix plus its number, e.g. "LEV.3." and 12. Lines at the
+// margin are headings, lines indented four or more spaces poetry and the
+// rest prose. A verse running over several paragraphs gets a tag in each.
+func esvContent(passage, prefix string) []interface{} {
+	notes := map[string]string{}
+	if body, footnotes, ok := strings.Cut(passage, "\nFootnotes\n"); ok {
+		passage = body
+		for _, m := range esvNote.FindAllStringSubmatch(footnotes, -1) {
+			notes[m[1]] = strings.TrimSpace(m[2])
+		}
+	}
+
+	content := []interface{}{}
+	sid := ""
+	for _, line := range strings.Split(passage, "\n") {
+		text := strings.TrimSpace(line)
+		if text == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if indent == 0 {
+			content = append(content, esvPara("s1", []interface{}{textItem(text)}))
+			continue
+		}
+		style := "p"
+		if indent >= 4 {
+			style = "q" + strconv.Itoa((indent-2)/2)
+		}
+
+		items := []interface{}{}
+		locs := esvVerseNumber.FindAllStringSubmatchIndex(text, -1)
+		start := 0
+		for i := 0; i <= len(locs); i++ {
+			end := len(text)
+			if i < len(locs) {
+				end = locs[i][0]
+			}
+			if sid != "" && strings.TrimSpace(text[start:end]) != "" {
+				items = append(items, map[string]interface{}{
+					"name":  "verse",
+					"attrs": map[string]interface{}{"sid": sid},
+					"items": esvItems(text[start:end], notes),
+				})
+			}
+			if i < len(locs) {
+				sid = prefix + text[locs[i][2]:locs[i][3]]
+				start = locs[i][1]
+			}
+		}
+		if len(items) > 0 {
+			content = append(content, esvPara(style, items))
+		}
+	}
+	return content
+}
+
+func esvPara(style string, items []interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"name":  "para",
+		"attrs": map[string]interface{}{"style": style},
+		"items": items,
+	}
+}
+
+// esvItems splits verse text at its footnote markers into text and notes.
+func esvItems(text string, notes map[string]string) []interface{} {
+	items := []interface{}{}
+	start := 0
+	for _, loc := range esvNoteCaller.FindAllStringSubmatchIndex(text, -1) {
+		note, ok := notes[text[loc[2]:loc[3]]]
+		if !ok {
+			continue
+		}
+		if start < loc[0] {
+			items = append(items, textItem(text[start:loc[0]]))
+		}
+		items = append(items, map[string]interface{}{
+			"name":  "note",
+			"attrs": map[string]interface{}{"style": "f", "caller": "+"},
+			"items": []interface{}{textItem(note)},
+		})
+		start = loc[1]
+	}
+	if start < len(text) {
+		items = append(items, textItem(text[start:]))
+	}
+	return items
+}
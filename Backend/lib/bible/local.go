@@ -0,0 +1,182 @@
+package bible
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"theword/Backend/lib/models"
+
+	"gorm.io/gorm"
+)
+
+// Local serves translations imported into the database.
+type Local struct {
+	DB *gorm.DB
+}
+
+// Has reports whether a translation's text is in the database.
+func (l *Local) Has(translationID string) bool {
+	var count int64
+	l.DB.Model(&models.BibleTranslation{}).Where("translation_id = ?", translationID).Count(&count)
+	return count > 0
+}
+
+func (l *Local) Translations(ctx context.Context) ([]interface{}, error) {
+	db := l.DB.WithContext(ctx)
+
+	var translations []models.BibleTranslation
+	if err := db.Order("translation_id").Find(&translations).Error; err != nil {
+		return nil, err
+	}
+
+	entries := make([]interface{}, 0, len(translations))
+	for _, t := range translations {
+		entries = append(entries, map[string]interface{}{
+			"id":           t.TranslationID,
+			"name":         t.Name,
+			"abbreviation": t.Abbreviation,
+			"language":     map[string]interface{}{"id": t.Language},
+		})
+	}
+	return entries, nil
+}
+
+func (l *Local) Books(ctx context.Context, translationID string) (map[string]interface{}, error) {
+	db := l.DB.WithContext(ctx)
+
+	var books []models.BibleBook
+	if err := db.Where("translation_id = ?", translationID).Order("position").Find(&books).Error; err != nil {
+		return nil, err
+	}
+
+	data := make([]interface{}, 0, len(books))
+	for _, b := range books {
+		data = append(data, bookEntry(translationID, b.BookID, b.Name))
+	}
+	return map[string]interface{}{"data": data}, nil
+}
+
+func (l *Local) Chapters(ctx context.Context, translationID, bookID string) (map[string]interface{}, error) {
+	db := l.DB.WithContext(ctx)
+
+	var book models.BibleBook
+	if err := db.Where("translation_id = ? AND book_id = ?", translationID, strings.ToUpper(bookID)).
+		First(&book).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return chapterList(translationID, book.BookID, book.Name, book.Chapters), nil
+}
+
+// Passage returns a chapter with one para per paragraph or poetry line and
+// one verse tag per verse. A verse that runs over several lines gets a verse
+// tag in each, with the same sid.
+func (l *Local) Passage(ctx context.Context, translationID, chapterID string) (map[string]interface{}, error) {
+	db := l.DB.WithContext(ctx)
+	chapterID = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(chapterID), " ", "."))
+
+	var verses []models.BibleVerse
+	if err := db.Where("translation_id = ? AND chapter_id = ?", translationID, chapterID).
+		Order("verse").Find(&verses).Error; err != nil {
+		return nil, err
+	}
+	if len(verses) == 0 {
+		return nil, ErrNotFound
+	}
+
+	var book models.BibleBook
+	db.Where("translation_id = ? AND book_id = ?", translationID, verses[0].BookID).First(&book)
+
+	var content []interface{}
+	var para map[string]interface{}
+	newPara := func(indent int) {
+		style := "p"
+		if indent > 0 {
+			style = "q" + strconv.Itoa(indent)
+		}
+		para = map[string]interface{}{
+			"name":  "para",
+			"type":  "tag",
+			"attrs": map[string]interface{}{"style": style},
+			"items": []interface{}{},
+		}
+		content = append(content, para)
+	}
+	addVerse := func(v models.BibleVerse, items []interface{}) {
+		para["items"] = append(para["items"].([]interface{}), map[string]interface{}{
+			"name": "verse",
+			"type": "tag",
+			"attrs": map[string]interface{}{
+				"number":  strconv.Itoa(v.Verse),
+				"style":   "v",
+				"sid":     v.VerseID,
+				"verseId": v.VerseID,
+			},
+			"items": items,
+		})
+	}
+
+	for _, v := range verses {
+		if v.Heading != "" {
+			for _, heading := range strings.Split(v.Heading, "\n") {
+				content = append(content, map[string]interface{}{
+					"name":  "para",
+					"type":  "tag",
+					"attrs": map[string]interface{}{"style": "s1"},
+					"items": []interface{}{textItem(heading)},
+				})
+			}
+		}
+		if para == nil || v.Paragraph || v.Heading != "" {
+			newPara(v.Indent)
+		}
+
+		segments := v.Segments
+		if segments == nil {
+			segments = []Segment{{Text: v.Text}}
+		}
+		items := []interface{}{}
+		for _, seg := range segments {
+			switch {
+			case seg.Break:
+				addVerse(v, items)
+				newPara(seg.Indent)
+				items = []interface{}{}
+			case seg.Footnote > 0 && seg.Footnote <= len(v.Footnotes):
+				items = append(items, map[string]interface{}{
+					"name":  "note",
+					"type":  "tag",
+					"attrs": map[string]interface{}{"style": "f", "caller": "+"},
+					"items": []interface{}{textItem(v.Footnotes[seg.Footnote-1])},
+				})
+			case seg.WordsOfJesus:
+				items = append(items, map[string]interface{}{
+					"name":  "char",
+					"type":  "tag",
+					"attrs": map[string]interface{}{"style": "wj"},
+					"items": []interface{}{textItem(seg.Text)},
+				})
+			case seg.Text != "":
+				items = append(items, textItem(seg.Text))
+			}
+		}
+		addVerse(v, items)
+	}
+
+	return map[string]interface{}{"data": map[string]interface{}{
+		"id":        chapterID,
+		"bibleId":   translationID,
+		"bookId":    verses[0].BookID,
+		"number":    strconv.Itoa(verses[0].Chapter),
+		"reference": book.Name + " " + strconv.Itoa(verses[0].Chapter),
+		"content":   content,
+	}}, nil
+}
+
+func textItem(text string) map[string]interface{} {
+	return map[string]interface{}{"type": "text", "text": text}
+}
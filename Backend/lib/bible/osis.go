@@ -0,0 +1,244 @@
+package bible
+
+import (
+	"encoding/xml"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ParseOSIS reads an OSIS document into t. Both container and milestone
+// verses are understood. Footnotes and words of Jesus are kept; cross
+// references and variant readings are dropped.
+func ParseOSIS(r io.Reader, t *Text) error {
+	b := newBuilder(t)
+	d := xml.NewDecoder(r)
+
+	var (
+		skip       int // depth inside an element whose text is dropped
+		title      *strings.Builder
+		titleShort string // short attribute of the open title
+		titleType  string
+		containers []bool // for each open <verse>, whether it wraps its text
+		book       string // OSIS name of the current book
+		quotes     []bool // for each open <q>, whether it holds words of Jesus
+		jesus      bool   // inside a milestone <q> of words of Jesus
+	)
+	red := func() {
+		b.setRed(jesus || slices.Contains(quotes, true))
+	}
+
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch el := tok.(type) {
+		case xml.StartElement:
+			if skip > 0 {
+				skip++
+				continue
+			}
+			switch el.Name.Local {
+			case "note":
+				if xmlAttr(el, "type") == "crossReference" {
+					skip = 1
+					continue
+				}
+				note, err := xmlText(d, func(el xml.StartElement) bool {
+					return el.Name.Local == "reference"
+				})
+				if err != nil {
+					return err
+				}
+				if title == nil {
+					b.addFootnote(note)
+				}
+			case "q":
+				switch {
+				case xmlAttr(el, "sID") != "":
+					jesus = xmlAttr(el, "who") == "Jesus"
+					quotes = append(quotes, false)
+				case xmlAttr(el, "eID") != "":
+					jesus = false
+					quotes = append(quotes, false)
+				default:
+					quotes = append(quotes, xmlAttr(el, "who") == "Jesus")
+				}
+				red()
+			case "rdg", "header":
+				skip = 1
+			case "div":
+				if xmlAttr(el, "type") != "book" {
+					continue
+				}
+				info, ok := BookByOSIS(xmlAttr(el, "osisID"))
+				if !ok {
+					skip = 1
+					continue
+				}
+				book = xmlAttr(el, "osisID")
+				b.startBook(info.USFM)
+			case "chapter":
+				if id := xmlAttr(el, "osisID"); id != "" && xmlAttr(el, "eID") == "" {
+					if n, ok := osisChapter(id, book); ok {
+						b.startChapter(n)
+					}
+				}
+			case "verse":
+				if xmlAttr(el, "eID") != "" {
+					b.endVerse()
+					containers = append(containers, false)
+					continue
+				}
+				containers = append(containers, xmlAttr(el, "sID") == "")
+				id, _, _ := strings.Cut(xmlAttr(el, "osisID"), " ")
+				parts := strings.Split(id, ".")
+				if len(parts) != 3 {
+					continue
+				}
+				if parts[0] != book {
+					info, ok := BookByOSIS(parts[0])
+					if !ok {
+						continue
+					}
+					book = parts[0]
+					b.startBook(info.USFM)
+				}
+				if n, err := strconv.Atoi(parts[1]); err == nil && n != b.chapter {
+					b.startChapter(n)
+				}
+				b.startVerse(leadingInt(parts[2]))
+			case "title":
+				if b.verse >= 0 {
+					continue
+				}
+				title = &strings.Builder{}
+				titleShort = xmlAttr(el, "short")
+				titleType = xmlAttr(el, "type")
+			case "p":
+				b.breakParagraph(0)
+			case "milestone":
+				if kind := xmlAttr(el, "type"); kind == "x-p" || kind == "pilcrow" {
+					b.breakParagraph(0)
+				}
+			case "l":
+				level, err := strconv.Atoi(xmlAttr(el, "level"))
+				if err != nil || level < 1 {
+					level = 1
+				}
+				b.breakParagraph(level)
+			}
+
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			switch el.Name.Local {
+			case "q":
+				if n := len(quotes); n > 0 {
+					quotes = quotes[:n-1]
+					red()
+				}
+			case "verse":
+				if n := len(containers); n > 0 {
+					if containers[n-1] {
+						b.endVerse()
+					}
+					containers = containers[:n-1]
+				}
+			case "title":
+				if title == nil {
+					continue
+				}
+				switch {
+				case titleType == "main" && titleShort != "":
+					b.nameBook(titleShort, 0)
+				case titleType == "main" || b.chapter == 0:
+					b.nameBook(title.String(), 3)
+				case titleType == "chapter":
+					// labels like "CHAPTER 3" repeat the chapter number
+				default:
+					b.addHeading(title.String())
+				}
+				title = nil
+			case "lg":
+				b.breakParagraph(0)
+			case "p", "l":
+				b.addText(" ")
+			}
+
+		case xml.CharData:
+			if skip > 0 {
+				continue
+			}
+			if title != nil {
+				title.Write(el)
+			} else {
+				b.addText(string(el))
+			}
+		}
+	}
+
+	b.endVerse()
+	return nil
+}
+
+// osisChapter returns the number of an OSIS chapter ID such as "Gen.1" in
+// the given book.
+func osisChapter(id, book string) (int, bool) {
+	name, chapter, ok := strings.Cut(id, ".")
+	if !ok || name != book {
+		return 0, false
+	}
+	n, err := strconv.Atoi(chapter)
+	return n, err == nil
+}
+
+// xmlText reads the text up to the end of the element just started, leaving
+// out elements that drop reports true for.
+func xmlText(d *xml.Decoder, drop func(xml.StartElement) bool) (string, error) {
+	var (
+		text    strings.Builder
+		depth   = 1
+		dropped int // depth of the dropped element, 0 when none is open
+	)
+	for depth > 0 {
+		tok, err := d.Token()
+		if err != nil {
+			return "", err
+		}
+		switch el := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if dropped == 0 && drop(el) {
+				dropped = depth
+			}
+		case xml.EndElement:
+			if depth == dropped {
+				dropped = 0
+			}
+			depth--
+		case xml.CharData:
+			if dropped == 0 {
+				text.Write(el)
+			}
+		}
+	}
+	return text.String(), nil
+}
+
+func xmlAttr(el xml.StartElement, name string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
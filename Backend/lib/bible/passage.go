@@ -0,0 +1,336 @@
+package bible
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"theword/Backend/lib/models"
+	"unicode"
+)
+
+// MaxPassageChapters bounds how many chapters one passage request may span.
+const MaxPassageChapters = 10
+
+var ErrPassageTooLong = errors.New("passage too long")
+
+// Segment is a run of verse text, stored with imported verses.
+type Segment = models.BibleSegment
+
+func plainSegment(s Segment) bool {
+	return !s.Break && s.Footnote == 0
+}
+
+// PassageVerse is one verse in the form every provider's text is normalized
+// to.
+type PassageVerse struct {
+	ID        string    `json:"id"` // e.g. "JHN.3.16"
+	Chapter   int       `json:"chapter"`
+	Number    int       `json:"number"`
+	Through   int       `json:"through,omitempty"`  // last verse of a bridged verse such as 1-2
+	Headings  []string  `json:"headings,omitempty"` // section headings before the verse
+	Paragraph bool      `json:"paragraph"`          // verse starts a new paragraph
+	Indent    int       `json:"indent"`             // poetry level, 0 for prose
+	Text      string    `json:"text"`
+	Segments  []Segment `json:"segments"`
+	Footnotes []string  `json:"footnotes,omitempty"`
+}
+
+// PassageText is a run of verses from one translation, with the notice it
+// must be shown with.
+type PassageText struct {
+	TranslationID string         `json:"translation_id"`
+	Reference     string         `json:"reference"` // e.g. "John 3:16–18"
+	Verses        []PassageVerse `json:"verses"`
+	Attribution   *Attribution   `json:"attribution"`
+}
+
+// Range fetches the given ranges chapter by chapter, so each chapter is
+// cached on its own, and cuts out the verses asked for. It returns
+// ErrPassageTooLong for more verses than the translation's licence lets us
+// show at once.
+func (p *Providers) Range(ctx context.Context, translationID string, ranges []Range) (*PassageText, error) {
+	chapters := 0
+	for _, r := range ranges {
+		chapters += r.EndChapter - r.StartChapter + 1
+	}
+	if chapters > MaxPassageChapters {
+		return nil, ErrPassageTooLong
+	}
+
+	provider := p.For(translationID)
+	passage := &PassageText{TranslationID: translationID, Reference: FormatReference(ranges, RefFull), Verses: []PassageVerse{}}
+	for _, r := range ranges {
+		for _, span := range r.Spans() {
+			chapterID := r.Book + "." + strconv.Itoa(span.Chapter)
+			result, err := provider.Passage(ctx, translationID, chapterID)
+			if err != nil {
+				return nil, err
+			}
+			if passage.Attribution == nil {
+				passage.Attribution = p.Attribution(translationID, result)
+			}
+			for _, v := range NormalizeChapter(result, chapterID) {
+				if inSpan(span, v.Number) {
+					passage.Verses = append(passage.Verses, v)
+				}
+			}
+		}
+	}
+	if len(passage.Verses) == 0 {
+		return nil, ErrNotFound
+	}
+	if err := p.checkLength(translationID, len(passage.Verses)); err != nil {
+		return nil, err
+	}
+	return passage, nil
+}
+
+// NormalizeChapter reads a chapter in the API.Bible JSON shape every
+// provider returns, whether its verse tags are milestones followed by text
+// (API.Bible) or wrap their text (local and ESV), into verses.
+func NormalizeChapter(result map[string]interface{}, chapterID string) []PassageVerse {
+	data, _ := result["data"].(map[string]interface{})
+	content, _ := data["content"].([]interface{})
+
+	w := chapterWalker{chapterID: chapterID, current: -1, byID: map[string]int{}}
+	w.walk(content)
+
+	verses := w.verses[:0]
+	for i, v := range w.verses {
+		v.Text, v.Segments = tidySegments(v.Segments)
+		v.Footnotes = w.footnotes[i]
+		if v.Text == "" && len(v.Footnotes) == 0 {
+			continue
+		}
+		if v.Segments == nil {
+			v.Segments = []Segment{{Text: v.Text}}
+		}
+		verses = append(verses, v)
+	}
+	return verses
+}
+
+type chapterWalker struct {
+	chapterID string
+	verses    []PassageVerse
+	footnotes [][]string
+	byID      map[string]int
+	current   int // index into verses, -1 before the first verse
+	headings  []string
+	paragraph bool // a paragraph break is waiting for the next text
+	indent    int
+	red       int // depth inside words of Jesus
+}
+
+func (w *chapterWalker) walk(items []interface{}) {
+	for _, item := range items {
+		node, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		attrs, _ := node["attrs"].(map[string]interface{})
+		children, _ := node["items"].([]interface{})
+		style, _ := attrs["style"].(string)
+
+		if node["type"] == "text" {
+			if id, ok := attrs["verseId"].(string); ok {
+				w.startVerse(id)
+			}
+			text, _ := node["text"].(string)
+			w.addText(text)
+			continue
+		}
+
+		switch node["name"] {
+		case "verse":
+			id, _ := attrs["verseId"].(string)
+			if id == "" {
+				id, _ = attrs["sid"].(string)
+			}
+			number, _ := attrs["number"].(string)
+			if id == "" && number != "" {
+				id = w.chapterID + "." + number
+			}
+			w.startVerse(id)
+			// API.Bible verse tags hold only the verse number.
+			if len(children) == 1 && number != "" && strings.TrimSpace(nodeText(children[0], nil)) == number {
+				continue
+			}
+			w.walk(children)
+		case "para":
+			switch kind, level := paragraphStyle(style); kind {
+			case styleHeading:
+				if heading := collapseSpace(nodeText(node, nil)); heading != "" {
+					w.headings = append(w.headings, heading)
+				}
+			case styleName, styleSkip:
+			case stylePoetry:
+				w.paragraph, w.indent = true, level
+				w.walk(children)
+			case styleBody:
+				w.paragraph, w.indent = true, 0
+				w.walk(children)
+			default:
+				w.walk(children)
+			}
+		case "char":
+			if style == "wj" {
+				w.red++
+				w.walk(children)
+				w.red--
+			} else {
+				w.walk(children)
+			}
+		case "note":
+			if style == "f" || style == "fe" {
+				var note strings.Builder
+				for _, child := range children {
+					note.WriteString(nodeText(child, map[string]bool{"fr": true}))
+				}
+				w.addFootnote(note.String())
+			}
+		default:
+			w.walk(children)
+		}
+	}
+}
+
+// startVerse makes id, in any form ParseReference reads, the verse text is
+// added to.
+func (w *chapterWalker) startVerse(id string) {
+	ranges, err := ParseReference(id)
+	if err != nil || len(ranges) == 0 || ranges[0].StartVerse == 0 {
+		return
+	}
+	id = ranges[0].VerseID()
+	if i, ok := w.byID[id]; ok {
+		w.current = i
+		return
+	}
+
+	v := PassageVerse{
+		ID:        id,
+		Chapter:   ranges[0].StartChapter,
+		Number:    ranges[0].StartVerse,
+		Headings:  w.headings,
+		Paragraph: w.paragraph,
+		Indent:    w.indent,
+	}
+	if r := ranges[0]; r.EndChapter == r.StartChapter && r.EndVerse > r.StartVerse {
+		v.Through = r.EndVerse
+	}
+	w.verses = append(w.verses, v)
+	w.footnotes = append(w.footnotes, nil)
+	w.current = len(w.verses) - 1
+	w.byID[id] = w.current
+	w.headings = nil
+	w.paragraph = false
+}
+
+func (w *chapterWalker) addText(s string) {
+	if w.current < 0 || s == "" {
+		return
+	}
+	v := &w.verses[w.current]
+	if w.paragraph && strings.TrimSpace(s) != "" {
+		if strings.TrimSpace(v.Text) != "" {
+			v.Segments = append(v.Segments, Segment{Break: true, Indent: w.indent})
+		}
+		w.paragraph = false
+	}
+	v.Text += s
+	v.Segments = append(v.Segments, Segment{Text: s, WordsOfJesus: w.red > 0})
+}
+
+func (w *chapterWalker) addFootnote(s string) {
+	if s = collapseSpace(s); w.current < 0 || s == "" {
+		return
+	}
+	w.footnotes[w.current] = append(w.footnotes[w.current], s)
+	v := &w.verses[w.current]
+	v.Segments = append(v.Segments, Segment{Footnote: len(w.footnotes[w.current])})
+}
+
+// nodeText returns the text inside a node, leaving out notes and char tags
+// whose style is in skip.
+func nodeText(item interface{}, skip map[string]bool) string {
+	node, ok := item.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	if node["type"] == "text" {
+		text, _ := node["text"].(string)
+		return text
+	}
+	attrs, _ := node["attrs"].(map[string]interface{})
+	style, _ := attrs["style"].(string)
+	if skip[style] || node["name"] == "note" {
+		return ""
+	}
+
+	var out strings.Builder
+	children, _ := node["items"].([]interface{})
+	for _, child := range children {
+		out.WriteString(nodeText(child, skip))
+	}
+	return out.String()
+}
+
+// tidySegments collapses the whitespace in a verse's segments the way
+// collapseSpace does for plain text. It returns the verse's plain text, and
+// the segments if any carry markup worth keeping.
+func tidySegments(segs []Segment) (string, []Segment) {
+	var (
+		text      strings.Builder
+		out       []Segment
+		space     bool // a space is owed before the next text
+		lineStart bool // the next text follows a break
+		markup    bool
+	)
+	for _, s := range segs {
+		switch {
+		case s.Break:
+			if text.Len() > 0 {
+				out = append(out, Segment{Break: true, Indent: s.Indent})
+				markup, lineStart = true, true
+			}
+			space = false
+			continue
+		case s.Footnote > 0:
+			out = append(out, Segment{Footnote: s.Footnote})
+			markup = true
+			continue
+		}
+
+		words := collapseSpace(s.Text)
+		if words == "" {
+			space = space || s.Text != ""
+			continue
+		}
+		if r := []rune(s.Text)[0]; unicode.IsSpace(r) {
+			space = true
+		}
+		if text.Len() > 0 && (space || lineStart) {
+			text.WriteString(" ")
+			if !lineStart {
+				words = " " + words
+			}
+		}
+		text.WriteString(strings.TrimPrefix(words, " "))
+
+		if n := len(out); n > 0 && plainSegment(out[n-1]) && out[n-1].WordsOfJesus == s.WordsOfJesus {
+			out[n-1].Text += words
+		} else {
+			out = append(out, Segment{Text: words, WordsOfJesus: s.WordsOfJesus})
+		}
+		markup = markup || s.WordsOfJesus
+		r := []rune(s.Text)
+		space, lineStart = unicode.IsSpace(r[len(r)-1]), false
+	}
+
+	if !markup {
+		return text.String(), nil
+	}
+	return text.String(), out
+}
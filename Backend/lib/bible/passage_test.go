@@ -0,0 +1,193 @@
+package bible
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"theword/Backend/lib/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// apiBibleChapter is API.Bible's JSON for John 3:16-17, trimmed: verse tags
+// are milestones holding only the number, and text nodes carry verseId.
+const apiBibleChapter = `{"data": {"content": [
+	{"name": "para", "type": "tag", "attrs": {"style": "s1"}, "items": [{"text": "For God So Loved the World", "type": "text"}]},
+	{"name": "para", "type": "tag", "attrs": {"style": "p"}, "items": [
+		{"name": "verse", "type": "tag", "attrs": {"number": "16", "style": "v", "sid": "JHN 3:16"}, "items": [{"text": "16", "type": "text"}]},
+		{"name": "char", "type": "tag", "attrs": {"style": "wj"}, "items": [
+			{"text": "“For God so loved the world,", "type": "text", "attrs": {"verseId": "JHN.3.16"}}
+		]},
+		{"name": "note", "type": "tag", "attrs": {"style": "f", "caller": "+"}, "items": [
+			{"name": "char", "type": "tag", "attrs": {"style": "fr"}, "items": [{"text": "3:16 ", "type": "text"}]},
+			{"name": "char", "type": "tag", "attrs": {"style": "ft"}, "items": [{"text": "Or only", "type": "text"}]}
+		]},
+		{"name": "char", "type": "tag", "attrs": {"style": "wj"}, "items": [
+			{"text": " that he gave his Son.”", "type": "text", "attrs": {"verseId": "JHN.3.16"}}
+		]},
+		{"text": " ", "type": "text", "attrs": {"verseId": "JHN.3.16"}}
+	]},
+	{"name": "para", "type": "tag", "attrs": {"style": "q1"}, "items": [
+		{"name": "verse", "type": "tag", "attrs": {"number": "17", "style": "v", "sid": "JHN 3:17"}, "items": [{"text": "17", "type": "text"}]},
+		{"text": "For God did not send", "type": "text", "attrs": {"verseId": "JHN.3.17"}}
+	]},
+	{"name": "para", "type": "tag", "attrs": {"style": "q2"}, "items": [
+		{"text": "his Son to condemn.", "type": "text", "attrs": {"verseId": "JHN.3.17"}}
+	]}
+]}}`
+
+func TestNormalizeChapter(t *testing.T) {
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(apiBibleChapter), &result); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []PassageVerse{{
+		ID:        "JHN.3.16",
+		Chapter:   3,
+		Number:    16,
+		Headings:  []string{"For God So Loved the World"},
+		Paragraph: true,
+		Text:      "“For God so loved the world, that he gave his Son.”",
+		Segments: []Segment{
+			{Text: "“For God so loved the world,", WordsOfJesus: true},
+			{Footnote: 1},
+			{Text: " that he gave his Son.”", WordsOfJesus: true},
+		},
+		Footnotes: []string{"Or only"},
+	}, {
+		ID:        "JHN.3.17",
+		Chapter:   3,
+		Number:    17,
+		Paragraph: true,
+		Indent:    1,
+		Text:      "For God did not send his Son to condemn.",
+		Segments:  []Segment{{Text: "For God did not send"}, {Break: true, Indent: 2}, {Text: "his Son to condemn."}},
+	}}
+	if got := NormalizeChapter(result, "JHN.3"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+}
+
+// TestParseMarkup checks each importer keeps words of Jesus and footnotes,
+// and drops cross references and footnote origin references.
+func TestParseMarkup(t *testing.T) {
+	sources := map[string]string{
+		FormatUSFM: "\\id JHN\n\\c 3\n\\p\n\\v 16 \\wj For God so loved\\wj*\\f + \\fr 3:16 \\ft Or only\\f* the world.\\x - \\xo 3:16 \\xt Rom 5:8\\x*\n",
+		FormatUSX: `<usx><book code="JHN" style="id"/><chapter number="3" style="c" sid="JHN 3"/><para style="p">` +
+			`<verse number="16" style="v" sid="JHN 3:16"/><char style="wj">For God so loved</char>` +
+			`<note caller="+" style="f"><char style="fr">3:16 </char><char style="ft">Or only</char></note> the world.` +
+			`<note caller="-" style="x"><char style="xt">Rom 5:8</char></note><verse eid="JHN 3:16"/></para></usx>`,
+		FormatOSIS: `<osis><osisText><div type="book" osisID="John"><chapter osisID="John.3"><p>` +
+			`<verse osisID="John.3.16"><q who="Jesus" marker="">For God so loved</q>` +
+			`<note placement="foot"><reference>3:16</reference> Or only</note> the world.` +
+			`<note type="crossReference">Rom 5:8</note></verse></p></chapter></div></osisText></osis>`,
+	}
+	want := Verse{
+		BookID:    "JHN",
+		Chapter:   3,
+		Number:    16,
+		Paragraph: true,
+		Text:      "For God so loved the world.",
+		Segments:  []Segment{{Text: "For God so loved", WordsOfJesus: true}, {Footnote: 1}, {Text: " the world."}},
+		Footnotes: []string{"Or only"},
+	}
+	for format, src := range sources {
+		t.Run(format, func(t *testing.T) {
+			var text Text
+			if err := Parse(format, strings.NewReader(src), &text); err != nil {
+				t.Fatal(err)
+			}
+			if err := text.clean(); err != nil {
+				t.Fatal(err)
+			}
+			if len(text.Verses) != 1 || !reflect.DeepEqual(text.Verses[0], want) {
+				t.Errorf("got  %+v\nwant %+v", text.Verses, want)
+			}
+		})
+	}
+}
+
+func TestPassageRange(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "bible.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.AutoMigrate(&models.BibleTranslation{}, &models.BibleBook{}, &models.BibleVerse{})
+
+	var text Text
+	src := "\\id PSA\n\\c 23\n\\d A Psalm of David.\n\\q1\n\\v 1 The \\nd Lord\\nd* is my shepherd;\\f + \\fr 23:1 \\ft Or \\fq shepherd\\f*\n" +
+		"\\q2 I shall not want.\n\\q1\n\\v 2 He makes me lie down.\n\\c 24\n\\s1 The King of Glory\n\\p\n\\v 1 The earth is the Lord’s.\n"
+	if err := ParseUSFM(strings.NewReader(src), &text); err != nil {
+		t.Fatal(err)
+	}
+	if err := Import(db, models.BibleTranslation{TranslationID: "WEB"}, &text); err != nil {
+		t.Fatal(err)
+	}
+	p := NewProviders(db, "", "", nil)
+
+	ranges, _ := ParseReference("Ps 23:1, 24:1")
+	passage, err := p.Range(context.Background(), "WEB", ranges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if passage.Reference != "Psalms 23:1; 24:1" || len(passage.Verses) != 2 {
+		t.Fatalf("got %q with %d verses", passage.Reference, len(passage.Verses))
+	}
+	first := passage.Verses[0]
+	wantSegments := []Segment{{Text: "The Lord is my shepherd;"}, {Footnote: 1}, {Break: true, Indent: 2}, {Text: "I shall not want."}}
+	if first.Indent != 1 || !reflect.DeepEqual(first.Headings, []string{"A Psalm of David."}) ||
+		!reflect.DeepEqual(first.Segments, wantSegments) || !reflect.DeepEqual(first.Footnotes, []string{"Or shepherd"}) {
+		t.Errorf("Ps 23:1 = %+v", first)
+	}
+	if second := passage.Verses[1]; second.ID != "PSA.24.1" || second.Headings[0] != "The King of Glory" || second.Indent != 0 {
+		t.Errorf("Ps 24:1 = %+v", second)
+	}
+
+	ranges, _ = ParseReference("Ps 23:5-6")
+	if _, err := p.Range(context.Background(), "WEB", ranges); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing verses: %v, want ErrNotFound", err)
+	}
+	ranges, _ = ParseReference("Ps 1-11")
+	if _, err := p.Range(context.Background(), "WEB", ranges); !errors.Is(err, ErrPassageTooLong) {
+		t.Errorf("11 chapters: %v, want ErrPassageTooLong", err)
+	}
+}
+
+func TestRender(t *testing.T) {
+	passage := &PassageText{Reference: "John 3:16–4:1", Verses: []PassageVerse{{
+		Chapter: 3, Number: 16, Headings: []string{"God's Love"}, Paragraph: true,
+		Segments:  []Segment{{Text: "For God so loved", WordsOfJesus: true}, {Footnote: 1}, {Text: " the <world>."}},
+		Footnotes: []string{"Or only"},
+	}, {
+		Chapter: 3, Number: 17,
+		Segments: []Segment{{Text: "He sent his Son."}},
+	}, {
+		Chapter: 4, Number: 1, Paragraph: true, Indent: 1,
+		Segments: []Segment{{Text: "Sing,"}, {Break: true, Indent: 2}, {Text: "O earth."}},
+	}}}
+
+	tests := []struct {
+		name, got, want string
+	}{
+		{"text", passage.Text(), "John 3:16–4:1\n\nGod's Love\n\n[3:16] For God so loved(1) the <world>. [17] He sent his Son.\n\n" +
+			"  [4:1] Sing,\n    O earth.\n\nFootnotes\n\n(1) Or only\n"},
+		{"html", passage.HTML(), "<h3>John 3:16–4:1</h3>\n<h3>God&#39;s Love</h3>\n" +
+			`<p class="p"><sup class="v">3:16</sup><span class="wj">For God so loved</span>` +
+			`<sup class="fn"><a href="#fn1" id="fnref1">1</a></sup> the &lt;world&gt;. <sup class="v">17</sup>He sent his Son.</p>` + "\n" +
+			`<p class="q1"><sup class="v">4:1</sup>Sing,</p>` + "\n" + `<p class="q2">O earth.</p>` + "\n" +
+			`<ol class="footnotes">` + "\n" + `<li id="fn1">Or only <a href="#fnref1">↩</a></li>` + "\n</ol>\n"},
+		{"markdown", passage.Markdown(), "### John 3:16–4:1\n\n### God's Love\n\n**3:16** For God so loved[^1] the <world>. **17** He sent his Son.\n\n" +
+			"**4:1** Sing,  \n&emsp;O earth.\n\n[^1]: Or only\n"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
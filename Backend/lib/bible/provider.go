@@ -0,0 +1,151 @@
+package bible
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Provider serves Bible text for a source of translations. Results are in
+// the JSON shape of api.scripture.api.bible, which the reader understands.
+type Provider interface {
+	// Translations lists the translations the provider serves, as entries
+	// for the translations list.
+	Translations(ctx context.Context) ([]interface{}, error)
+	Books(ctx context.Context, translationID string) (map[string]interface{}, error)
+	Chapters(ctx context.Context, translationID, bookID string) (map[string]interface{}, error)
+	// Passage returns a chapter given as e.g. "JHN.3".
+	Passage(ctx context.Context, translationID, reference string) (map[string]interface{}, error)
+}
+
+var (
+	ErrUnsupported = errors.New("not supported for this translation")
+	ErrMissingKey  = errors.New("missing API key")
+)
+
+// UpstreamError is a failed call to a remote scripture service.
+type UpstreamError struct {
+	Service string
+	Status  int // 0 when the service could not be reached
+	Body    string
+	Err     error
+}
+
+func (e *UpstreamError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Service, e.Err)
+	}
+	return fmt.Sprintf("%s returned %d: %s", e.Service, e.Status, e.Body)
+}
+
+func (e *UpstreamError) Unwrap() error { return e.Err }
+
+// Providers picks the provider for each translation: stored translations
+// first, then ESV, then API.Bible for everything else. Upstream providers
+// answer through Cache when one is set, and translations the Catalog
+// disables are not served.
+type Providers struct {
+	Local    *Local
+	ESV      *ESV
+	APIBible *APIBible
+	Cache    *Cache
+	Catalog  *Catalog
+}
+
+func NewProviders(db *gorm.DB, apiBibleKey, esvKey string, cache *Cache) *Providers {
+	return &Providers{
+		Local:    &Local{DB: db},
+		ESV:      NewESV(esvKey),
+		APIBible: NewAPIBible(apiBibleKey),
+		Cache:    cache,
+		Catalog:  &Catalog{},
+	}
+}
+
+func (p *Providers) For(translationID string) Provider {
+	return p.choose(translationID, p.ESV)
+}
+
+// choose is For with esv serving the ESV.
+func (p *Providers) choose(translationID string, esv *ESV) Provider {
+	switch {
+	case !p.Catalog.Enabled(translationID):
+		return unavailable{}
+	case p.Local.Has(translationID):
+		return p.Local
+	case strings.EqualFold(translationID, esvID):
+		return p.Cache.Wrap(esv)
+	}
+	return p.Cache.Wrap(p.APIBible)
+}
+
+// Passage fetches a passage as its provider returns it, with its
+// attribution added. Like Range, it refuses more verses than the
+// translation's licence lets us show at once. The ESV comes without the
+// headings and footnotes Range reads, as this endpoint has always served it.
+func (p *Providers) Passage(ctx context.Context, translationID, reference string) (map[string]interface{}, error) {
+	plain := *p.ESV
+	plain.Plain = true
+	result, err := p.choose(translationID, &plain).Passage(ctx, translationID, reference)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.checkLength(translationID, countVerses(result)); err != nil {
+		return nil, err
+	}
+	result["attribution"] = p.Attribution(translationID, result)
+	return result, nil
+}
+
+// Translations lists every provider's enabled translations with their
+// catalog details. When API.Bible fails the others are still listed, so
+// stored translations keep the reader working without it.
+func (p *Providers) Translations(ctx context.Context) ([]interface{}, error) {
+	stored, err := p.Local.Translations(ctx)
+	if err != nil {
+		log.Printf("Failed to list stored translations: %v", err)
+	}
+
+	remote, err := p.Cache.Wrap(p.APIBible).Translations(ctx)
+	if err != nil {
+		if len(stored) == 0 {
+			return nil, err
+		}
+		log.Printf("Listing stored translations only: %v", err)
+	}
+
+	esv, _ := p.ESV.Translations(ctx)
+	all := append(remote, esv...)
+	return p.catalogEntries(append(all, stored...)), nil
+}
+
+// bookEntry is a book in the books list.
+func bookEntry(translationID, bookID, name string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":           bookID,
+		"bibleId":      translationID,
+		"abbreviation": bookID,
+		"name":         name,
+		"nameLong":     name,
+	}
+}
+
+// chapterList is the chapters list of a book.
+func chapterList(translationID, bookID, name string, chapters int) map[string]interface{} {
+	data := make([]interface{}, 0, chapters)
+	for n := 1; n <= chapters; n++ {
+		data = append(data, map[string]interface{}{
+			"id":        bookID + "." + strconv.Itoa(n),
+			"bibleId":   translationID,
+			"bookId":    bookID,
+			"number":    strconv.Itoa(n),
+			"reference": name + " " + strconv.Itoa(n),
+		})
+	}
+	return map[string]interface{}{"data": data}
+}
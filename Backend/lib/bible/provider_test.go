@@ -0,0 +1,197 @@
+package bible
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"theword/Backend/lib/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func standIn(t *testing.T, header, key string, routes map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(header) != key {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		body, ok := routes[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAPIBible(t *testing.T) {
+	srv := standIn(t, "api-key", "k", map[string]string{
+		"/bibles":                      `{"data":[{"id":"de4e12af7f28f599-02","name":"King James (Authorised) Version"}]}`,
+		"/bibles/B/books/JHN/chapters": `{"data":[{"id":"JHN.intro","number":"intro"},{"id":"JHN.1","number":"1"}]}`,
+		"/bibles/B/chapters/JHN.3":     `{"data":{"id":"JHN.3","content":[]}}`,
+		"/bibles/B/books":              `not json`,
+	})
+	a := &APIBible{BaseURL: srv.URL, Key: "k", Client: srv.Client()}
+	ctx := context.Background()
+
+	translations, err := a.Translations(ctx)
+	if err != nil || len(translations) != 1 {
+		t.Fatalf("Translations() = %v, %v", translations, err)
+	}
+
+	chapters, err := a.Chapters(ctx, "B", "JHN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data := chapters["data"].([]interface{}); len(data) != 1 {
+		t.Errorf("Chapters() kept the intro: %v", data)
+	}
+
+	passage, err := a.Passage(ctx, "B", "JHN.3")
+	if err != nil || passage["data"].(map[string]interface{})["id"] != "JHN.3" {
+		t.Errorf("Passage() = %v, %v", passage, err)
+	}
+
+	var upstream *UpstreamError
+	if _, err := a.Books(ctx, "B"); !errors.As(err, &upstream) {
+		t.Errorf("Books() with a bad body = %v, want UpstreamError", err)
+	}
+	if _, err := a.Passage(ctx, "B", "JHN.99"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Passage() of a missing chapter = %v, want ErrNotFound", err)
+	}
+	if _, err := (&APIBible{BaseURL: srv.URL, Key: "wrong", Client: srv.Client()}).Books(ctx, "B"); !errors.As(err, &upstream) || upstream.Status != http.StatusUnauthorized {
+		t.Errorf("Books() with a bad key = %v, want a 401 UpstreamError", err)
+	}
+	if _, err := (&APIBible{BaseURL: srv.URL}).Books(ctx, "B"); !errors.Is(err, ErrMissingKey) {
+		t.Errorf("Books() without a key = %v, want ErrMissingKey", err)
+	}
+}
+
+func TestESV(t *testing.T) {
+	var query string
+	var headings []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Token k" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		query = r.URL.Query().Get("q")
+		headings = append(headings, r.URL.Query().Get("include-headings")+" "+r.URL.Query().Get("include-footnotes"))
+		w.Write([]byte(`{"passages":["The Burden of Nineveh\n\n  [1] An oracle concerning Nineveh.  [2] The LORD is a jealous God(1)\n    and avenging;\n\nFootnotes\n\n(1) 1:2 Or *zealous*\n (ESV)"]}`))
+	}))
+	defer srv.Close()
+	e := &ESV{BaseURL: srv.URL, Key: "k", Client: srv.Client()}
+
+	passage, err := e.Passage(context.Background(), "ESV", "NAM.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "Nahum 1" {
+		t.Errorf("queried %q, want %q", query, "Nahum 1")
+	}
+	if c := passage["data"].(map[string]interface{})["copyright"]; c != "(ESV)" {
+		t.Errorf("copyright = %v, want (ESV)", c)
+	}
+
+	verses := NormalizeChapter(passage, "NAM.1")
+	if len(verses) != 2 {
+		t.Fatalf("got %d verses, want 2", len(verses))
+	}
+	if h := verses[0].Headings; len(h) != 1 || h[0] != "The Burden of Nineveh" {
+		t.Errorf("headings = %q", h)
+	}
+	second := verses[1]
+	if second.ID != "NAM.1.2" || second.Text != "The LORD is a jealous God and avenging;" {
+		t.Errorf("verse 2 = %s %q", second.ID, second.Text)
+	}
+	want := []Segment{{Text: "The LORD is a jealous God"}, {Footnote: 1}, {Break: true, Indent: 1}, {Text: "and avenging;"}}
+	if !reflect.DeepEqual(second.Segments, want) || len(second.Footnotes) != 1 || second.Footnotes[0] != "Or *zealous*" {
+		t.Errorf("verse 2 segments = %+v, footnotes %q", second.Segments, second.Footnotes)
+	}
+
+	books, err := e.Books(context.Background(), "ESV")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(books["data"].([]interface{})); n != 66 {
+		t.Errorf("Books() listed %d books, want 66", n)
+	}
+	if _, err := e.Chapters(context.Background(), "ESV", "TOB"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Chapters(TOB) = %v, want ErrNotFound", err)
+	}
+
+	// The legacy passage endpoint keeps the text it has always had, cached
+	// apart from the full chapter the passage endpoints read.
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "bible.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.AutoMigrate(&models.BibleTranslation{})
+	headings = nil
+	p := NewProviders(db, "", "", NewCache(1<<20, nil))
+	p.ESV = e
+	ranges, _ := ParseReference("Nahum 1:1")
+	for i := 0; i < 2; i++ {
+		if _, err := p.Passage(context.Background(), "ESV", "NAM.1"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := p.Range(context.Background(), "ESV", ranges); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []string{"false false", "true true"}; !reflect.DeepEqual(headings, want) {
+		t.Errorf("headings and footnotes requested = %q, want %q", headings, want)
+	}
+}
+
+func TestLocal(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "bible.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.AutoMigrate(&models.BibleTranslation{}, &models.BibleBook{}, &models.BibleVerse{})
+
+	var text Text
+	src := "\\id JHN\n\\h John\n\\c 3\n\\s1 Nicodemus\n\\p\n\\v 1 Now there was a man.\n\\v 2 The same came.\n"
+	if err := ParseUSFM(strings.NewReader(src), &text); err != nil {
+		t.Fatal(err)
+	}
+	if err := Import(db, models.BibleTranslation{TranslationID: "WEB", Name: "World English Bible"}, &text); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewProviders(db, "", "", nil)
+	if _, ok := p.For("WEB").(*Local); !ok {
+		t.Fatalf("For(WEB) = %T, want *Local", p.For("WEB"))
+	}
+	if _, ok := p.For("ESV").(*ESV); !ok {
+		t.Errorf("For(ESV) = %T, want *ESV", p.For("ESV"))
+	}
+
+	translations, err := p.Translations(context.Background())
+	if err != nil || len(translations) != 2 {
+		t.Errorf("Translations() without an API.Bible key = %v, %v; want ESV and WEB", translations, err)
+	}
+
+	passage, err := p.Local.Passage(context.Background(), "WEB", "jhn 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := passage["data"].(map[string]interface{})["content"].([]interface{})
+	if len(content) != 2 {
+		t.Fatalf("got %d paras, want a heading and a paragraph", len(content))
+	}
+
+	if _, err := p.Local.Chapters(context.Background(), "WEB", "GEN"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Chapters() of a missing book = %v, want ErrNotFound", err)
+	}
+}
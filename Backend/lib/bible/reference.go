@@ -0,0 +1,600 @@
+package bible
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidReference is returned, wrapped with the reason, for text that
+// isn't a Scripture reference.
+var ErrInvalidReference = errors.New("invalid reference")
+
+const (
+	// MaxVerse is the most verses any chapter has (Psalm 119).
+	MaxVerse = 176
+	// maxReferenceLength bounds the text ParseReference will read.
+	maxReferenceLength = 1000
+)
+
+// Range is a span of verses in one book, e.g. John 3:16-18. Either both
+// verses are set or neither is, in which case the range covers whole
+// chapters: John 3 is {JHN 3 0 3 0}.
+type Range struct {
+	Book         string `json:"book"`
+	StartChapter int    `json:"start_chapter"`
+	StartVerse   int    `json:"start_verse"`
+	EndChapter   int    `json:"end_chapter"`
+	EndVerse     int    `json:"end_verse"`
+}
+
+// IsVerse reports whether the range is a single verse.
+func (r Range) IsVerse() bool {
+	return r.StartVerse > 0 && r.StartChapter == r.EndChapter && r.StartVerse == r.EndVerse
+}
+
+// ChapterID returns the ID of the chapter the range starts in, e.g. "JHN.3".
+func (r Range) ChapterID() string {
+	return r.Book + "." + strconv.Itoa(r.StartChapter)
+}
+
+// VerseID returns the ID of the verse the range starts at, e.g. "JHN.3.16",
+// or "" for a range of whole chapters.
+func (r Range) VerseID() string {
+	if r.StartVerse == 0 {
+		return ""
+	}
+	return r.ChapterID() + "." + strconv.Itoa(r.StartVerse)
+}
+
+// Span is the part of a Range that falls in one chapter. A zero StartVerse
+// means the whole chapter; a zero EndVerse means to the end of it.
+type Span struct {
+	Chapter    int
+	StartVerse int
+	EndVerse   int
+}
+
+// Spans splits the range by chapter.
+func (r Range) Spans() []Span {
+	var spans []Span
+	for ch := r.StartChapter; ch <= r.EndChapter; ch++ {
+		s := Span{Chapter: ch}
+		if r.StartVerse > 0 {
+			if ch == r.StartChapter {
+				s.StartVerse = r.StartVerse
+			} else {
+				s.StartVerse = 1
+			}
+			if ch == r.EndChapter {
+				s.EndVerse = r.EndVerse
+			}
+		}
+		spans = append(spans, s)
+	}
+	return spans
+}
+
+func (r Range) wholeBook() bool {
+	b, _ := BookByUSFM(r.Book)
+	return r.StartChapter == 1 && r.StartVerse == 0 && r.EndChapter == b.Chapters && r.EndVerse == 0
+}
+
+// ParseReference reads references as people type them, e.g.
+// "Jn 3:16-18; Rom 8:28", "1 Cor 13", "Gen 1:1-2:3", "Ps 23, 24", "Jude 3"
+// or "JHN.3.16". Books may be given by name, abbreviation, USFM or OSIS
+// code, or any unambiguous prefix of the name.
+//
+// After a comma a bare number continues the kind of list before it, so
+// "Jn 3:16, 18" is two verses and "Ps 23, 24" two chapters; after a
+// semicolon it is always a chapter. In single-chapter books bare numbers are
+// verses.
+func ParseReference(s string) ([]Range, error) {
+	if len(s) > maxReferenceLength {
+		return nil, fmt.Errorf("%w: too long", ErrInvalidReference)
+	}
+	toks, err := tokenizeReference(s)
+	if err != nil {
+		return nil, err
+	}
+	p := refParser{toks: toks}
+	return p.parse()
+}
+
+type refTokenKind int
+
+const (
+	refWord refTokenKind = iota
+	refNumber
+	refColon
+	refDot
+	refDash
+	refComma
+	refSemicolon
+)
+
+type refToken struct {
+	kind refTokenKind
+	text string
+	n    int
+}
+
+func tokenizeReference(s string) ([]refToken, error) {
+	var toks []refToken
+	rs := []rune(s)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r) || r == '(' || r == ')':
+			i++
+		case codeAt(rs[i:]) != "":
+			code := codeAt(rs[i:])
+			toks = append(toks, refToken{kind: refWord, text: strings.ToLower(code)})
+			i += len(code)
+		case unicode.IsLetter(r):
+			j := i
+			for j < len(rs) && unicode.IsLetter(rs[j]) {
+				j++
+			}
+			toks = append(toks, refToken{kind: refWord, text: strings.ToLower(string(rs[i:j]))})
+			i = j
+		case r >= '0' && r <= '9':
+			j := i
+			for j < len(rs) && rs[j] >= '0' && rs[j] <= '9' {
+				j++
+			}
+			if j-i > 3 {
+				return nil, fmt.Errorf("%w: number %s is too large", ErrInvalidReference, string(rs[i:j]))
+			}
+			n, _ := strconv.Atoi(string(rs[i:j]))
+			toks = append(toks, refToken{kind: refNumber, text: string(rs[i:j]), n: n})
+			i = j
+			// Part-verse suffixes such as the "a" in 16a are dropped.
+			if i < len(rs) && (rs[i] == 'a' || rs[i] == 'b' || rs[i] == 'c') && (i+1 == len(rs) || !unicode.IsLetter(rs[i+1])) {
+				i++
+			}
+		default:
+			kind, ok := map[rune]refTokenKind{
+				':': refColon, '.': refDot, ',': refComma, ';': refSemicolon,
+				'-': refDash, '‐': refDash, '‑': refDash, '–': refDash, '—': refDash,
+			}[r]
+			if !ok {
+				return nil, fmt.Errorf("%w: unexpected %q", ErrInvalidReference, r)
+			}
+			toks = append(toks, refToken{kind: kind, text: string(r)})
+			i++
+		}
+	}
+	if len(toks) == 0 {
+		return nil, fmt.Errorf("%w: empty", ErrInvalidReference)
+	}
+	return toks, nil
+}
+
+// codeAt returns the USFM code s starts with if it is one of those with a
+// digit inside, such as S3Y, which would otherwise read as a book and a
+// chapter. Only the upper-case code counts, so "Ps2" is still Psalm 2.
+func codeAt(s []rune) string {
+	for _, b := range Canon {
+		code := b.USFM
+		if code[0] >= '0' && code[0] <= '9' || !strings.ContainsAny(code, "0123456789") || len(s) < len(code) || string(s[:len(code)]) != code {
+			continue
+		}
+		if len(s) == len(code) || !unicode.IsLetter(s[len(code)]) && !unicode.IsDigit(s[len(code)]) {
+			return code
+		}
+	}
+	return ""
+}
+
+type refParser struct {
+	toks []refToken
+	pos  int
+
+	book    BookInfo
+	hasBook bool
+	chapter int  // the chapter bare verse numbers belong to
+	verses  bool // whether a bare number is a verse
+	ids     bool // whether the book was written as an ID, e.g. "JHN.3"
+}
+
+func (p *refParser) peek() (refToken, bool) {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos], true
+	}
+	return refToken{}, false
+}
+
+func (p *refParser) accept(kinds ...refTokenKind) bool {
+	t, ok := p.peek()
+	if !ok {
+		return false
+	}
+	for _, k := range kinds {
+		if t.kind == k {
+			p.pos++
+			return true
+		}
+	}
+	return false
+}
+
+func (p *refParser) parse() ([]Range, error) {
+	var ranges []Range
+	for {
+		r, err := p.item()
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+
+		t, ok := p.peek()
+		switch {
+		case !ok:
+			return ranges, nil
+		case t.kind == refComma:
+			p.pos++
+		case t.kind == refSemicolon:
+			p.pos++
+			p.verses = false
+		case p.startsBook():
+			// OSIS lists separate references with spaces alone.
+		default:
+			return nil, fmt.Errorf("%w: unexpected %q", ErrInvalidReference, t.text)
+		}
+	}
+}
+
+// startsBook reports whether the next tokens name a book rather than
+// continue a passage.
+func (p *refParser) startsBook() bool {
+	t, ok := p.peek()
+	if !ok {
+		return false
+	}
+	if t.kind == refWord {
+		return true
+	}
+	return t.kind == refNumber && p.pos+1 < len(p.toks) && p.toks[p.pos+1].kind == refWord
+}
+
+// item parses one book, chapter or verse range, with its book if given.
+func (p *refParser) item() (Range, error) {
+	if p.startsBook() {
+		b, err := p.bookName()
+		if err != nil {
+			return Range{}, err
+		}
+		p.book, p.hasBook, p.verses = b, true, false
+		// IDs such as "JUD.1" always give the chapter, even in
+		// single-chapter books.
+		p.ids = p.accept(refDot)
+
+		// In OSIS lists "1Cor 1Cor.1" is the book, then its first chapter.
+		if t, ok := p.peek(); !ok || t.kind != refNumber || !p.ids && p.startsBook() {
+			return Range{Book: b.USFM, StartChapter: 1, EndChapter: b.Chapters}, nil
+		}
+	}
+	if !p.hasBook {
+		return Range{}, fmt.Errorf("%w: no book given", ErrInvalidReference)
+	}
+
+	startChapter, startVerse, err := p.point()
+	if err != nil {
+		return Range{}, err
+	}
+	endChapter, endVerse := startChapter, startVerse
+	if p.accept(refDash) {
+		if p.startsBook() {
+			b, err := p.bookName()
+			if err != nil {
+				return Range{}, err
+			}
+			if b.USFM != p.book.USFM {
+				return Range{}, fmt.Errorf("%w: ranges cannot span books", ErrInvalidReference)
+			}
+			p.accept(refDot)
+		}
+		ch, v, explicit, err := p.numbers()
+		if err != nil {
+			return Range{}, err
+		}
+		switch {
+		case explicit:
+			endChapter, endVerse = ch, v
+		case startVerse > 0:
+			endVerse = ch
+		default:
+			endChapter = ch
+		}
+	}
+
+	r := Range{Book: p.book.USFM, StartChapter: startChapter, StartVerse: startVerse, EndChapter: endChapter, EndVerse: endVerse}
+	if r.StartVerse == 0 && r.EndVerse > 0 {
+		r.StartVerse = 1
+	}
+	if err := p.check(r); err != nil {
+		return Range{}, err
+	}
+	p.chapter, p.verses = r.EndChapter, r.StartVerse > 0
+	return r, nil
+}
+
+// point parses where a range starts: a chapter, a chapter and verse, or a
+// bare verse where the context calls for one.
+func (p *refParser) point() (chapter, verse int, err error) {
+	ch, v, explicit, err := p.numbers()
+	switch {
+	case err != nil:
+		return 0, 0, err
+	case explicit:
+		return ch, v, nil
+	case p.book.Chapters == 1 && !p.ids:
+		return 1, ch, nil
+	case p.verses:
+		return p.chapter, ch, nil
+	}
+	return ch, 0, nil
+}
+
+// numbers parses "n" or "n:m", reporting whether the second number was
+// given.
+func (p *refParser) numbers() (n, m int, explicit bool, err error) {
+	t, ok := p.peek()
+	if !ok || t.kind != refNumber {
+		return 0, 0, false, fmt.Errorf("%w: expected a number", ErrInvalidReference)
+	}
+	p.pos++
+	if t.n == 0 {
+		return 0, 0, false, fmt.Errorf("%w: chapters and verses start at 1", ErrInvalidReference)
+	}
+	if !p.accept(refColon, refDot) {
+		return t.n, 0, false, nil
+	}
+	v, ok := p.peek()
+	if !ok || v.kind != refNumber || v.n == 0 {
+		return 0, 0, false, fmt.Errorf("%w: expected a verse", ErrInvalidReference)
+	}
+	p.pos++
+	return t.n, v.n, true, nil
+}
+
+func (p *refParser) check(r Range) error {
+	b := p.book
+	switch {
+	case r.StartChapter < 1 || r.EndChapter > b.Chapters:
+		return fmt.Errorf("%w: %s has %d chapters", ErrInvalidReference, b.Name, b.Chapters)
+	case r.StartVerse > 0 && r.EndVerse == 0:
+		return fmt.Errorf("%w: missing verse", ErrInvalidReference)
+	case r.StartVerse > MaxVerse || r.EndVerse > MaxVerse:
+		return fmt.Errorf("%w: no chapter has that many verses", ErrInvalidReference)
+	case r.EndChapter < r.StartChapter || r.EndChapter == r.StartChapter && r.EndVerse < r.StartVerse:
+		return fmt.Errorf("%w: range ends before it starts", ErrInvalidReference)
+	}
+	return nil
+}
+
+// bookName reads a book name, which may start with a number ("1 John",
+// "1Jn"), a roman numeral or ordinal ("II Kings", "First Peter"), and run to
+// several words ("Song of Solomon").
+func (p *refParser) bookName() (BookInfo, error) {
+	var key strings.Builder
+	var words []string
+	if t, _ := p.peek(); t.kind == refNumber {
+		key.WriteString(t.text)
+		words = append(words, t.text)
+		p.pos++
+		if t, ok := p.peek(); ok && t.kind == refWord && ordinalSuffixes[t.text] &&
+			p.pos+1 < len(p.toks) && p.toks[p.pos+1].kind == refWord {
+			p.pos++
+		}
+	}
+	// Remember where each word ends, so a name that only matches without
+	// its last words can give them back: "PrAzar AddPs" is two books.
+	var keys []string
+	var ends []int
+	for {
+		t, ok := p.peek()
+		if !ok || t.kind != refWord {
+			break
+		}
+		word := t.text
+		if key.Len() == 0 && p.pos+1 < len(p.toks) && p.toks[p.pos+1].kind == refWord {
+			if n, ok := bookNumerals[word]; ok {
+				word = n
+			}
+		}
+		key.WriteString(word)
+		words = append(words, t.text)
+		p.pos++
+		keys = append(keys, key.String())
+		ends = append(ends, p.pos)
+		// Allow "1 Sam." and "Song of Sol. 2", but leave "JHN.3" alone.
+		if p.pos+1 < len(p.toks) && p.toks[p.pos].kind == refDot && p.toks[p.pos+1].kind == refWord {
+			p.pos++
+		}
+	}
+
+	// "Psalm 151" is a book of its own, not a chapter of Psalms.
+	if t, ok := p.peek(); ok && t.kind == refNumber {
+		for _, b := range Canon {
+			if bookKey(b.Name) == key.String()+t.text {
+				p.pos++
+				return b, nil
+			}
+		}
+	}
+	if b, ok := lookupBookName(key.String()); ok {
+		return b, nil
+	}
+	for i := len(keys) - 2; i >= 0; i-- {
+		if b, ok := booksByKey[keys[i]]; ok {
+			p.pos = ends[i]
+			return b, nil
+		}
+	}
+	return BookInfo{}, fmt.Errorf("%w: unknown book %q", ErrInvalidReference, strings.Join(words, " "))
+}
+
+var (
+	bookNumerals = map[string]string{
+		"i": "1", "ii": "2", "iii": "3", "iv": "4",
+		"first": "1", "second": "2", "third": "3", "fourth": "4",
+	}
+	ordinalSuffixes = map[string]bool{"st": true, "nd": true, "rd": true, "th": true}
+)
+
+// booksByKey maps every name, abbreviation and code, lower-cased with spaces
+// and dots removed, to its book.
+var booksByKey = map[string]BookInfo{}
+
+func init() {
+	for _, b := range Canon {
+		for _, name := range append([]string{b.USFM, b.OSIS, b.Name}, b.Abbreviations...) {
+			key := bookKey(name)
+			if _, ok := booksByKey[key]; !ok {
+				booksByKey[key] = b
+			}
+		}
+	}
+}
+
+func bookKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '.' || r == '(' || r == ')' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, name)
+}
+
+// lookupBookName finds a book by key, falling back to the only book whose
+// name starts with it.
+func lookupBookName(key string) (BookInfo, bool) {
+	if b, ok := booksByKey[key]; ok {
+		return b, true
+	}
+	if len(key) < 3 {
+		return BookInfo{}, false
+	}
+	var found BookInfo
+	matches := 0
+	for _, b := range Canon {
+		if strings.HasPrefix(bookKey(b.Name), key) {
+			found = b
+			matches++
+		}
+	}
+	return found, matches == 1
+}
+
+// RefStyle chooses how FormatReference writes references.
+type RefStyle int
+
+const (
+	RefFull  RefStyle = iota // John 3:16–18; Romans 8:28
+	RefShort                 // John 3:16-18; Rom 8:28
+	RefOSIS                  // John.3.16-John.3.18 Rom.8.28
+	RefID                    // JHN.3.16-JHN.3.18,ROM.8.28
+)
+
+// String formats the range in RefFull style.
+func (r Range) String() string {
+	return FormatReference([]Range{r}, RefFull)
+}
+
+// FormatReference writes ranges back out in the given style. ParseReference
+// reads every style back to the same ranges.
+func FormatReference(ranges []Range, style RefStyle) string {
+	if style == RefOSIS || style == RefID {
+		return formatIDs(ranges, style)
+	}
+
+	dash := "-"
+	if style == RefFull {
+		dash = "–"
+	}
+	var out strings.Builder
+	for i, r := range ranges {
+		b, _ := BookByUSFM(r.Book)
+		sameBook := i > 0 && ranges[i-1].Book == r.Book && !ranges[i-1].wholeBook() && !r.wholeBook()
+		switch {
+		case i == 0:
+		case sameBook && r.StartVerse > 0 && ranges[i-1].StartVerse > 0 && ranges[i-1].EndChapter == r.StartChapter:
+			// "John 3:16, 18" — the verse continues the chapter before.
+			out.WriteString(", ")
+			out.WriteString(formatVerses(r, dash, true))
+			continue
+		default:
+			out.WriteString("; ")
+		}
+		if !sameBook {
+			name := b.Name
+			if style == RefShort && len(b.Abbreviations) > 0 {
+				name = b.Abbreviations[0]
+			}
+			out.WriteString(name)
+			if r.wholeBook() {
+				continue
+			}
+			out.WriteString(" ")
+		}
+
+		switch {
+		case r.StartVerse > 0:
+			out.WriteString(formatVerses(r, dash, b.Chapters == 1))
+		case r.StartChapter == r.EndChapter:
+			out.WriteString(strconv.Itoa(r.StartChapter))
+		default:
+			out.WriteString(strconv.Itoa(r.StartChapter) + dash + strconv.Itoa(r.EndChapter))
+		}
+	}
+	return out.String()
+}
+
+// formatVerses writes a verse range, leaving out the chapter where the
+// reader already knows it.
+func formatVerses(r Range, dash string, bare bool) string {
+	start := strconv.Itoa(r.StartChapter) + ":" + strconv.Itoa(r.StartVerse)
+	if bare {
+		start = strconv.Itoa(r.StartVerse)
+	}
+	switch {
+	case r.StartChapter != r.EndChapter:
+		return start + dash + strconv.Itoa(r.EndChapter) + ":" + strconv.Itoa(r.EndVerse)
+	case r.StartVerse != r.EndVerse:
+		return start + dash + strconv.Itoa(r.EndVerse)
+	}
+	return start
+}
+
+func formatIDs(ranges []Range, style RefStyle) string {
+	refs := make([]string, len(ranges))
+	for i, r := range ranges {
+		b, _ := BookByUSFM(r.Book)
+		code := b.USFM
+		if style == RefOSIS {
+			code = b.OSIS
+		}
+		if r.wholeBook() {
+			refs[i] = code
+			continue
+		}
+		start := code + "." + strconv.Itoa(r.StartChapter)
+		end := code + "." + strconv.Itoa(r.EndChapter)
+		if r.StartVerse > 0 {
+			start += "." + strconv.Itoa(r.StartVerse)
+			end += "." + strconv.Itoa(r.EndVerse)
+		}
+		refs[i] = start
+		if end != start {
+			refs[i] += "-" + end
+		}
+	}
+	if style == RefOSIS {
+		return strings.Join(refs, " ")
+	}
+	return strings.Join(refs, ",")
+}
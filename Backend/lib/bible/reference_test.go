@@ -0,0 +1,150 @@
+package bible
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []Range
+	}{
+		{"Jn 3:16", []Range{{"JHN", 3, 16, 3, 16}}},
+		{"John 3:16-18", []Range{{"JHN", 3, 16, 3, 18}}},
+		{"jn 3.16–18", []Range{{"JHN", 3, 16, 3, 18}}},
+		{"JHN.3.16", []Range{{"JHN", 3, 16, 3, 16}}},
+		{"JHN.3", []Range{{"JHN", 3, 0, 3, 0}}},
+		{"Jn 3:16-18; Rom 8:28", []Range{{"JHN", 3, 16, 3, 18}, {"ROM", 8, 28, 8, 28}}},
+		{"Gen 1:1-2:3", []Range{{"GEN", 1, 1, 2, 3}}},
+		{"Gen 1-2:3", []Range{{"GEN", 1, 1, 2, 3}}},
+		{"Ps 23, 24", []Range{{"PSA", 23, 0, 23, 0}, {"PSA", 24, 0, 24, 0}}},
+		{"Ps 23-24", []Range{{"PSA", 23, 0, 24, 0}}},
+		{"Jn 3:16, 18-20", []Range{{"JHN", 3, 16, 3, 16}, {"JHN", 3, 18, 3, 20}}},
+		{"Jn 3:16; 18", []Range{{"JHN", 3, 16, 3, 16}, {"JHN", 18, 0, 18, 0}}},
+		{"Jn 3:16-4:2, 5", []Range{{"JHN", 3, 16, 4, 2}, {"JHN", 4, 5, 4, 5}}},
+		{"Jn 3:16, 1 Cor 13", []Range{{"JHN", 3, 16, 3, 16}, {"1CO", 13, 0, 13, 0}}},
+		{"Jude 3", []Range{{"JUD", 1, 3, 1, 3}}},
+		{"Jude 3-5", []Range{{"JUD", 1, 3, 1, 5}}},
+		{"Jude 1:3", []Range{{"JUD", 1, 3, 1, 3}}},
+		{"Jude", []Range{{"JUD", 1, 0, 1, 0}}},
+		{"Romans", []Range{{"ROM", 1, 0, 16, 0}}},
+		{"1 Sam 3", []Range{{"1SA", 3, 0, 3, 0}}},
+		{"1Sam. 3:1", []Range{{"1SA", 3, 1, 3, 1}}},
+		{"I Samuel 3", []Range{{"1SA", 3, 0, 3, 0}}},
+		{"II Kings 2", []Range{{"2KI", 2, 0, 2, 0}}},
+		{"First John 4:8", []Range{{"1JN", 4, 8, 4, 8}}},
+		{"3rd John 4", []Range{{"3JN", 1, 4, 1, 4}}},
+		{"Song of Solomon 2:1", []Range{{"SNG", 2, 1, 2, 1}}},
+		{"Song of Songs 2", []Range{{"SNG", 2, 0, 2, 0}}},
+		{"SNG.2", []Range{{"SNG", 2, 0, 2, 0}}},
+		{"Revel 21", []Range{{"REV", 21, 0, 21, 0}}},
+		{"Philip 4:13", []Range{{"PHP", 4, 13, 4, 13}}},
+		{"Rom 8:28a", []Range{{"ROM", 8, 28, 8, 28}}},
+		{"John.3.16-John.3.18 Rom.8.28", []Range{{"JHN", 3, 16, 3, 18}, {"ROM", 8, 28, 8, 28}}},
+		{"S3Y.1", []Range{{"S3Y", 1, 0, 1, 0}}},
+		{"PS2", []Range{{"PS2", 1, 0, 1, 0}}},
+		{"Psalm 151", []Range{{"PS2", 1, 0, 1, 0}}},
+		{"JUD.1", []Range{{"JUD", 1, 0, 1, 0}}},
+		{"JUD.1.3", []Range{{"JUD", 1, 3, 1, 3}}},
+		{"1 Th 4:16", []Range{{"1TH", 4, 16, 4, 16}}},
+		{"Ps2", []Range{{"PSA", 2, 0, 2, 0}}},
+		{"Esther (Greek) 3", []Range{{"ESG", 3, 0, 3, 0}}},
+		{"NAM.1:2", []Range{{"NAM", 1, 2, 1, 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseReference(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseReferenceErrors(t *testing.T) {
+	for _, in := range []string{
+		"", "3:16", "Hezekiah 3", "Jn 22", "Jn 0", "Jn 3:0", "Jn 3:18-16", "Jn 4-3",
+		"Jn 3:16-Rom 1:1", "Jn 3:16 ff", "Thess 1", "Jn 3:999", "Jn 3:1000", "Jn 3:", "Jn 3:16-",
+		"Jn #3",
+	} {
+		if r, err := ParseReference(in); !errors.Is(err, ErrInvalidReference) {
+			t.Errorf("ParseReference(%q) = %v, %v; want ErrInvalidReference", in, r, err)
+		}
+	}
+}
+
+// TestBookKeys checks every name, abbreviation and code finds its own book.
+func TestBookKeys(t *testing.T) {
+	for _, b := range Canon {
+		for _, name := range append([]string{b.USFM, b.OSIS, b.Name}, b.Abbreviations...) {
+			got, err := ParseReference(name + " 1")
+			if err != nil {
+				t.Errorf("%s: %v", name, err)
+				continue
+			}
+			if got[0].Book != b.USFM {
+				t.Errorf("%q is %s, want %s", name, got[0].Book, b.USFM)
+			}
+		}
+	}
+}
+
+func TestFormatReference(t *testing.T) {
+	ranges, err := ParseReference("Jn 3:16-18, 20; 4; Rom 8:28-9:1; Jude 3; Ps 23-24; Rev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for style, want := range map[RefStyle]string{
+		RefFull:  "John 3:16–18, 20; 4; Romans 8:28–9:1; Jude 3; Psalms 23–24; Revelation",
+		RefShort: "John 3:16-18, 20; 4; Rom 8:28-9:1; Jude 3; Ps 23-24; Rev",
+		RefOSIS:  "John.3.16-John.3.18 John.3.20 John.4 Rom.8.28-Rom.9.1 Jude.1.3 Ps.23-Ps.24 Rev",
+		RefID:    "JHN.3.16-JHN.3.18,JHN.3.20,JHN.4,ROM.8.28-ROM.9.1,JUD.1.3,PSA.23-PSA.24,REV",
+	} {
+		if got := FormatReference(ranges, style); got != want {
+			t.Errorf("style %d: got %q, want %q", style, got, want)
+		}
+	}
+}
+
+// FuzzParseReference checks the parser never panics, only returns valid
+// ranges, and reads every style it formats back to the same ranges.
+func FuzzParseReference(f *testing.F) {
+	for _, s := range []string{
+		"Jn 3:16-18; Rom 8:28", "Gen 1:1-2:3", "Ps 23, 24", "Jude 3-5", "1 Cor 13",
+		"JHN.3.16-JHN.4.2,ROM.8", "Song of Sol. 2:1; 3", "II Kings 2:11a", "Rev",
+		"Jn 3:16, 18; 4:1-5:2, 6", "S3Y.1 PS2",
+	} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		ranges, err := ParseReference(s)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidReference) {
+				t.Fatalf("%q: unwrapped error %v", s, err)
+			}
+			return
+		}
+		for _, r := range ranges {
+			b, ok := BookByUSFM(r.Book)
+			if !ok || r.StartChapter < 1 || r.EndChapter > b.Chapters || (r.StartVerse == 0) != (r.EndVerse == 0) ||
+				r.EndChapter < r.StartChapter || r.EndChapter == r.StartChapter && r.EndVerse < r.StartVerse {
+				t.Fatalf("%q: invalid range %+v", s, r)
+			}
+		}
+		for _, style := range []RefStyle{RefFull, RefShort, RefOSIS, RefID} {
+			out := FormatReference(ranges, style)
+			again, err := ParseReference(out)
+			if err != nil {
+				t.Fatalf("%q formatted as %q: %v", s, out, err)
+			}
+			if !reflect.DeepEqual(again, ranges) {
+				t.Fatalf("%q formatted as %q reads back as %v, want %v", s, out, again, ranges)
+			}
+		}
+	})
+}
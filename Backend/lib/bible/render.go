@@ -0,0 +1,243 @@
+package bible
+
+import (
+	"html"
+	"strconv"
+	"strings"
+)
+
+// renderer writes a passage in one output format. render walks the passage
+// and calls it in reading order.
+type renderer interface {
+	heading(s string)
+	paragraph(indent int)
+	verse(label string)
+	text(s string, red bool)
+	footnote(n int)
+	finish(footnotes []string, copyright string) string
+}
+
+// Text renders the passage as plain text with [n] verse numbers, indented
+// poetry, and numbered footnotes and the copyright notice at the end.
+func (p *PassageText) Text() string {
+	return p.render(&textRenderer{})
+}
+
+// HTML renders the passage as an HTML fragment: h3 headings, p elements
+// classed p or q1, q2..., sup verse numbers, span.wj around words of Jesus
+// and footnotes linked to an ol at the end, then the copyright notice in
+// p.copyright.
+func (p *PassageText) HTML() string {
+	return p.render(&htmlRenderer{})
+}
+
+// Markdown renders the passage as Markdown with ### headings, bold verse
+// numbers, [^n] footnotes and the copyright notice in italics.
+func (p *PassageText) Markdown() string {
+	return p.render(&markdownRenderer{})
+}
+
+func (p *PassageText) render(r renderer) string {
+	r.heading(p.Reference)
+
+	// Passages spanning chapters label each chapter's first verse in full.
+	chapters := len(p.Verses) > 0 && p.Verses[0].Chapter != p.Verses[len(p.Verses)-1].Chapter
+
+	var footnotes []string
+	open, chapter := false, 0
+	for _, v := range p.Verses {
+		for _, h := range v.Headings {
+			r.heading(h)
+			open = false
+		}
+		if !open || v.Paragraph {
+			r.paragraph(v.Indent)
+		} else {
+			r.text(" ", false)
+		}
+		open = true
+
+		label := strconv.Itoa(v.Number)
+		if chapters && v.Chapter != chapter {
+			label = strconv.Itoa(v.Chapter) + ":" + label
+		}
+		chapter = v.Chapter
+		r.verse(label)
+
+		for _, s := range v.Segments {
+			switch {
+			case s.Break:
+				r.paragraph(s.Indent)
+			case s.Footnote > 0 && s.Footnote <= len(v.Footnotes):
+				footnotes = append(footnotes, v.Footnotes[s.Footnote-1])
+				r.footnote(len(footnotes))
+			case s.Text != "":
+				r.text(s.Text, s.WordsOfJesus)
+			}
+		}
+	}
+	copyright := ""
+	if p.Attribution != nil {
+		copyright = p.Attribution.Copyright
+	}
+	return r.finish(footnotes, copyright)
+}
+
+type textRenderer struct {
+	out    strings.Builder
+	indent int // indent of the open paragraph, -1 after a heading
+}
+
+func (t *textRenderer) heading(s string) {
+	if t.out.Len() > 0 {
+		t.out.WriteString("\n\n")
+	}
+	t.out.WriteString(s)
+	t.indent = -1
+}
+
+func (t *textRenderer) paragraph(indent int) {
+	// Lines of poetry follow each other; anything else gets a blank line.
+	if indent > 0 && t.indent > 0 {
+		t.out.WriteString("\n")
+	} else if t.out.Len() > 0 {
+		t.out.WriteString("\n\n")
+	}
+	t.out.WriteString(strings.Repeat("  ", indent))
+	t.indent = indent
+}
+
+func (t *textRenderer) verse(label string) {
+	t.out.WriteString("[" + label + "] ")
+}
+
+func (t *textRenderer) text(s string, red bool) {
+	t.out.WriteString(s)
+}
+
+func (t *textRenderer) footnote(n int) {
+	t.out.WriteString("(" + strconv.Itoa(n) + ")")
+}
+
+func (t *textRenderer) finish(footnotes []string, copyright string) string {
+	if len(footnotes) > 0 {
+		t.out.WriteString("\n\nFootnotes\n")
+		for i, f := range footnotes {
+			t.out.WriteString("\n(" + strconv.Itoa(i+1) + ") " + f)
+		}
+	}
+	if copyright != "" {
+		t.out.WriteString("\n\n" + copyright)
+	}
+	return t.out.String() + "\n"
+}
+
+type htmlRenderer struct {
+	out  strings.Builder
+	open bool // a <p> is open
+}
+
+func (h *htmlRenderer) close() {
+	if h.open {
+		h.out.WriteString("</p>\n")
+		h.open = false
+	}
+}
+
+func (h *htmlRenderer) heading(s string) {
+	h.close()
+	h.out.WriteString("<h3>" + html.EscapeString(s) + "</h3>\n")
+}
+
+func (h *htmlRenderer) paragraph(indent int) {
+	h.close()
+	class := "p"
+	if indent > 0 {
+		class = "q" + strconv.Itoa(indent)
+	}
+	h.out.WriteString(`<p class="` + class + `">`)
+	h.open = true
+}
+
+func (h *htmlRenderer) verse(label string) {
+	h.out.WriteString(`<sup class="v">` + html.EscapeString(label) + "</sup>")
+}
+
+func (h *htmlRenderer) text(s string, red bool) {
+	if red {
+		h.out.WriteString(`<span class="wj">` + html.EscapeString(s) + "</span>")
+	} else {
+		h.out.WriteString(html.EscapeString(s))
+	}
+}
+
+func (h *htmlRenderer) footnote(n int) {
+	id := strconv.Itoa(n)
+	h.out.WriteString(`<sup class="fn"><a href="#fn` + id + `" id="fnref` + id + `">` + id + "</a></sup>")
+}
+
+func (h *htmlRenderer) finish(footnotes []string, copyright string) string {
+	h.close()
+	if len(footnotes) > 0 {
+		h.out.WriteString(`<ol class="footnotes">` + "\n")
+		for i, f := range footnotes {
+			id := strconv.Itoa(i + 1)
+			h.out.WriteString(`<li id="fn` + id + `">` + html.EscapeString(f) +
+				` <a href="#fnref` + id + `">↩</a></li>` + "\n")
+		}
+		h.out.WriteString("</ol>\n")
+	}
+	if copyright != "" {
+		h.out.WriteString(`<p class="copyright">` + html.EscapeString(copyright) + "</p>\n")
+	}
+	return h.out.String()
+}
+
+type markdownRenderer struct {
+	out    strings.Builder
+	indent int // indent of the open paragraph, -1 after a heading
+}
+
+func (m *markdownRenderer) heading(s string) {
+	if m.out.Len() > 0 {
+		m.out.WriteString("\n\n")
+	}
+	m.out.WriteString("### " + s)
+	m.indent = -1
+}
+
+func (m *markdownRenderer) paragraph(indent int) {
+	// Lines of poetry end in a hard line break rather than a new paragraph.
+	if indent > 0 && m.indent > 0 {
+		m.out.WriteString("  \n")
+	} else if m.out.Len() > 0 {
+		m.out.WriteString("\n\n")
+	}
+	m.out.WriteString(strings.Repeat("&emsp;", max(indent-1, 0)))
+	m.indent = indent
+}
+
+func (m *markdownRenderer) verse(label string) {
+	m.out.WriteString("**" + label + "** ")
+}
+
+func (m *markdownRenderer) text(s string, red bool) {
+	m.out.WriteString(s)
+}
+
+func (m *markdownRenderer) footnote(n int) {
+	m.out.WriteString("[^" + strconv.Itoa(n) + "]")
+}
+
+func (m *markdownRenderer) finish(footnotes []string, copyright string) string {
+	if len(footnotes) > 0 {
+		m.out.WriteString("\n")
+		for i, f := range footnotes {
+			m.out.WriteString("\n[^" + strconv.Itoa(i+1) + "]: " + f)
+		}
+	}
+	if copyright != "" {
+		m.out.WriteString("\n\n_" + copyright + "_")
+	}
+	return m.out.String() + "\n"
+}
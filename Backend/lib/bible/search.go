@@ -0,0 +1,473 @@
+package bible
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"theword/Backend/lib/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrEmptyQuery = errors.New("empty search query")
+
+// searchConfigs maps ISO 639-3 language codes to Postgres text search
+// configurations. Other languages, and cached text whose language is not
+// known, are indexed without stemming.
+var searchConfigs = map[string]string{
+	"dan": "danish", "deu": "german", "eng": "english", "fin": "finnish", "fra": "french",
+	"hun": "hungarian", "ita": "italian", "nld": "dutch", "nob": "norwegian", "nor": "norwegian",
+	"por": "portuguese", "ron": "romanian", "rus": "russian", "spa": "spanish", "swe": "swedish",
+	"tur": "turkish",
+}
+
+func searchConfig(language string) string {
+	if config, ok := searchConfigs[strings.ToLower(language)]; ok {
+		return config
+	}
+	return "simple"
+}
+
+func knownConfig(config string) bool {
+	for _, c := range searchConfigs {
+		if c == config {
+			return true
+		}
+	}
+	return config == "simple"
+}
+
+func isPostgres(db *gorm.DB) bool {
+	return db.Dialector.Name() == "postgres"
+}
+
+// MigrateSearch creates the search index table, and on Postgres its tsvector
+// column and GIN index.
+func MigrateSearch(db *gorm.DB) error {
+	if err := db.AutoMigrate(&models.BibleSearchVerse{}); err != nil {
+		return err
+	}
+	if !isPostgres(db) {
+		return nil
+	}
+	if err := db.Exec(`ALTER TABLE bible_search_verses ADD COLUMN IF NOT EXISTS search tsvector`).Error; err != nil {
+		return err
+	}
+	return db.Exec(`CREATE INDEX IF NOT EXISTS idx_bible_search_verses_search ON bible_search_verses USING GIN (search)`).Error
+}
+
+// IndexTranslation rebuilds the search index of a stored translation.
+func IndexTranslation(db *gorm.DB, translationID string) error {
+	if err := MigrateSearch(db); err != nil {
+		return err
+	}
+	var translation models.BibleTranslation
+	if err := db.Where("translation_id = ?", translationID).First(&translation).Error; err != nil {
+		return err
+	}
+	config := searchConfig(translation.Language)
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("translation_id = ?", translationID).Delete(&models.BibleSearchVerse{}).Error; err != nil {
+			return err
+		}
+
+		var verses []models.BibleVerse
+		err := tx.Where("translation_id = ?", translationID).Order("id").
+			FindInBatches(&verses, 1000, func(batch *gorm.DB, _ int) error {
+				rows := make([]models.BibleSearchVerse, 0, len(verses))
+				for _, v := range verses {
+					rows = append(rows, searchVerse(translationID, v.VerseID, v.Chapter, v.Verse, v.Text, config))
+				}
+				return tx.Create(&rows).Error
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		if isPostgres(tx) {
+			return tx.Exec(`UPDATE bible_search_verses SET search = to_tsvector(config::regconfig, text) WHERE translation_id = ?`,
+				translationID).Error
+		}
+		return nil
+	})
+}
+
+// IndexStored indexes the stored translations that are not in the search
+// index yet, such as those imported before it existed.
+func IndexStored(db *gorm.DB) error {
+	if err := MigrateSearch(db); err != nil {
+		return err
+	}
+	var missing []string
+	err := db.Model(&models.BibleTranslation{}).
+		Where("translation_id NOT IN (?)", db.Model(&models.BibleSearchVerse{}).Distinct("translation_id").Where("cached = ?", false)).
+		Pluck("translation_id", &missing).Error
+	if err != nil {
+		return err
+	}
+	for _, id := range missing {
+		if err := IndexTranslation(db, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexCachedChapter replaces the verses of a cached chapter in the search
+// index. They are dropped when the cache entry expires.
+func indexCachedChapter(ctx context.Context, db *gorm.DB, translationID, chapterID string, value []byte, expiresAt time.Time) error {
+	var result map[string]interface{}
+	if err := json.Unmarshal(value, &result); err != nil {
+		return err
+	}
+
+	rows := []models.BibleSearchVerse{}
+	for _, v := range NormalizeChapter(result, chapterID) {
+		row := searchVerse(translationID, v.ID, v.Chapter, v.Number, v.Text, "simple")
+		row.Cached, row.ExpiresAt = true, &expiresAt
+		rows = append(rows, row)
+	}
+
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("translation_id = ? AND chapter_id = ?", translationID, chapterID).
+			Delete(&models.BibleSearchVerse{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		if err := tx.Create(&rows).Error; err != nil {
+			return err
+		}
+		if isPostgres(tx) {
+			return tx.Exec(`UPDATE bible_search_verses SET search = to_tsvector(config::regconfig, text) WHERE translation_id = ? AND chapter_id = ?`,
+				translationID, chapterID).Error
+		}
+		return nil
+	})
+}
+
+func searchVerse(translationID, verseID string, chapter, verse int, text, config string) models.BibleSearchVerse {
+	book, _, _ := strings.Cut(verseID, ".")
+	return models.BibleSearchVerse{
+		TranslationID: translationID,
+		ChapterID:     book + "." + strconv.Itoa(chapter),
+		VerseID:       verseID,
+		BookID:        book,
+		Position:      position(book),
+		Chapter:       chapter,
+		Verse:         verse,
+		Text:          text,
+		Terms:         " " + strings.Join(searchWords(text), " ") + " ",
+		Config:        config,
+	}
+}
+
+// SearchQuery is a full-text search of scripture. Text takes words, which
+// match any form with the same stem, "quoted phrases" and -excluded words.
+type SearchQuery struct {
+	Text         string
+	Translations []string // all when empty; IDs match in any case
+	Exclude      []string // translations left out
+	Books        []string // USFM codes; all when empty
+	Testament    *Testament
+	Limit        int // 0 for no limit
+	Offset       int
+}
+
+// SearchResult is a matching verse. Snippet is its text, or the part of it
+// around the matches, with matches wrapped in <mark>.
+type SearchResult struct {
+	TranslationID string `json:"translation_id"`
+	VerseID       string `json:"verse_id"`
+	Reference     string `json:"reference"` // e.g. "John 3:16"
+	Text          string `json:"text"`
+	Snippet       string `json:"snippet"`
+}
+
+// Search finds verses matching q, best matches first on Postgres and in
+// canonical order elsewhere.
+func Search(ctx context.Context, db *gorm.DB, q SearchQuery) ([]SearchResult, error) {
+	terms := parseSearch(q.Text)
+	if !hasPositive(terms) {
+		return nil, ErrEmptyQuery
+	}
+
+	books := q.Books
+	if q.Testament != nil {
+		var inTestament []string
+		for _, b := range Canon {
+			if b.Testament == *q.Testament && (len(q.Books) == 0 || containsFold(q.Books, b.USFM)) {
+				inTestament = append(inTestament, b.USFM)
+			}
+		}
+		if len(inTestament) == 0 {
+			return []SearchResult{}, nil
+		}
+		books = inTestament
+	}
+
+	translations, exclude := upperAll(q.Translations), upperAll(q.Exclude)
+	filter := func(tx *gorm.DB) *gorm.DB {
+		tx = tx.Model(&models.BibleSearchVerse{}).Where("(expires_at IS NULL OR expires_at > ?)", time.Now())
+		if len(translations) > 0 {
+			tx = tx.Where("UPPER(translation_id) IN ?", translations)
+		}
+		if len(exclude) > 0 {
+			tx = tx.Where("UPPER(translation_id) NOT IN ?", exclude)
+		}
+		if len(books) > 0 {
+			tx = tx.Where("book_id IN ?", books)
+		}
+		return tx
+	}
+	db = db.WithContext(ctx)
+	limit := q.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+
+	var rows []struct {
+		models.BibleSearchVerse
+		Snippet string
+	}
+	if isPostgres(db) {
+		var configs []string
+		if err := filter(db).Distinct("config").Pluck("config", &configs).Error; err != nil {
+			return nil, err
+		}
+		if len(configs) == 0 {
+			return []SearchResult{}, nil
+		}
+
+		// One match per configuration, each written out, so the GIN index
+		// can serve them.
+		var match []string
+		var args []interface{}
+		for _, config := range configs {
+			if !knownConfig(config) {
+				continue
+			}
+			match = append(match, "(config = '"+config+"' AND search @@ websearch_to_tsquery('"+config+"', ?))")
+			args = append(args, q.Text)
+		}
+		err := filter(db).
+			Select(`*, ts_headline(config::regconfig, text, websearch_to_tsquery(config::regconfig, ?),
+				'StartSel=<mark>, StopSel=</mark>, MaxWords=30, MinWords=15') AS snippet,
+				ts_rank(search, websearch_to_tsquery(config::regconfig, ?)) AS rank`, q.Text, q.Text).
+			Where("("+strings.Join(match, " OR ")+")", args...).
+			Order("rank DESC, position, chapter, verse, translation_id").
+			Limit(limit).Offset(q.Offset).
+			Find(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		tx := filter(db).Select("*")
+		for _, t := range terms {
+			pattern := "% " + strings.Join(t.words, " ") + " %"
+			if t.exclude {
+				tx = tx.Where("terms NOT LIKE ?", pattern)
+			} else {
+				tx = tx.Where("terms LIKE ?", pattern)
+			}
+		}
+		err := tx.Order("position, chapter, verse, translation_id").
+			Limit(limit).Offset(q.Offset).
+			Find(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		for i := range rows {
+			rows[i].Snippet = highlight(rows[i].Text, terms)
+		}
+	}
+
+	results := make([]SearchResult, 0, len(rows))
+	for _, row := range rows {
+		reference := row.VerseID
+		if r, err := ParseReference(row.VerseID); err == nil {
+			reference = FormatReference(r, RefFull)
+		}
+		results = append(results, SearchResult{
+			TranslationID: row.TranslationID,
+			VerseID:       row.VerseID,
+			Reference:     reference,
+			Text:          row.Text,
+			Snippet:       row.Snippet,
+		})
+	}
+	return results, nil
+}
+
+func upperAll(list []string) []string {
+	upper := make([]string, len(list))
+	for i, s := range list {
+		upper[i] = strings.ToUpper(s)
+	}
+	return upper
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// searchTerm is a word or phrase of a query, as stemmed words.
+type searchTerm struct {
+	words   []string
+	exclude bool
+}
+
+// parseSearch reads a query the way Postgres's websearch_to_tsquery does,
+// minus OR, which the fallback search does not support.
+func parseSearch(s string) []searchTerm {
+	var terms []searchTerm
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		exclude := strings.HasPrefix(s, "-")
+		s = strings.TrimPrefix(s, "-")
+
+		var text string
+		if strings.HasPrefix(s, `"`) {
+			var ok bool
+			if text, s, ok = strings.Cut(s[1:], `"`); !ok {
+				s = ""
+			}
+		} else {
+			text, s, _ = strings.Cut(s, " ")
+			if strings.EqualFold(text, "or") {
+				continue
+			}
+		}
+		if words := searchWords(text); len(words) > 0 {
+			terms = append(terms, searchTerm{words, exclude})
+		}
+	}
+	return terms
+}
+
+func hasPositive(terms []searchTerm) bool {
+	for _, t := range terms {
+		if !t.exclude {
+			return true
+		}
+	}
+	return false
+}
+
+// searchWords splits text into lower-case stemmed words.
+func searchWords(text string) []string {
+	var words []string
+	for _, w := range strings.FieldsFunc(text, notWordRune) {
+		if w = stem(w); w != "" {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+func notWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '’'
+}
+
+// stem reduces an English word to a rough stem, so "loved", "loveth" and
+// "loving" all match "love". It stands in for Postgres's stemmer where the
+// database has none; other languages are matched as written.
+func stem(w string) string {
+	w = strings.ToLower(strings.Trim(w, "'’"))
+	for _, possessive := range []string{"'s", "’s"} {
+		w = strings.TrimSuffix(w, possessive)
+	}
+	for _, suffix := range []string{"eth", "est", "ing", "ed", "es", "s"} {
+		base := strings.TrimSuffix(w, suffix)
+		if base == w || len(base) < 3 {
+			continue
+		}
+		if suffix == "s" && (strings.HasSuffix(base, "s") || strings.HasSuffix(base, "u") || strings.HasSuffix(base, "i")) {
+			continue
+		}
+		w = base
+		// "stopped" stems to "stop", but "blessed" to "bless".
+		if n := len(w); suffix != "s" && suffix != "es" && n > 3 && w[n-1] == w[n-2] && !strings.ContainsRune("aeiouylsz", rune(w[n-1])) {
+			w = w[:n-1]
+		}
+		break
+	}
+	if len(w) > 3 {
+		w = strings.TrimSuffix(w, "e")
+	}
+	return w
+}
+
+// highlight marks the words of text that match a term, cutting long verses
+// down to the words around the first match.
+func highlight(text string, terms []searchTerm) string {
+	match := map[string]bool{}
+	for _, t := range terms {
+		if !t.exclude {
+			for _, w := range t.words {
+				match[w] = true
+			}
+		}
+	}
+
+	const window, before = 30, 10
+	words := strings.Fields(text)
+	first := -1
+	for i, word := range words {
+		marked := false
+		for _, part := range strings.FieldsFunc(word, notWordRune) {
+			if match[stem(part)] {
+				marked = true
+				break
+			}
+		}
+		if !marked {
+			words[i] = html.EscapeString(word)
+			continue
+		}
+		words[i] = markWord(word)
+		if first < 0 {
+			first = i
+		}
+	}
+
+	start, end := 0, len(words)
+	if len(words) > window {
+		start = max(0, min(first-before, len(words)-window))
+		end = start + window
+	}
+	snippet := strings.Join(words[start:end], " ")
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(words) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+// markWord wraps a word in <mark>, leaving surrounding punctuation outside,
+// and escapes it.
+func markWord(word string) string {
+	start := strings.IndexFunc(word, func(r rune) bool { return !notWordRune(r) })
+	end := strings.LastIndexFunc(word, func(r rune) bool { return !notWordRune(r) })
+	if start < 0 {
+		return html.EscapeString(word)
+	}
+	_, size := utf8.DecodeRuneInString(word[end:])
+	end += size
+	return html.EscapeString(word[:start]) + "<mark>" + html.EscapeString(word[start:end]) + "</mark>" + html.EscapeString(word[end:])
+}
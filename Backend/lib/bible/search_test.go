@@ -0,0 +1,136 @@
+package bible
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"theword/Backend/lib/models"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestSearch(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "bible.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.AutoMigrate(&models.BibleTranslation{}, &models.BibleBook{}, &models.BibleVerse{})
+
+	var text Text
+	src := "\\id DEU\n\\c 6\n\\p\n\\v 5 And thou shalt love the LORD thy God with all thine heart.\n" +
+		"\\id JHN\n\\c 3\n\\p\n\\v 16 For God so loved the world, that he gave his only begotten Son.\n" +
+		"\\id 1JN\n\\c 4\n\\p\n\\v 8 He that loveth not knoweth not God; for God is love.\n"
+	if err := ParseUSFM(strings.NewReader(src), &text); err != nil {
+		t.Fatal(err)
+	}
+	if err := Import(db, models.BibleTranslation{TranslationID: "KJV", Language: "eng"}, &text); err != nil {
+		t.Fatal(err)
+	}
+
+	// A cached chapter of an upstream translation is searchable until it
+	// expires.
+	chapter := []byte(`{"data":{"content":[{"name":"para","items":[
+		{"name":"verse","attrs":{"sid":"ROM.5.8"},"items":[{"type":"text","text":"But God commendeth his love toward us."}]}]}]}}`)
+	if err := indexCachedChapter(context.Background(), db, "NET", "ROM.5", chapter, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := indexCachedChapter(context.Background(), db, "OLD", "ROM.5", chapter, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	nt := NewTestament
+	tests := []struct {
+		query SearchQuery
+		want  []string // translation and verse IDs
+	}{
+		{SearchQuery{Text: "love"}, []string{"KJV DEU.6.5", "KJV JHN.3.16", "NET ROM.5.8", "KJV 1JN.4.8"}},
+		{SearchQuery{Text: "LOVING god"}, []string{"KJV DEU.6.5", "KJV JHN.3.16", "NET ROM.5.8", "KJV 1JN.4.8"}},
+		{SearchQuery{Text: `"so loved"`}, []string{"KJV JHN.3.16"}},
+		{SearchQuery{Text: `"loved so"`}, nil},
+		{SearchQuery{Text: "love -world -heart"}, []string{"NET ROM.5.8", "KJV 1JN.4.8"}},
+		{SearchQuery{Text: "love", Translations: []string{"KJV"}, Testament: &nt}, []string{"KJV JHN.3.16", "KJV 1JN.4.8"}},
+		{SearchQuery{Text: "love", Translations: []string{"net"}}, []string{"NET ROM.5.8"}},
+		{SearchQuery{Text: "love", Exclude: []string{"kjv"}}, []string{"NET ROM.5.8"}},
+		{SearchQuery{Text: "love", Books: []string{"DEU", "ROM"}}, []string{"KJV DEU.6.5", "NET ROM.5.8"}},
+		{SearchQuery{Text: "love", Books: []string{"DEU"}, Testament: &nt}, nil},
+		{SearchQuery{Text: "love", Limit: 1, Offset: 1}, []string{"KJV JHN.3.16"}},
+	}
+	for _, tt := range tests {
+		results, err := Search(context.Background(), db, tt.query)
+		if err != nil {
+			t.Fatalf("%+v: %v", tt.query, err)
+		}
+		var got []string
+		for _, r := range results {
+			got = append(got, r.TranslationID+" "+r.VerseID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v: got %q, want %q", tt.query, got, tt.want)
+		}
+	}
+
+	results, err := Search(context.Background(), db, SearchQuery{Text: "loved world", Books: []string{"JHN"}})
+	if err != nil || len(results) != 1 {
+		t.Fatalf("got %v, %v", results, err)
+	}
+	if r := results[0]; r.Reference != "John 3:16" ||
+		r.Snippet != "For God so <mark>loved</mark> the <mark>world</mark>, that he gave his only begotten Son." {
+		t.Errorf("result = %+v", r)
+	}
+
+	if _, err := Search(context.Background(), db, SearchQuery{Text: ` -love "" `}); !errors.Is(err, ErrEmptyQuery) {
+		t.Errorf("query without terms: %v, want ErrEmptyQuery", err)
+	}
+
+	// Translations imported before the index existed are picked up.
+	db.Where("translation_id = ?", "KJV").Delete(&models.BibleSearchVerse{})
+	if err := IndexStored(db); err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	db.Model(&models.BibleSearchVerse{}).Where("translation_id = ?", "KJV").Count(&count)
+	if count != 3 {
+		t.Errorf("IndexStored indexed %d verses, want 3", count)
+	}
+}
+
+func TestStem(t *testing.T) {
+	for _, group := range [][]string{
+		{"love", "loved", "loves", "loveth", "lovest", "loving", "Love"},
+		{"bless", "blessed", "blesseth", "blessing"},
+		{"stop", "stopped", "stopping"},
+		{"Jesus"},
+		{"Lord", "Lord's", "LORD’s"},
+		{"king"},
+	} {
+		for _, w := range group {
+			if got, want := stem(w), stem(group[0]); got != want {
+				t.Errorf("stem(%q) = %q, want %q like %q", w, got, want, group[0])
+			}
+		}
+	}
+	if stem("Jesus") != "jesus" || stem("king") != "king" {
+		t.Errorf("stem(Jesus) = %q, stem(king) = %q", stem("Jesus"), stem("king"))
+	}
+}
+
+func TestHighlightLongVerse(t *testing.T) {
+	words := strings.Fields(strings.Repeat("and ", 40) + "grace " + strings.Repeat("and ", 40))
+	got := highlight(strings.Join(words, " "), parseSearch("grace"))
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") || len(strings.Fields(got)) != 30 ||
+		!strings.Contains(got, "<mark>grace</mark>") {
+		t.Errorf("highlight = %q", got)
+	}
+}
+
+func TestHighlightEscapes(t *testing.T) {
+	got := highlight(`Love <b>&</b> "grace"`, parseSearch("grace"))
+	if want := `Love &lt;b&gt;&amp;&lt;/b&gt; &#34;<mark>grace</mark>&#34;`; got != want {
+		t.Errorf("highlight = %q, want %q", got, want)
+	}
+}
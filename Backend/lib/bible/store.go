@@ -0,0 +1,112 @@
+package bible
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"theword/Backend/lib/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	FormatUSFM = "usfm"
+	FormatOSIS = "osis"
+	FormatUSX  = "usx"
+)
+
+var ErrNotFound = errors.New("not found")
+
+// Parse reads one source file in the given format into t.
+func Parse(format string, r io.Reader, t *Text) error {
+	switch format {
+	case FormatUSFM:
+		return ParseUSFM(r, t)
+	case FormatOSIS:
+		return ParseOSIS(r, t)
+	case FormatUSX:
+		return ParseUSX(r, t)
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+// FormatOf guesses a source file's format from its extension, returning ""
+// for files that are not scripture.
+func FormatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".usfm", ".sfm", ".ptx":
+		return FormatUSFM
+	case ".usx":
+		return FormatUSX
+	case ".osis", ".xml":
+		return FormatOSIS
+	}
+	return ""
+}
+
+// Import replaces the stored text of a translation with t and rebuilds its
+// search index.
+func Import(db *gorm.DB, translation models.BibleTranslation, t *Text) error {
+	if err := t.clean(); err != nil {
+		return err
+	}
+	translation.ImportedAt = time.Now()
+
+	id := translation.TranslationID
+	chapters := map[string]int{}
+	verses := make([]models.BibleVerse, 0, len(t.Verses))
+	for _, v := range t.Verses {
+		chapters[v.BookID] = max(chapters[v.BookID], v.Chapter)
+		verses = append(verses, models.BibleVerse{
+			TranslationID: id,
+			VerseID:       v.ID(),
+			ChapterID:     v.BookID + "." + strconv.Itoa(v.Chapter),
+			BookID:        v.BookID,
+			Chapter:       v.Chapter,
+			Verse:         v.Number,
+			Text:          v.Text,
+			Heading:       v.Heading,
+			Paragraph:     v.Paragraph,
+			Indent:        v.Indent,
+			Segments:      v.Segments,
+			Footnotes:     v.Footnotes,
+		})
+	}
+	books := make([]models.BibleBook, 0, len(t.Books))
+	for _, b := range t.Books {
+		books = append(books, models.BibleBook{
+			TranslationID: id,
+			BookID:        b.ID,
+			Name:          b.Name,
+			Position:      position(b.ID) + 1,
+			Chapters:      chapters[b.ID],
+		})
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("translation_id = ?", id).Delete(&models.BibleVerse{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("translation_id = ?", id).Delete(&models.BibleBook{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Save(&translation).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&books).Error; err != nil {
+			return err
+		}
+		return tx.CreateInBatches(&verses, 1000).Error
+	})
+	if err != nil {
+		return err
+	}
+	if err := IndexTranslation(db, id); err != nil {
+		return fmt.Errorf("indexing for search: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+package bible
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"theword/Backend/lib/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var strongsPattern = regexp.MustCompile(`^(?:STRONG:)?([GH])0*(\d+)[A-Z]?$`)
+
+// ParseStrongs normalises a Strong's number such as "G0025", "g25" or
+// "H7225G" to its plain form, e.g. "G25".
+func ParseStrongs(s string) (string, bool) {
+	m := strongsPattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(s)))
+	if m == nil || m[2] == "" {
+		return "", false
+	}
+	return m[1] + m[2], true
+}
+
+// wordColumns names the columns ImportWords reads, by the header names it
+// accepts for them.
+var wordColumns = map[string]string{
+	"verse": "verse", "ref": "verse", "reference": "verse",
+	"position": "position", "word": "word",
+	"transliteration": "transliteration", "translit": "transliteration",
+	"lemma": "lemma", "strongs": "strongs", "strong's": "strongs", "strong": "strongs",
+	"morphology": "morphology", "morph": "morphology", "grammar": "morphology",
+	"gloss": "gloss",
+}
+
+// ImportWords loads original-language words from a tab-separated
+// interlinear file whose header names its columns:
+//
+//	Verse	Position	Word	Transliteration	Lemma	Strongs	Morphology	Gloss
+//	John.3.16	1	Οὕτως	houtōs	οὕτω	G3779	ADV	thus
+//
+// Verse, Word and Strongs are required; without Position, words are
+// numbered in file order. Openly licensed interlinears such as STEPBible's
+// TAGNT and TAHOT load once cut down to these columns. Importing a file
+// replaces the words of the books it covers. It returns how many words were
+// stored.
+func ImportWords(db *gorm.DB, r io.Reader) (int, error) {
+	if err := db.AutoMigrate(&models.BibleWord{}); err != nil {
+		return 0, err
+	}
+
+	var words []models.BibleWord
+	var books []string
+	seen := map[string]bool{}
+	var columns map[string]int
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if columns == nil {
+			columns = map[string]int{}
+			for i, name := range fields {
+				if column, ok := wordColumns[strings.ToLower(strings.TrimSpace(name))]; ok {
+					columns[column] = i
+				}
+			}
+			for _, required := range []string{"verse", "word", "strongs"} {
+				if _, ok := columns[required]; !ok {
+					return 0, fmt.Errorf("header has no %s column", required)
+				}
+			}
+			continue
+		}
+		field := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(fields) {
+				return strings.TrimSpace(fields[i])
+			}
+			return ""
+		}
+
+		ranges, err := ParseReference(field("verse"))
+		if err != nil || !ranges[0].IsVerse() {
+			return 0, fmt.Errorf("line %d: invalid verse %q", line, field("verse"))
+		}
+		v := ranges[0]
+		word := models.BibleWord{
+			VerseID:         v.VerseID(),
+			BookID:          v.Book,
+			Canon:           position(v.Book),
+			Chapter:         v.StartChapter,
+			Verse:           v.StartVerse,
+			Word:            field("word"),
+			Transliteration: field("transliteration"),
+			Lemma:           field("lemma"),
+			Morphology:      field("morphology"),
+			Gloss:           field("gloss"),
+		}
+		word.Strongs, _ = ParseStrongs(field("strongs"))
+		if p := field("position"); p != "" {
+			if word.Position, err = strconv.Atoi(p); err != nil {
+				return 0, fmt.Errorf("line %d: position: %w", line, err)
+			}
+		} else if n := len(words); n > 0 && words[n-1].VerseID == word.VerseID {
+			word.Position = words[n-1].Position + 1
+		} else {
+			word.Position = 1
+		}
+
+		if !seen[v.Book] {
+			seen[v.Book] = true
+			books = append(books, v.Book)
+		}
+		words = append(words, word)
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	if len(words) == 0 {
+		return 0, nil
+	}
+
+	return len(words), db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("book_id IN ?", books).Delete(&models.BibleWord{}).Error; err != nil {
+			return err
+		}
+		return tx.CreateInBatches(words, 1000).Error
+	})
+}
+
+// ImportLexicon loads Strong's dictionary entries from the Open Scriptures
+// strongs-greek-dictionary.js or strongs-hebrew-dictionary.js files, or the
+// same object as plain JSON, keyed by Strong's number. Existing entries are
+// replaced. It returns how many entries were stored.
+func ImportLexicon(db *gorm.DB, r io.Reader) (int, error) {
+	if err := db.AutoMigrate(&models.BibleLexiconEntry{}); err != nil {
+		return 0, err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	// Strip the JavaScript around the object.
+	start, end := bytes.IndexByte(data, '{'), bytes.LastIndexByte(data, '}')
+	if start < 0 || end < start {
+		return 0, errors.New("no dictionary object found")
+	}
+
+	var dictionary map[string]struct {
+		Lemma      string `json:"lemma"`
+		Translit   string `json:"translit"`
+		Xlit       string `json:"xlit"` // the Hebrew file's name for translit
+		Pron       string `json:"pron"`
+		Derivation string `json:"derivation"`
+		Definition string `json:"strongs_def"`
+		KJVUsage   string `json:"kjv_def"`
+	}
+	if err := json.Unmarshal(data[start:end+1], &dictionary); err != nil {
+		return 0, err
+	}
+
+	entries := make([]models.BibleLexiconEntry, 0, len(dictionary))
+	for key, e := range dictionary {
+		strongs, ok := ParseStrongs(key)
+		if !ok {
+			continue
+		}
+		translit := e.Translit
+		if translit == "" {
+			translit = e.Xlit
+		}
+		entries = append(entries, models.BibleLexiconEntry{
+			Strongs:         strongs,
+			Lemma:           e.Lemma,
+			Transliteration: translit,
+			Pronunciation:   e.Pron,
+			Derivation:      strings.TrimSpace(e.Derivation),
+			Definition:      strings.TrimSpace(e.Definition),
+			KJVUsage:        strings.TrimSpace(e.KJVUsage),
+		})
+	}
+	if len(entries) == 0 {
+		return 0, nil
+	}
+	err = db.Clauses(clause.OnConflict{UpdateAll: true}).CreateInBatches(entries, 1000).Error
+	return len(entries), err
+}
+
+// VerseWords is a verse's original-language words in order.
+type VerseWords struct {
+	VerseID   string             `json:"verse_id"`
+	Reference string             `json:"reference"`
+	Words     []models.BibleWord `json:"words"`
+	Text      string             `json:"text,omitempty"`
+}
+
+// Words returns the original-language words of each verse in ranges that
+// has any.
+func Words(ctx context.Context, db *gorm.DB, ranges []Range) ([]VerseWords, error) {
+	match, args := versesIn(ranges)
+	var words []models.BibleWord
+	err := db.WithContext(ctx).Where(match, args...).
+		Order("canon, chapter, verse, position").
+		Find(&words).Error
+	if err != nil {
+		return nil, err
+	}
+	return groupWords(words), nil
+}
+
+// Lexicon looks up a Strong's number, returning ErrNotFound when the
+// lexicon has no entry for it.
+func Lexicon(ctx context.Context, db *gorm.DB, strongs string) (*models.BibleLexiconEntry, error) {
+	var entry models.BibleLexiconEntry
+	if err := db.WithContext(ctx).Where("strongs = ?", strongs).Limit(1).Find(&entry).Error; err != nil {
+		return nil, err
+	}
+	if entry.Strongs == "" {
+		return nil, ErrNotFound
+	}
+	return &entry, nil
+}
+
+// Concordance lists the verses using a Strong's number in canonical order,
+// each with the words that translate it, and how many verses there are in
+// all. A limit of 0 means no limit.
+func Concordance(ctx context.Context, db *gorm.DB, strongs string, limit, offset int) ([]VerseWords, int, error) {
+	db = db.WithContext(ctx)
+	var total int64
+	if err := db.Model(&models.BibleWord{}).Where("strongs = ?", strongs).Distinct("verse_id").Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if limit <= 0 {
+		limit = -1
+	}
+
+	var verseIDs []string
+	err := db.Model(&models.BibleWord{}).
+		Select("verse_id").
+		Where("strongs = ?", strongs).
+		Group("verse_id").
+		Order("MIN(canon), MIN(chapter), MIN(verse)").
+		Limit(limit).Offset(offset).
+		Pluck("verse_id", &verseIDs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(verseIDs) == 0 {
+		return []VerseWords{}, int(total), nil
+	}
+
+	var words []models.BibleWord
+	err = db.Where("strongs = ? AND verse_id IN ?", strongs, verseIDs).
+		Order("canon, chapter, verse, position").
+		Find(&words).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return groupWords(words), int(total), nil
+}
+
+// groupWords splits words, in verse order, by verse.
+func groupWords(words []models.BibleWord) []VerseWords {
+	verses := []VerseWords{}
+	for _, w := range words {
+		if n := len(verses); n == 0 || verses[n-1].VerseID != w.VerseID {
+			reference := w.VerseID
+			if ranges, err := ParseReference(w.VerseID); err == nil {
+				reference = FormatReference(ranges, RefFull)
+			}
+			verses = append(verses, VerseWords{VerseID: w.VerseID, Reference: reference})
+		}
+		verses[len(verses)-1].Words = append(verses[len(verses)-1].Words, w)
+	}
+	return verses
+}
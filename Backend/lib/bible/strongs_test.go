@@ -0,0 +1,105 @@
+package bible
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestParseStrongs(t *testing.T) {
+	for in, want := range map[string]string{"G0025": "G25", "g25": "G25", "H7225G": "H7225", "strong:H157": "H157", " G3779 ": "G3779"} {
+		if got, ok := ParseStrongs(in); !ok || got != want {
+			t.Errorf("ParseStrongs(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+	for _, in := range []string{"", "G", "X25", "G25x1", "H9003/{H7225G}"} {
+		if got, ok := ParseStrongs(in); ok {
+			t.Errorf("ParseStrongs(%q) = %q, want failure", in, got)
+		}
+	}
+}
+
+func TestStrongs(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "bible.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nt := "Verse\tWord\tTransliteration\tLemma\tStrongs\tMorph\tGloss\n" +
+		"John.3.16\tΟὕτως\thoutōs\tοὕτω\tG3779\tADV\tthus\n" +
+		"John.3.16\tγὰρ\tgar\tγάρ\tG1063\tCONJ\tfor\n" +
+		"John.3.16\tἠγάπησεν\tēgapēsen\tἀγαπάω\tG0025\tV-AAI-3S\tloved\n" +
+		"1John.4.8\tἀγαπῶν\tagapōn\tἀγαπάω\tG25\tV-PAP-NSM\tloving\n" +
+		"Rom.5.8\tἀγάπην\tagapēn\tἀγάπη\tG26\tN-ASF\tlove\n"
+	n, err := ImportWords(db, strings.NewReader(nt))
+	if err != nil || n != 5 {
+		t.Fatalf("imported %d words, %v; want 5", n, err)
+	}
+	ot := "Ref\tPosition\tWord\tStrong's\tGloss\n" +
+		"Deut.6.5\t2\tאָהַבְתָּ\tH0157\tyou shall love\n"
+	if _, err := ImportWords(db, strings.NewReader(ot)); err != nil {
+		t.Fatal(err)
+	}
+	// Importing a book again replaces only that book.
+	if _, err := ImportWords(db, strings.NewReader("Verse\tWord\tStrongs\nRom.5.8\tἀγάπην\tG26\nRom.5.8\tαὐτοῦ\tG846\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ImportWords(db, strings.NewReader("Verse\tWord\nJohn.3.16\tx\n")); err == nil {
+		t.Error("import without a Strongs column succeeded")
+	}
+
+	ranges, _ := ParseReference("John 3:16; Rom 5:8")
+	verses, err := Words(context.Background(), db, ranges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(verses) != 2 || verses[0].Reference != "John 3:16" || len(verses[0].Words) != 3 || len(verses[1].Words) != 2 {
+		t.Fatalf("words = %+v", verses)
+	}
+	if w := verses[0].Words[2]; w.Position != 3 || w.Strongs != "G25" || w.Lemma != "ἀγαπάω" || w.Morphology != "V-AAI-3S" || w.Gloss != "loved" {
+		t.Errorf("John 3:16 word 3 = %+v", w)
+	}
+	if w := verses[1].Words[1]; w.Position != 2 || w.Strongs != "G846" {
+		t.Errorf("Rom 5:8 word 2 = %+v", w)
+	}
+
+	verses, total, err := Concordance(context.Background(), db, "G25", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, v := range verses {
+		got = append(got, v.VerseID+" "+v.Words[0].Word)
+	}
+	if want := []string{"JHN.3.16 ἠγάπησεν", "1JN.4.8 ἀγαπῶν"}; total != 2 || !reflect.DeepEqual(got, want) {
+		t.Errorf("concordance G25 = %q (%d), want %q", got, total, want)
+	}
+	if verses, total, _ := Concordance(context.Background(), db, "G25", 1, 1); total != 2 || len(verses) != 1 || verses[0].VerseID != "1JN.4.8" {
+		t.Errorf("concordance G25 page 2 = %+v (%d)", verses, total)
+	}
+
+	lexicon := `var strongsGreekDictionary = {"G25":{"strongs_def":" to love (in a social or moral sense)","derivation":"perhaps from ἄγαν (much);","translit":"agapáō","lemma":"ἀγαπάω","kjv_def":"(be-)love(-ed)"},
+		"G26":{"strongs_def":"love","lemma":"ἀγάπη","translit":"agápē"}}; module.exports = strongsGreekDictionary;`
+	if n, err := ImportLexicon(db, strings.NewReader(lexicon)); err != nil || n != 2 {
+		t.Fatalf("imported %d entries, %v; want 2", n, err)
+	}
+	if _, err := ImportLexicon(db, strings.NewReader(`{"H157":{"lemma":"אָהַב","xlit":"ʼâhab","pron":"aw-hab'","strongs_def":"to have affection for"}}`)); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := Lexicon(context.Background(), db, "G25")
+	if err != nil || entry.Lemma != "ἀγαπάω" || entry.Definition != "to love (in a social or moral sense)" || entry.KJVUsage != "(be-)love(-ed)" {
+		t.Errorf("G25 = %+v, %v", entry, err)
+	}
+	if entry, err := Lexicon(context.Background(), db, "H157"); err != nil || entry.Transliteration != "ʼâhab" {
+		t.Errorf("H157 = %+v, %v", entry, err)
+	}
+	if _, err := Lexicon(context.Background(), db, "G9999"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown entry: %v, want ErrNotFound", err)
+	}
+}
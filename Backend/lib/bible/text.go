@@ -0,0 +1,292 @@
+// Package bible stores Bible translations in the database and parses the
+// USFM, OSIS and USX formats public-domain texts are published in.
+package bible
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Verse is one verse as read from a source text, with the layout that
+// precedes it.
+type Verse struct {
+	BookID    string // USFM code
+	Chapter   int
+	Number    int
+	Text      string
+	Heading   string
+	Paragraph bool
+	Indent    int
+	Segments  []Segment // only for verses with words of Jesus, footnotes or line breaks
+	Footnotes []string
+}
+
+func (v Verse) ID() string {
+	return v.BookID + "." + strconv.Itoa(v.Chapter) + "." + strconv.Itoa(v.Number)
+}
+
+type Book struct {
+	ID   string // USFM code
+	Name string
+}
+
+// Text is a translation as parsed from its source files. Parsers append to
+// it, so several per-book files can be read into one Text.
+type Text struct {
+	Books  []Book
+	Verses []Verse
+}
+
+// clean drops books with no verses and folds repeated verses into the first
+// occurrence, which happens when a source splits a verse around a footnote
+// or lists a book twice.
+func (t *Text) clean() error {
+	seen := map[string]int{}
+	verses := t.Verses[:0]
+	hasVerses := map[string]bool{}
+	for _, v := range t.Verses {
+		if i, ok := seen[v.ID()]; ok {
+			verses[i].join(v)
+			continue
+		}
+		seen[v.ID()] = len(verses)
+		hasVerses[v.BookID] = true
+		verses = append(verses, v)
+	}
+	t.Verses = verses
+
+	books := t.Books[:0]
+	named := map[string]bool{}
+	for _, b := range t.Books {
+		if !hasVerses[b.ID] || named[b.ID] {
+			continue
+		}
+		named[b.ID] = true
+		if b.Name == "" {
+			b.Name = b.ID
+			if info, ok := BookByUSFM(b.ID); ok {
+				b.Name = info.Name
+			}
+		}
+		books = append(books, b)
+	}
+	t.Books = books
+
+	if len(t.Verses) == 0 {
+		return fmt.Errorf("no verses found")
+	}
+	return nil
+}
+
+// join appends the text of another part of the same verse.
+func (v *Verse) join(o Verse) {
+	if v.Segments == nil && o.Segments == nil && len(o.Footnotes) == 0 {
+		v.Text = strings.TrimSpace(v.Text + " " + o.Text)
+		return
+	}
+	segs := append(v.segments(), Segment{Text: " "})
+	for _, s := range o.segments() {
+		if s.Footnote > 0 {
+			s.Footnote += len(v.Footnotes)
+		}
+		segs = append(segs, s)
+	}
+	v.Footnotes = append(v.Footnotes, o.Footnotes...)
+	v.Text, v.Segments = tidySegments(segs)
+}
+
+// segments returns the verse as segments, whether or not it has markup.
+func (v *Verse) segments() []Segment {
+	if v.Segments != nil {
+		return v.Segments
+	}
+	return []Segment{{Text: v.Text}}
+}
+
+// builder accumulates verses as a parser walks a source text. Headings and
+// paragraph breaks seen between verses are held until the next verse starts.
+type builder struct {
+	text      *Text
+	book      int // index into text.Books, -1 before the first book
+	nameRank  int // rank of the name the current book was given; lower wins
+	chapter   int
+	verse     int // index into text.Verses, -1 when no verse is open
+	segments  []Segment
+	footnotes []string
+	red       bool // inside words of Jesus
+	headings  []string
+	paragraph bool
+	indent    int
+}
+
+func newBuilder(t *Text) *builder {
+	return &builder{text: t, book: -1, verse: -1}
+}
+
+func (b *builder) startBook(id string) {
+	b.endVerse()
+	b.text.Books = append(b.text.Books, Book{ID: strings.ToUpper(id)})
+	b.book = len(b.text.Books) - 1
+	b.nameRank = -1
+	b.chapter = 0
+	b.headings = nil
+}
+
+// nameBook names the current book, preferring the short running header over
+// longer titles: rank 0 beats rank 1 and so on.
+func (b *builder) nameBook(name string, rank int) {
+	name = collapseSpace(name)
+	if b.book < 0 || name == "" || (b.nameRank >= 0 && b.nameRank <= rank) {
+		return
+	}
+	b.text.Books[b.book].Name = name
+	b.nameRank = rank
+}
+
+func (b *builder) startChapter(n int) {
+	b.endVerse()
+	b.chapter = n
+}
+
+func (b *builder) startVerse(n int) {
+	b.endVerse()
+	if b.book < 0 || b.chapter < 1 || n < 1 {
+		return
+	}
+	b.text.Verses = append(b.text.Verses, Verse{
+		BookID:    b.text.Books[b.book].ID,
+		Chapter:   b.chapter,
+		Number:    n,
+		Heading:   strings.Join(b.headings, "\n"),
+		Paragraph: b.paragraph,
+		Indent:    b.indent,
+	})
+	b.verse = len(b.text.Verses) - 1
+	b.headings = nil
+	b.paragraph = false
+}
+
+func (b *builder) endVerse() {
+	if b.verse < 0 {
+		return
+	}
+	v := &b.text.Verses[b.verse]
+	v.Text, v.Segments = tidySegments(b.segments)
+	v.Footnotes = b.footnotes
+	b.segments, b.footnotes = nil, nil
+	b.verse = -1
+}
+
+// addText appends to the open verse; text outside a verse is dropped. A
+// paragraph break inside a verse, as between lines of poetry, is kept as a
+// break segment.
+func (b *builder) addText(s string) {
+	if b.verse < 0 {
+		return
+	}
+	if b.paragraph && strings.TrimSpace(s) != "" && b.hasText() {
+		b.segments = append(b.segments, Segment{Break: true, Indent: b.indent})
+		b.paragraph = false
+	}
+	if n := len(b.segments); n > 0 && plainSegment(b.segments[n-1]) && b.segments[n-1].WordsOfJesus == b.red {
+		b.segments[n-1].Text += s
+		return
+	}
+	b.segments = append(b.segments, Segment{Text: s, WordsOfJesus: b.red})
+}
+
+func (b *builder) hasText() bool {
+	for _, s := range b.segments {
+		if strings.TrimSpace(s.Text) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// setRed marks the text that follows as words of Jesus or not.
+func (b *builder) setRed(red bool) {
+	b.red = red
+}
+
+// addFootnote calls a footnote at this point in the open verse.
+func (b *builder) addFootnote(s string) {
+	if s = collapseSpace(s); b.verse < 0 || s == "" {
+		return
+	}
+	b.footnotes = append(b.footnotes, s)
+	b.segments = append(b.segments, Segment{Footnote: len(b.footnotes)})
+}
+
+func (b *builder) addHeading(s string) {
+	if s = collapseSpace(s); s != "" {
+		b.headings = append(b.headings, s)
+	}
+}
+
+// breakParagraph starts a new paragraph at the next verse, as poetry of the
+// given level or prose when indent is 0.
+func (b *builder) breakParagraph(indent int) {
+	b.paragraph = true
+	b.indent = indent
+}
+
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// leadingInt parses the number at the start of s, so bridged or lettered
+// verse numbers like "1-2" or "3a" resolve to their first verse.
+func leadingInt(s string) int {
+	s = strings.TrimSpace(s)
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	n, _ := strconv.Atoi(s[:end])
+	return n
+}
+
+type styleKind int
+
+const (
+	styleChar    styleKind = iota // not a paragraph style
+	styleBody                     // prose paragraph
+	stylePoetry                   // poetry line; level is the indent
+	styleHeading                  // section heading
+	styleName                     // book name; level is its rank
+	styleSkip                     // identification, introduction and other front matter
+)
+
+// paragraphStyle classifies a USFM paragraph marker, which USX also uses as
+// its para style names.
+func paragraphStyle(style string) (styleKind, int) {
+	base := strings.TrimRight(style, "0123456789")
+	level := 1
+	if n, err := strconv.Atoi(style[len(base):]); err == nil {
+		level = n
+	}
+
+	switch base {
+	case "h":
+		return styleName, 0
+	case "toc":
+		if level == 1 || level == 2 {
+			return styleName, 3 - level
+		}
+		return styleSkip, 0
+	case "mt":
+		return styleName, 3
+	case "s", "ms", "d", "sp", "qa":
+		return styleHeading, 0
+	case "q", "qm", "qc", "qr":
+		return stylePoetry, level
+	case "p", "m", "pi", "mi", "pc", "pr", "cls", "li", "lim", "pm", "pmo", "pmc", "pmr", "po", "lf", "ph", "b":
+		return styleBody, 0
+	case "id", "ide", "rem", "sts", "toca", "cl", "cp", "cd", "mr", "sr", "r", "restore", "usfm", "mte", "qd",
+		"imt", "is", "ip", "ipi", "im", "imi", "ipq", "imq", "ipr", "iq", "ib", "ili", "iot", "io", "iex", "imte", "ie":
+		return styleSkip, 0
+	}
+	return styleChar, 0
+}
@@ -0,0 +1,178 @@
+package bible
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// ParseUSFM reads a USFM file, usually one book, into t. Footnotes and words
+// of Jesus are kept, cross references are dropped and other character markup
+// is reduced to its text.
+func ParseUSFM(r io.Reader, t *Text) error {
+	p := usfmParser{b: newBuilder(t)}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		p.line(sc.Text())
+	}
+	p.b.endVerse()
+	return sc.Err()
+}
+
+type usfmParser struct {
+	b    *builder
+	skip string // closing marker of the note being skipped or read, e.g. "f*"
+	note *strings.Builder
+	ref  bool // inside a footnote's \fr origin reference
+}
+
+func (p *usfmParser) line(line string) {
+	line = strings.TrimSpace(line)
+	if p.skip == "" && strings.HasPrefix(line, `\`) {
+		marker, rest, _ := strings.Cut(line[1:], " ")
+		rest = strings.TrimSpace(rest)
+
+		switch marker {
+		case "id":
+			if fields := strings.Fields(rest); len(fields) > 0 {
+				p.b.startBook(fields[0])
+			}
+			return
+		case "c":
+			p.b.startChapter(leadingInt(rest))
+			return
+		}
+
+		switch kind, level := paragraphStyle(marker); kind {
+		case styleName:
+			p.b.nameBook(p.plain(rest), level)
+			return
+		case styleHeading:
+			p.b.endVerse()
+			p.b.addHeading(p.plain(rest))
+			return
+		case styleSkip:
+			return
+		}
+	}
+
+	p.inline(line)
+	p.b.addText(" ")
+}
+
+// inline walks a line of verse text, acting on the markers in it.
+func (p *usfmParser) inline(s string) {
+	for s != "" {
+		i := strings.IndexByte(s, '\\')
+		if i < 0 {
+			p.text(s)
+			return
+		}
+		p.text(s[:i])
+
+		marker, rest := usfmMarker(s[i+1:])
+		s = rest
+		name := strings.TrimPrefix(marker, "+")
+
+		if p.skip != "" {
+			switch {
+			case name == p.skip:
+				if p.note != nil {
+					p.b.addFootnote(p.note.String())
+				}
+				p.skip, p.note = "", nil
+			case p.note != nil && !strings.HasSuffix(name, "*"):
+				p.ref = name == "fr"
+			}
+			continue
+		}
+
+		switch name {
+		case "v":
+			number, after, _ := strings.Cut(strings.TrimLeft(s, " "), " ")
+			p.b.startVerse(leadingInt(number))
+			s = after
+			continue
+		case "c":
+			number, after, _ := strings.Cut(strings.TrimLeft(s, " "), " ")
+			p.b.startChapter(leadingInt(number))
+			s = after
+			continue
+		case "f", "fe":
+			// The caller, e.g. "+", comes first.
+			p.skip, p.note, p.ref = name+"*", &strings.Builder{}, false
+			s = strings.TrimLeft(s, " ")
+			if i := strings.IndexAny(s, ` \`); i > 0 {
+				s = s[i:]
+			}
+			continue
+		case "ef", "x", "ex":
+			p.skip = name + "*"
+			continue
+		case "wj":
+			p.b.setRed(true)
+			continue
+		case "wj*":
+			p.b.setRed(false)
+			continue
+		}
+
+		switch kind, level := paragraphStyle(name); kind {
+		case styleBody:
+			p.b.breakParagraph(0)
+		case stylePoetry:
+			p.b.breakParagraph(level)
+		}
+	}
+}
+
+// text adds verse text, dropping the attributes USFM 3 puts after a "|" in
+// character markup such as \w grace|strong="G5485"\w*.
+func (p *usfmParser) text(s string) {
+	if s == "" {
+		return
+	}
+	if i := strings.IndexByte(s, '|'); i >= 0 {
+		s = s[:i]
+	}
+	switch {
+	case p.note != nil && !p.ref:
+		p.note.WriteString(s)
+	case p.skip == "":
+		p.b.addText(s)
+	}
+}
+
+// plain returns the text of a heading or title line with markup removed.
+func (p *usfmParser) plain(s string) string {
+	var out strings.Builder
+	for s != "" {
+		i := strings.IndexByte(s, '\\')
+		if i < 0 {
+			out.WriteString(s)
+			break
+		}
+		out.WriteString(s[:i])
+		var marker string
+		marker, s = usfmMarker(s[i+1:])
+		if marker == "f" || marker == "x" {
+			_, s, _ = strings.Cut(s, `\`+marker+"*")
+		}
+	}
+	return out.String()
+}
+
+// usfmMarker splits a marker name, including any closing "*", from the text
+// after it. The single space that ends an opening marker is consumed.
+func usfmMarker(s string) (string, string) {
+	end := 0
+	for end < len(s) && s[end] != ' ' && s[end] != '\\' && s[end] != '*' {
+		end++
+	}
+	if end < len(s) && s[end] == '*' {
+		return s[:end+1], s[end+1:]
+	}
+	marker, rest := s[:end], s[end:]
+	return marker, strings.TrimPrefix(rest, " ")
+}
@@ -0,0 +1,120 @@
+package bible
+
+import (
+	"encoding/xml"
+	"io"
+	"slices"
+	"strings"
+)
+
+// ParseUSX reads a USX file, one book, into t. USX 2 files without verse
+// end milestones are read too, each verse running to the next. Footnotes and
+// words of Jesus are kept.
+func ParseUSX(r io.Reader, t *Text) error {
+	b := newBuilder(t)
+	d := xml.NewDecoder(r)
+
+	var (
+		skip  int // depth inside an element whose text is dropped
+		kind  styleKind
+		level int
+		para  *strings.Builder // text of a heading or name paragraph
+		chars []bool           // for each open char, whether it marks words of Jesus
+	)
+
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch el := tok.(type) {
+		case xml.StartElement:
+			if skip > 0 {
+				skip++
+				continue
+			}
+			switch el.Name.Local {
+			case "book":
+				b.startBook(xmlAttr(el, "code"))
+				skip = 1
+			case "chapter":
+				if n := xmlAttr(el, "number"); n != "" && xmlAttr(el, "eid") == "" {
+					b.startChapter(leadingInt(n))
+				}
+			case "verse":
+				if xmlAttr(el, "eid") != "" {
+					b.endVerse()
+				} else {
+					b.startVerse(leadingInt(xmlAttr(el, "number")))
+				}
+			case "note":
+				if style := xmlAttr(el, "style"); style != "f" && style != "fe" {
+					skip = 1
+					continue
+				}
+				note, err := xmlText(d, func(el xml.StartElement) bool {
+					return el.Name.Local == "char" && xmlAttr(el, "style") == "fr"
+				})
+				if err != nil {
+					return err
+				}
+				b.addFootnote(note)
+			case "char":
+				chars = append(chars, xmlAttr(el, "style") == "wj")
+				b.setRed(slices.Contains(chars, true))
+			case "figure", "sidebar":
+				skip = 1
+			case "para":
+				kind, level = paragraphStyle(xmlAttr(el, "style"))
+				switch kind {
+				case styleSkip:
+					skip = 1
+				case styleName, styleHeading:
+					b.endVerse()
+					para = &strings.Builder{}
+				case styleBody:
+					b.breakParagraph(0)
+				case stylePoetry:
+					b.breakParagraph(level)
+				}
+			}
+
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			if el.Name.Local == "char" && len(chars) > 0 {
+				chars = chars[:len(chars)-1]
+				b.setRed(slices.Contains(chars, true))
+			}
+			if el.Name.Local != "para" {
+				continue
+			}
+			if para == nil {
+				b.addText(" ")
+			} else if kind == styleName {
+				b.nameBook(para.String(), level)
+			} else {
+				b.addHeading(para.String())
+			}
+			para = nil
+
+		case xml.CharData:
+			switch {
+			case skip > 0:
+			case para != nil:
+				para.Write(el)
+			default:
+				b.addText(string(el))
+			}
+		}
+	}
+
+	b.endVerse()
+	return nil
+}
@@ -0,0 +1,142 @@
+package bible
+
+import "strings"
+
+// Versification is how a translation numbers its chapters and verses.
+// Verse IDs elsewhere in the app use the English scheme of the KJV and
+// most English translations.
+type Versification string
+
+const (
+	VersificationEnglish Versification = "english"
+	// VersificationHebrew numbers as the Masoretic text does, as Jewish and
+	// some scholarly translations do: psalm titles are verses, and chapter
+	// breaks fall elsewhere in books such as Joel and Malachi.
+	VersificationHebrew Versification = "hebrew"
+)
+
+// ParseVersification reads a versification name, defaulting to English.
+func ParseVersification(s string) (Versification, bool) {
+	switch v := Versification(strings.ToLower(strings.TrimSpace(s))); v {
+	case "", VersificationEnglish:
+		return VersificationEnglish, true
+	case VersificationHebrew:
+		return v, true
+	}
+	return "", false
+}
+
+// verseShift moves English verses first-last of a chapter to start at
+// toChapter:toFirst. A first of 0 marks verses the English scheme has no
+// number for, such as psalm titles.
+type verseShift struct {
+	book             string
+	chapter          int
+	first, last      int
+	toChapter        int
+	toFirst, toCount int // toCount is only set for unnumbered verses
+}
+
+// hebrewShifts lists where the Hebrew scheme differs from the English.
+// Verses the schemes split differently, such as Isaiah 63:19 and 64:1, are
+// left at their own numbers.
+var hebrewShifts = append([]verseShift{
+	{"GEN", 31, 55, 55, 32, 1, 0}, {"GEN", 32, 1, 32, 32, 2, 0},
+	{"EXO", 8, 1, 4, 7, 26, 0}, {"EXO", 8, 5, 32, 8, 1, 0},
+	{"EXO", 22, 1, 1, 21, 37, 0}, {"EXO", 22, 2, 31, 22, 1, 0},
+	{"LEV", 6, 1, 7, 5, 20, 0}, {"LEV", 6, 8, 30, 6, 1, 0},
+	{"NUM", 16, 36, 50, 17, 1, 0}, {"NUM", 17, 1, 13, 17, 16, 0},
+	{"NUM", 29, 40, 40, 30, 1, 0}, {"NUM", 30, 1, 16, 30, 2, 0},
+	{"DEU", 12, 32, 32, 13, 1, 0}, {"DEU", 13, 1, 18, 13, 2, 0},
+	{"DEU", 22, 30, 30, 23, 1, 0}, {"DEU", 23, 1, 25, 23, 2, 0},
+	{"DEU", 29, 1, 1, 28, 69, 0}, {"DEU", 29, 2, 29, 29, 1, 0},
+	{"1SA", 23, 29, 29, 24, 1, 0}, {"1SA", 24, 1, 22, 24, 2, 0},
+	{"2SA", 18, 33, 33, 19, 1, 0}, {"2SA", 19, 1, 43, 19, 2, 0},
+	{"1KI", 4, 21, 34, 5, 1, 0}, {"1KI", 5, 1, 18, 5, 15, 0},
+	{"2KI", 11, 21, 21, 12, 1, 0}, {"2KI", 12, 1, 21, 12, 2, 0},
+	{"1CH", 6, 1, 15, 5, 27, 0}, {"1CH", 6, 16, 81, 6, 1, 0},
+	{"2CH", 2, 1, 1, 1, 18, 0}, {"2CH", 2, 2, 18, 2, 1, 0},
+	{"2CH", 14, 1, 1, 13, 23, 0}, {"2CH", 14, 2, 15, 14, 1, 0},
+	{"NEH", 4, 1, 6, 3, 33, 0}, {"NEH", 4, 7, 23, 4, 1, 0},
+	{"NEH", 9, 38, 38, 10, 1, 0}, {"NEH", 10, 1, 39, 10, 2, 0},
+	{"JOB", 41, 1, 8, 40, 25, 0}, {"JOB", 41, 9, 34, 41, 1, 0},
+	{"ECC", 5, 1, 1, 4, 17, 0}, {"ECC", 5, 2, 20, 5, 1, 0},
+	{"SNG", 6, 13, 13, 7, 1, 0}, {"SNG", 7, 1, 13, 7, 2, 0},
+	{"ISA", 9, 1, 1, 8, 23, 0}, {"ISA", 9, 2, 21, 9, 1, 0},
+	{"ISA", 64, 2, 12, 64, 1, 0},
+	{"JER", 9, 1, 1, 8, 23, 0}, {"JER", 9, 2, 26, 9, 1, 0},
+	{"EZK", 20, 45, 49, 21, 1, 0}, {"EZK", 21, 1, 32, 21, 6, 0},
+	{"DAN", 4, 1, 3, 3, 31, 0}, {"DAN", 4, 4, 37, 4, 1, 0},
+	{"DAN", 5, 31, 31, 6, 1, 0}, {"DAN", 6, 1, 28, 6, 2, 0},
+	{"HOS", 1, 10, 11, 2, 1, 0}, {"HOS", 2, 1, 23, 2, 3, 0},
+	{"HOS", 11, 12, 12, 12, 1, 0}, {"HOS", 12, 1, 14, 12, 2, 0},
+	{"HOS", 13, 16, 16, 14, 1, 0}, {"HOS", 14, 1, 9, 14, 2, 0},
+	{"JOL", 2, 28, 32, 3, 1, 0}, {"JOL", 3, 1, 21, 4, 1, 0},
+	{"JON", 1, 17, 17, 2, 1, 0}, {"JON", 2, 1, 10, 2, 2, 0},
+	{"MIC", 5, 1, 1, 4, 14, 0}, {"MIC", 5, 2, 15, 5, 1, 0},
+	{"NAM", 1, 15, 15, 2, 1, 0}, {"NAM", 2, 1, 13, 2, 2, 0},
+	{"ZEC", 1, 18, 21, 2, 1, 0}, {"ZEC", 2, 1, 13, 2, 5, 0},
+	{"MAL", 4, 1, 6, 3, 19, 0},
+}, psalmTitleShifts()...)
+
+// psalmTitleShifts numbers psalm titles as the Hebrew scheme does: one
+// verse, or two for 51, 52, 54 and 60, pushing the rest down.
+func psalmTitleShifts() []verseShift {
+	titles := map[int]int{51: 2, 52: 2, 54: 2, 60: 2}
+	for _, n := range []int{
+		3, 4, 5, 6, 7, 8, 9, 12, 13, 18, 19, 20, 21, 22, 30, 31, 34, 36, 38, 39, 40, 41, 42, 44, 45, 46,
+		47, 48, 49, 53, 55, 56, 57, 58, 59, 61, 62, 63, 64, 65, 67, 68, 69, 70, 75, 76, 77, 80, 81, 83,
+		84, 85, 88, 89, 92, 102, 108, 140, 142,
+	} {
+		titles[n] = 1
+	}
+
+	var shifts []verseShift
+	for psalm, n := range titles {
+		shifts = append(shifts,
+			verseShift{"PSA", psalm, 0, 0, psalm, 1, n},
+			verseShift{"PSA", psalm, 1, MaxVerse - n, psalm, 1 + n, 0})
+	}
+	return shifts
+}
+
+func (v Versification) shifts() []verseShift {
+	if v == VersificationHebrew {
+		return hebrewShifts
+	}
+	return nil
+}
+
+// Chapters lists the chapters of the translation that hold verses of the
+// given English chapter, the chapter itself first.
+func (v Versification) Chapters(book string, chapter int) []int {
+	chapters := []int{chapter}
+	for _, s := range v.shifts() {
+		if s.book == book && s.chapter == chapter && s.toChapter != chapter {
+			chapters = append(chapters, s.toChapter)
+		}
+	}
+	return chapters
+}
+
+// Canonical returns the English chapter and verse of a verse numbered in
+// this scheme. A verse of 0 means the verse has no English number, as with
+// psalm titles, which English translations print as headings.
+func (v Versification) Canonical(book string, chapter, verse int) (int, int) {
+	for _, s := range v.shifts() {
+		if s.book != book || s.toChapter != chapter {
+			continue
+		}
+		count := s.toCount
+		if s.first > 0 {
+			count = s.last - s.first + 1
+		}
+		if verse >= s.toFirst && verse < s.toFirst+count {
+			if s.first == 0 {
+				return s.chapter, 0
+			}
+			return s.chapter, s.first + verse - s.toFirst
+		}
+	}
+	return chapter, verse
+}
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"theword/Backend/lib/middleware"
+	"theword/Backend/lib/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestDB(t *testing.T, tables ...interface{}) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(tables...); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// asUser stands in for AuthMiddleware, taking the user ID from X-User-ID.
+func asUser(c *gin.Context) {
+	id, _ := strconv.Atoi(c.GetHeader("X-User-ID"))
+	c.Set("userID", uint(id))
+	c.Next()
+}
+
+// getJSON requests path as user and decodes the response into out, if given.
+func getJSON(t *testing.T, r http.Handler, user uint, path string, out interface{}) int {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("X-User-ID", strconv.Itoa(int(user)))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if out != nil && w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+	}
+	return w.Code
+}
+
+// Users in the access fixture.
+const (
+	userOutsider     uint = 1 // belongs to another church
+	userChurchMember uint = 2 // belongs to the church, in no group
+	userChurchLeader uint = 3 // admin of the church
+	userGroupMember  uint = 4 // active member of the private group, from another church
+	userGroupLeader  uint = 5 // the private group's LeaderID
+	userPending      uint = 6 // waiting for approval to the private group
+)
+
+// Boards in the access fixture. Each board has one public and one
+// members-only message, and one prayer request.
+const (
+	testChurch       uint = 1
+	testPrivateGroup uint = 10
+	testPublicGroup  uint = 11
+)
+
+func accessFixture(t *testing.T) *gin.Engine {
+	db := newTestDB(t, &models.User{}, &models.Church{}, &models.SmallGroup{}, &models.GroupMember{},
+		&models.ChurchEvent{}, &models.Message{}, &models.MessageReaction{}, &models.PrayerRequest{})
+
+	db.Create(&[]models.Church{{ChurchID: testChurch, Name: "Grace"}, {ChurchID: 2, Name: "Other"}})
+	db.Create(&[]models.User{
+		{UserID: userOutsider, Email: "outsider@example.com", ChurchID: 2},
+		{UserID: userChurchMember, Email: "member@example.com", ChurchID: testChurch},
+		{UserID: userChurchLeader, Email: "leader@example.com", ChurchID: testChurch, IsAdmin: true},
+		{UserID: userGroupMember, Email: "group@example.com", ChurchID: 2},
+		{UserID: userGroupLeader, Email: "groupleader@example.com", ChurchID: 2},
+		{UserID: userPending, Email: "pending@example.com", ChurchID: 2},
+	})
+	db.Create(&[]models.SmallGroup{
+		{GroupID: testPrivateGroup, ChurchID: testChurch, Name: "Private", LeaderID: userGroupLeader},
+		{GroupID: testPublicGroup, ChurchID: testChurch, Name: "Public", IsPublic: true},
+	})
+	db.Create(&[]models.GroupMember{
+		{GroupID: testPrivateGroup, UserID: userGroupMember, Role: models.GroupRoleMember, Status: models.GroupMemberActive},
+		{GroupID: testPrivateGroup, UserID: userPending, Role: models.GroupRoleMember, Status: models.GroupMemberPending},
+	})
+	db.Create(&[]models.Message{
+		{ChurchID: testChurch, Content: "church public", IsPublic: true},
+		{ChurchID: testChurch, Content: "church members"},
+		{GroupID: testPrivateGroup, Content: "group public", IsPublic: true},
+		{GroupID: testPrivateGroup, Content: "group members"},
+		{GroupID: testPublicGroup, Content: "open group public", IsPublic: true},
+		{GroupID: testPublicGroup, Content: "open group members"},
+	})
+	db.Create(&[]models.PrayerRequest{
+		{ChurchID: testChurch, Content: "church prayer", Visibility: models.PrayerVisibilityChurch, Status: models.PrayerStatusActive},
+		{GroupID: testPrivateGroup, Content: "group prayer", Visibility: models.PrayerVisibilityGroup, Status: models.PrayerStatusActive},
+	})
+
+	// The routes as main.go registers them, with asUser for AuthMiddleware.
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/churches/:id", asUser, middleware.ChurchAccess(db), GetChurchDetails(db))
+	r.GET("/api/churches/:id/groups", asUser, middleware.ChurchAccess(db), GetChurchGroups(db))
+	r.GET("/api/churches/:id/messages", asUser, middleware.ChurchAccess(db), GetChurchMessages(db))
+	r.GET("/api/churches/:id/prayers", asUser, middleware.ChurchAccess(db), middleware.MembersOnly, GetChurchPrayerRequests(db))
+	r.GET("/api/groups/:id", asUser, middleware.GroupAccess(db), GetGroupDetails(db))
+	r.GET("/api/groups/:id/messages", asUser, middleware.GroupAccess(db), GetGroupMessages(db))
+	r.GET("/api/groups/:id/prayers", asUser, middleware.GroupAccess(db), middleware.MembersOnly, GetGroupPrayerRequests(db))
+	return r
+}
+
+type boardDetails struct {
+	Groups         []models.SmallGroup    `json:"groups"`
+	Messages       []models.Message       `json:"messages"`
+	PrayerRequests []models.PrayerRequest `json:"prayerRequests"`
+	IsMember       bool                   `json:"isMember"`
+	IsLeader       bool                   `json:"isLeader"`
+}
+
+func TestChurchAccess(t *testing.T) {
+	r := accessFixture(t)
+	path := "/api/churches/" + strconv.Itoa(int(testChurch))
+
+	tests := []struct {
+		name                         string
+		user                         uint
+		groups, messages, prayers    int
+		member, leader, readsPrayers bool
+	}{
+		{"outsider", userOutsider, 1, 1, 0, false, false, false},
+		{"member", userChurchMember, 2, 2, 1, true, false, true},
+		{"leader", userChurchLeader, 2, 2, 1, true, true, true},
+		{"group member from another church", userGroupMember, 1, 1, 0, false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var details boardDetails
+			if code := getJSON(t, r, tt.user, path, &details); code != http.StatusOK {
+				t.Fatalf("details: %d", code)
+			}
+			if len(details.Groups) != tt.groups || len(details.Messages) != tt.messages || len(details.PrayerRequests) != tt.prayers ||
+				details.IsMember != tt.member || details.IsLeader != tt.leader {
+				t.Errorf("details = %d groups, %d messages, %d prayers, member %v, leader %v",
+					len(details.Groups), len(details.Messages), len(details.PrayerRequests), details.IsMember, details.IsLeader)
+			}
+			for _, m := range details.Messages {
+				if !m.IsPublic && !tt.member {
+					t.Errorf("members-only message %q shown", m.Content)
+				}
+			}
+
+			var groups []models.SmallGroup
+			if code := getJSON(t, r, tt.user, path+"/groups", &groups); code != http.StatusOK || len(groups) != tt.groups {
+				t.Errorf("groups: %d, %d groups, want %d", code, len(groups), tt.groups)
+			}
+			for _, g := range groups {
+				if !g.IsPublic && !tt.member {
+					t.Errorf("unlisted group %q shown", g.Name)
+				}
+			}
+
+			var messages []models.MessageWithMeta
+			if code := getJSON(t, r, tt.user, path+"/messages", &messages); code != http.StatusOK || len(messages) != tt.messages {
+				t.Errorf("messages: %d, %d messages, want %d", code, len(messages), tt.messages)
+			}
+
+			var prayers []models.PrayerRequest
+			code := getJSON(t, r, tt.user, path+"/prayers", &prayers)
+			if !tt.readsPrayers {
+				if code != http.StatusForbidden {
+					t.Errorf("prayers: %d, want 403", code)
+				}
+			} else if code != http.StatusOK || len(prayers) != tt.prayers {
+				t.Errorf("prayers: %d, %d requests, want %d", code, len(prayers), tt.prayers)
+			}
+		})
+	}
+
+	for _, path := range []string{"/api/churches/99", "/api/churches/99/groups", "/api/churches/99/messages", "/api/churches/99/prayers"} {
+		if code := getJSON(t, r, userChurchLeader, path, nil); code != http.StatusNotFound {
+			t.Errorf("GET %s: %d, want 404", path, code)
+		}
+	}
+}
+
+func TestGroupAccess(t *testing.T) {
+	r := accessFixture(t)
+	private := "/api/groups/" + strconv.Itoa(int(testPrivateGroup))
+	public := "/api/groups/" + strconv.Itoa(int(testPublicGroup))
+
+	tests := []struct {
+		name           string
+		user           uint
+		path           string
+		details        int // status of the group's details
+		messages       int
+		prayers        int // -1 when the prayer board is refused
+		member, leader bool
+	}{
+		{"outsider, private group", userOutsider, private, http.StatusForbidden, 1, -1, false, false},
+		{"pending member", userPending, private, http.StatusForbidden, 1, -1, false, false},
+		{"church member, private group", userChurchMember, private, http.StatusOK, 1, -1, false, false},
+		{"group member", userGroupMember, private, http.StatusOK, 2, 1, true, false},
+		{"group leader", userGroupLeader, private, http.StatusOK, 2, 1, false, true},
+		{"church leader", userChurchLeader, private, http.StatusOK, 2, 1, false, true},
+		{"outsider, public group", userOutsider, public, http.StatusOK, 1, -1, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var details boardDetails
+			code := getJSON(t, r, tt.user, tt.path, &details)
+			if code != tt.details {
+				t.Fatalf("details: %d, want %d", code, tt.details)
+			}
+			if code == http.StatusOK {
+				wantPrayers := max(tt.prayers, 0)
+				if len(details.Messages) != tt.messages || len(details.PrayerRequests) != wantPrayers ||
+					details.IsMember != tt.member || details.IsLeader != tt.leader {
+					t.Errorf("details = %d messages, %d prayers, member %v, leader %v",
+						len(details.Messages), len(details.PrayerRequests), details.IsMember, details.IsLeader)
+				}
+			}
+
+			var messages []models.MessageWithMeta
+			if code := getJSON(t, r, tt.user, tt.path+"/messages", &messages); code != http.StatusOK || len(messages) != tt.messages {
+				t.Errorf("messages: %d, %d messages, want %d", code, len(messages), tt.messages)
+			}
+			for _, m := range messages {
+				if !m.IsPublic && tt.messages == 1 {
+					t.Errorf("members-only message %q shown", m.Content)
+				}
+			}
+
+			var prayers []models.PrayerRequest
+			code = getJSON(t, r, tt.user, tt.path+"/prayers", &prayers)
+			if tt.prayers < 0 {
+				if code != http.StatusForbidden {
+					t.Errorf("prayers: %d, want 403", code)
+				}
+			} else if code != http.StatusOK || len(prayers) != tt.prayers {
+				t.Errorf("prayers: %d, %d requests, want %d", code, len(prayers), tt.prayers)
+			}
+		})
+	}
+
+	for _, path := range []string{"/api/groups/99", "/api/groups/99/messages", "/api/groups/99/prayers"} {
+		if code := getJSON(t, r, userChurchLeader, path, nil); code != http.StatusNotFound {
+			t.Errorf("GET %s: %d, want 404", path, code)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"theword/Backend/lib/audio"
+	"theword/Backend/lib/bible"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetChapterAudio describes a chapter's recording: where to stream it from
+// and, when the recording has them, verse timestamps for following along.
+func GetChapterAudio(library *audio.Library) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if library == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Audio not available"})
+			return
+		}
+		translationID, chapterID := c.Param("bibleId"), strings.ToUpper(c.Param("chapterId"))
+
+		obj, contentType, err := library.Open(c.Request.Context(), translationID, chapterID)
+		if err != nil {
+			audioError(c, err)
+			return
+		}
+		size := obj.Size
+		obj.Close()
+
+		timestamps, err := library.Timestamps(c.Request.Context(), translationID, chapterID)
+		if err != nil {
+			// The audio still plays without them.
+			log.Printf("Failed to read audio timestamps: %v", err)
+		}
+		reference := chapterID
+		if ranges, err := bible.ParseReference(chapterID); err == nil {
+			reference = bible.FormatReference(ranges, bible.RefFull)
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"translation_id": translationID,
+			"chapter_id":     chapterID,
+			"reference":      reference,
+			"stream_url":     "/api/bible/" + translationID + "/audio/" + chapterID + "/stream",
+			"content_type":   contentType,
+			"size":           size,
+			"timestamps":     timestamps,
+		})
+	}
+}
+
+// StreamChapterAudio streams a chapter's recording, honouring Range
+// requests so players can seek.
+func StreamChapterAudio(library *audio.Library) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if library == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Audio not available"})
+			return
+		}
+		chapterID := strings.ToUpper(c.Param("chapterId"))
+
+		obj, contentType, err := library.Open(c.Request.Context(), c.Param("bibleId"), chapterID)
+		if err != nil {
+			audioError(c, err)
+			return
+		}
+		defer obj.Close()
+
+		c.Header("Content-Type", contentType)
+		c.Header("Cache-Control", "public, max-age=86400")
+		http.ServeContent(c.Writer, c.Request, chapterID, obj.ModTime, obj)
+	}
+}
+
+func audioError(c *gin.Context, err error) {
+	if errors.Is(err, audio.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Audio not available"})
+		return
+	}
+	log.Printf("Failed to open audio: %v", err)
+	c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch audio"})
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"theword/Backend/lib/bible"
+)
+
+// bibleBooks is the Protestant canon, which reading plans and reading stats
+// cover.
+var bibleBooks = bible.ProtestantCanon()
+
+func findBibleBook(id string) (bible.BookInfo, bool) {
+	return bible.BookByUSFM(id)
+}
+
+// normaliseChapterReference upper-cases a chapter ID in the form
+// GetBiblePassage takes, e.g. "JHN.3", and checks the chapter exists.
+func normaliseChapterReference(ref string) (string, bool) {
+	ref = strings.ToUpper(strings.TrimSpace(ref))
+	if !strings.Contains(ref, ".") {
+		return ref, false
+	}
+	book, chapter, ok := bible.ParseChapterID(ref)
+	if !ok {
+		return ref, false
+	}
+	return book.USFM + "." + strconv.Itoa(chapter), true
+}
+
+// bookChapters lists every chapter reference in the given books, in order.
+func bookChapters(books []bible.BookInfo) []string {
+	var refs []string
+	for _, b := range books {
+		for ch := 1; ch <= b.Chapters; ch++ {
+			refs = append(refs, b.USFM+"."+strconv.Itoa(ch))
+		}
+	}
+	return refs
+}
+
+// normaliseVerseReference reads a single verse, either in the sid form
+// GetBiblePassage emits, e.g. "JHN.3.16", as typed, e.g. "Jn 3:16", or in
+// the "chapter:verse" form the app saves ESV verses under, e.g.
+// "JHN.3:JHN.3.16", and returns its sid and chapter ID.
+func normaliseVerseReference(ref string) (verseID, chapterID string, ok bool) {
+	ranges, err := bible.ParseReference(ref)
+	if err != nil {
+		chapter, verse, found := strings.Cut(ref, ":")
+		if !found {
+			return ref, "", false
+		}
+		if ranges, err = bible.ParseReference(verse); err != nil || len(ranges) != 1 ||
+			!strings.EqualFold(chapter, ranges[0].ChapterID()) {
+			return ref, "", false
+		}
+	}
+	if len(ranges) != 1 || !ranges[0].IsVerse() {
+		return ref, "", false
+	}
+	return ranges[0].VerseID(), ranges[0].ChapterID(), true
+}
+
+// verseReferenceFilter turns a search such as "Jn 3:16-18; Rom 8" into a
+// condition matching the verse IDs it covers in column, or returns false if
+// q isn't a reference. Verse IDs the app saved in the "chapter:verse" form
+// match too.
+func verseReferenceFilter(column, q string) (string, []interface{}, bool) {
+	ranges, err := bible.ParseReference(q)
+	if err != nil {
+		return "", nil, false
+	}
+
+	var conds []string
+	var args []interface{}
+	for _, r := range ranges {
+		if book, _ := bible.BookByUSFM(r.Book); r.StartVerse == 0 && r.StartChapter == 1 && r.EndChapter == book.Chapters {
+			conds = append(conds, column+" LIKE ?")
+			args = append(args, r.Book+".%")
+			continue
+		}
+		for _, span := range r.Spans() {
+			chapterID := r.Book + "." + strconv.Itoa(span.Chapter)
+			if span.StartVerse == 0 {
+				conds = append(conds, column+" LIKE ? OR "+column+" LIKE ?")
+				args = append(args, chapterID+".%", chapterID+":%")
+				continue
+			}
+			end := span.EndVerse
+			if end == 0 {
+				end = bible.MaxVerse
+			}
+			var ids []string
+			for v := span.StartVerse; v <= end; v++ {
+				verseID := chapterID + "." + strconv.Itoa(v)
+				ids = append(ids, verseID, chapterID+":"+verseID)
+			}
+			conds = append(conds, column+" IN ?")
+			args = append(args, ids)
+		}
+	}
+	return strings.Join(conds, " OR "), args, true
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"theword/Backend/lib/bible"
+	"theword/Backend/lib/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// siteAdmin checks that the requesting user is a site admin, writing the
+// error response if not.
+func siteAdmin(c *gin.Context, db *gorm.DB) bool {
+	userID := c.MustGet("userID").(uint)
+	var user models.User
+	if err := db.First(&user, "user_id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return false
+	}
+	if !user.IsSiteAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only site admins can manage translations"})
+		return false
+	}
+	return true
+}
+
+// SyncSiteAdmins makes the users whose emails are in the comma-separated
+// list, and only them, site admins. It runs at startup with
+// SITE_ADMIN_EMAILS, so access is granted and revoked through configuration.
+func SyncSiteAdmins(db *gorm.DB, emails string) {
+	var admins []string
+	for _, email := range strings.Split(emails, ",") {
+		if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+			admins = append(admins, email)
+		}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		revoke := tx.Model(&models.User{}).Where("is_site_admin = ?", true)
+		if len(admins) > 0 {
+			revoke = revoke.Where("LOWER(email) NOT IN ?", admins)
+		}
+		if err := revoke.Update("is_site_admin", false).Error; err != nil {
+			return err
+		}
+		if len(admins) == 0 {
+			return nil
+		}
+		return tx.Model(&models.User{}).Where("LOWER(email) IN ?", admins).Update("is_site_admin", true).Error
+	})
+	if err != nil {
+		log.Printf("Failed to update site admins: %v", err)
+		return
+	}
+	if len(admins) == 0 {
+		log.Println("SITE_ADMIN_EMAILS is not set; no one can manage the translation catalog.")
+	}
+}
+
+// GetTranslationCatalog lists every translation in the catalog, disabled
+// ones included, with its licence terms.
+func GetTranslationCatalog(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !siteAdmin(c, db) {
+			return
+		}
+
+		var entries []models.BibleCatalogEntry
+		if err := db.Order("translation_id").Find(&entries).Error; err != nil {
+			log.Printf("Failed to fetch translation catalog: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch translations"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": entries})
+	}
+}
+
+// UpdateTranslationCatalog adds a translation to the catalog or changes the
+// fields given for one already there, e.g. {"enabled": false} to stop
+// listing and serving it. Changes apply at once.
+func UpdateTranslationCatalog(db *gorm.DB, bibles *bible.Providers) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !siteAdmin(c, db) {
+			return
+		}
+
+		id := strings.ToUpper(c.Param("id"))
+		var entry models.BibleCatalogEntry
+		if err := db.Where("UPPER(translation_id) = ?", id).Limit(1).Find(&entry).Error; err != nil {
+			log.Printf("Failed to fetch translation catalog entry: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch translation"})
+			return
+		}
+		if entry.TranslationID == "" {
+			entry = models.BibleCatalogEntry{TranslationID: id, Provider: bible.ProviderAPIBible, CacheAllowed: true, Enabled: true}
+		}
+
+		if err := c.ShouldBindJSON(&entry); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid translation"})
+			return
+		}
+		entry.TranslationID = id
+		switch entry.Provider {
+		case bible.ProviderLocal, bible.ProviderESV, bible.ProviderAPIBible:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Provider must be local, esv or api.bible"})
+			return
+		}
+		if entry.MaxVerses < 0 || entry.CacheMaxVerses < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Verse limits cannot be negative"})
+			return
+		}
+
+		if err := bibles.SaveCatalogEntry(c.Request.Context(), &entry); err != nil {
+			log.Printf("Failed to save translation catalog entry: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save translation"})
+			return
+		}
+		c.JSON(http.StatusOK, entry)
+	}
+}
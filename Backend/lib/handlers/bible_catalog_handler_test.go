@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"theword/Backend/lib/bible"
+	"theword/Backend/lib/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSyncSiteAdmins(t *testing.T) {
+	db := newTestDB(t, &models.User{})
+	db.Create(&[]models.User{
+		{UserID: 1, Email: "admin@example.com", IsSiteAdmin: true},
+		{UserID: 2, Email: "Curator@Example.com"},
+		{UserID: 3, Email: "reader@example.com"},
+	})
+	siteAdmins := func() []uint {
+		var ids []uint
+		db.Model(&models.User{}).Where("is_site_admin = ?", true).Order("user_id").Pluck("user_id", &ids)
+		return ids
+	}
+
+	SyncSiteAdmins(db, " curator@example.com, nobody@example.com")
+	if ids := siteAdmins(); len(ids) != 1 || ids[0] != 2 {
+		t.Errorf("site admins = %v, want [2]", ids)
+	}
+
+	SyncSiteAdmins(db, "")
+	if ids := siteAdmins(); len(ids) != 0 {
+		t.Errorf("site admins with none configured = %v", ids)
+	}
+}
+
+func TestUpdateTranslationCatalogCase(t *testing.T) {
+	db := newTestDB(t, &models.User{}, &models.BibleTranslation{}, &models.BibleCatalogEntry{})
+	db.Create(&models.User{UserID: 1, Email: "curator@example.com", IsSiteAdmin: true})
+	db.Create(&models.BibleCatalogEntry{TranslationID: "ESV", Provider: bible.ProviderESV, MaxVerses: 500, Enabled: true})
+	bibles := bible.NewProviders(db, "", "", nil)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.PUT("/api/admin/bible/translations/:id", asUser, UpdateTranslationCatalog(db, bibles))
+
+	if code := send(r, http.MethodPut, 1, "/api/admin/bible/translations/esv", `{"enabled":false}`); code != http.StatusOK {
+		t.Fatalf("PUT esv: %d", code)
+	}
+	var entries []models.BibleCatalogEntry
+	db.Find(&entries)
+	if len(entries) != 1 || entries[0].TranslationID != "ESV" || entries[0].Enabled || entries[0].MaxVerses != 500 {
+		t.Errorf("catalog = %+v, want ESV alone, disabled", entries)
+	}
+}
@@ -1,239 +1,461 @@
 package handlers
 
 import (
-	"encoding/json"
-	"fmt"
-	"io"
+	"errors"
 	"log"
 	"net/http"
-	"net/url"
-	"regexp"
+	"strconv"
 	"strings"
+	"theword/Backend/lib/bible"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-func GetBibleTranslations(apiKey string) gin.HandlerFunc {
+func GetBibleTranslations(bibles *bible.Providers) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if apiKey == "" {
-			log.Println("Missing BIBLE_KEY")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Missing BIBLE_KEY"})
+		translations, err := bibles.Translations(c.Request.Context())
+		if err != nil {
+			bibleError(c, err, "Failed to fetch translations")
 			return
 		}
 
-		req, _ := http.NewRequest("GET", "https://api.scripture.api.bible/v1/bibles", nil)
-		req.Header.Set("api-key", apiKey)
+		c.JSON(http.StatusOK, gin.H{"data": translations})
+	}
+}
+
+func GetBibleBooks(bibles *bible.Providers) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bibleId := c.Param("bibleId")
 
-		resp, err := http.DefaultClient.Do(req)
+		result, err := bibles.For(bibleId).Books(c.Request.Context(), bibleId)
 		if err != nil {
-			log.Printf("HTTP request error: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reach Scripture API"})
+			bibleError(c, err, "Failed to fetch books")
 			return
 		}
-		defer resp.Body.Close()
 
-		body, _ := io.ReadAll(resp.Body)
+		c.JSON(http.StatusOK, result)
+	}
+}
 
-		if resp.StatusCode != 200 {
-			log.Printf("Scripture API returned %d: %s", resp.StatusCode, string(body))
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "Failed to fetch translations",
-				"details": string(body),
-			})
-			return
-		}
+func GetBibleChapters(bibles *bible.Providers) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bibleId := c.Param("bibleId")
 
-		var result map[string]interface{}
-		if err := json.Unmarshal(body, &result); err != nil {
-			log.Printf("JSON decode error: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse API response"})
+		result, err := bibles.For(bibleId).Chapters(c.Request.Context(), bibleId, c.Param("bookId"))
+		if err != nil {
+			bibleError(c, err, "Failed to fetch chapters")
 			return
 		}
 
-		dataRaw, ok := result["data"].([]interface{})
-		if !ok {
-			log.Printf("Unexpected format from Scripture API: %s", string(body))
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid response format"})
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+func GetBiblePassage(bibles *bible.Providers) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		translationId := c.Param("translationId")
+
+		result, err := bibles.Passage(c.Request.Context(), translationId, c.Query("q"))
+		if err != nil {
+			bibleError(c, err, "Failed to fetch passage")
 			return
 		}
 
-		dataRaw = append(dataRaw, map[string]interface{}{
-			"id":   "ESV",
-			"name": "English Standard Version",
-		})
-		result["data"] = dataRaw
-
 		c.JSON(http.StatusOK, result)
 	}
 }
 
-func GetBibleBooks(apiKey string) gin.HandlerFunc {
+// GetBiblePassageRange returns the verses of a reference such as
+// "Jn 3:16-18; Rom 8:28" as JSON, or rendered with format=text, html or
+// markdown.
+func GetBiblePassageRange(bibles *bible.Providers) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		bibleId := c.Param("bibleId")
-
-		url := fmt.Sprintf("https://api.scripture.api.bible/v1/bibles/%s/books", bibleId)
-		req, _ := http.NewRequest("GET", url, nil)
-		req.Header.Set("api-key", apiKey)
+		ranges, err := bible.ParseReference(c.Query("ref"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reference"})
+			return
+		}
 
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil || resp.StatusCode != 200 {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch books"})
+		format := c.DefaultQuery("format", "json")
+		switch format {
+		case "json", "text", "html", "markdown":
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Format must be json, text, html or markdown"})
 			return
 		}
-		defer resp.Body.Close()
 
-		body, _ := io.ReadAll(resp.Body)
-		var result map[string]interface{}
-		json.Unmarshal(body, &result)
+		passage, err := bibles.Range(c.Request.Context(), c.Param("bibleId"), ranges)
+		if err != nil {
+			bibleError(c, err, "Failed to fetch passage")
+			return
+		}
 
-		c.JSON(http.StatusOK, result)
+		switch format {
+		case "text":
+			c.String(http.StatusOK, passage.Text())
+		case "html":
+			c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(passage.HTML()))
+		case "markdown":
+			c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(passage.Markdown()))
+		default:
+			c.JSON(http.StatusOK, passage)
+		}
 	}
 }
 
-func GetBibleChapters(apiKey string) gin.HandlerFunc {
+// CompareBiblePassage lines up a reference in the translations listed in
+// translations, e.g. "ESV,KJV,WEB", verse by verse. Translations that fail
+// carry an error and the rest are still returned.
+func CompareBiblePassage(bibles *bible.Providers) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		bibleId := c.Param("bibleId")
-		bookId := c.Param("bookId")
+		ranges, err := bible.ParseReference(c.Query("ref"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reference"})
+			return
+		}
 
-		url := fmt.Sprintf("https://api.scripture.api.bible/v1/bibles/%s/books/%s/chapters", bibleId, bookId)
-		req, _ := http.NewRequest("GET", url, nil)
-		req.Header.Set("api-key", apiKey)
+		var translations []string
+		seen := map[string]bool{}
+		for _, id := range strings.Split(c.Query("translations"), ",") {
+			if id = strings.TrimSpace(id); id != "" && !seen[strings.ToUpper(id)] {
+				seen[strings.ToUpper(id)] = true
+				translations = append(translations, id)
+			}
+		}
+		if len(translations) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "translations is required"})
+			return
+		}
 
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil || resp.StatusCode != 200 {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch chapters"})
+		comparison, err := bibles.Compare(c.Request.Context(), translations, ranges)
+		if err != nil {
+			bibleError(c, err, "Failed to compare translations")
 			return
 		}
-		defer resp.Body.Close()
 
-		body, _ := io.ReadAll(resp.Body)
-		var result map[string]interface{}
-		json.Unmarshal(body, &result)
+		c.JSON(http.StatusOK, comparison)
+	}
+}
 
-		// Filter out "intro" chapters
-		data := result["data"].([]interface{})
-		filtered := []interface{}{}
-		for _, ch := range data {
-			chMap := ch.(map[string]interface{})
-			if chMap["number"] != "intro" {
-				filtered = append(filtered, chMap)
+// SearchBible searches the text of stored translations, and of cached ones
+// where their licence allows, for q: words, "quoted phrases" and -excluded
+// words. translations, book (e.g. "John" or "Rom; 1 Cor") and testament
+// (ot, nt or dc) narrow the search. Disabled translations are left out, and
+// each translation found comes with its attribution.
+func SearchBible(db *gorm.DB, bibles *bible.Providers) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, pageSize := biblePage(c)
+		query := bible.SearchQuery{
+			Text:    c.Query("q"),
+			Exclude: bibles.Catalog.Disabled(),
+			Limit:   pageSize,
+			Offset:  (page - 1) * pageSize,
+		}
+		for _, id := range strings.Split(c.Query("translations"), ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				query.Translations = append(query.Translations, id)
 			}
 		}
-		result["data"] = filtered
+		if book := c.Query("book"); book != "" {
+			ranges, err := bible.ParseReference(book)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid book"})
+				return
+			}
+			for _, r := range ranges {
+				query.Books = append(query.Books, r.Book)
+			}
+		}
+		if testament := c.Query("testament"); testament != "" {
+			t, ok := map[string]bible.Testament{"ot": bible.OldTestament, "nt": bible.NewTestament, "dc": bible.Deuterocanon}[strings.ToLower(testament)]
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Testament must be ot, nt or dc"})
+				return
+			}
+			query.Testament = &t
+		}
 
-		c.JSON(http.StatusOK, result)
+		results, err := bible.Search(c.Request.Context(), db, query)
+		if errors.Is(err, bible.ErrEmptyQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Search query is required"})
+			return
+		}
+		if err != nil {
+			log.Printf("Bible search failed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search"})
+			return
+		}
+
+		attributions := []*bible.Attribution{}
+		seen := map[string]bool{}
+		for _, r := range results {
+			if !seen[r.TranslationID] {
+				seen[r.TranslationID] = true
+				attributions = append(attributions, bibles.Attribution(r.TranslationID, nil))
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": results, "attributions": attributions, "page": page, "pageSize": pageSize})
 	}
 }
 
-var esvBookMap = map[string]string{
-	"RUT": "Ruth",
-	"NAH": "Nahum",
-	"NAM": "Nahum",
-	// add more only if you discover they fail
-}
+// GetCrossReferences lists the verses related to ref, most voted first.
+// With ?translation= each carries its text.
+func GetCrossReferences(db *gorm.DB, bibles *bible.Providers) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ranges, err := bible.ParseReference(c.Query("ref"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reference"})
+			return
+		}
+
+		page, pageSize := biblePage(c)
+		related, err := bible.CrossReferences(c.Request.Context(), db, ranges, pageSize, (page-1)*pageSize)
+		if err != nil {
+			log.Printf("Failed to fetch cross-references: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cross-references"})
+			return
+		}
+		attribution, err := fillVerseText(c, bibles, related)
+		if err != nil {
+			bibleError(c, err, "Failed to fetch passage")
+			return
+		}
 
-func normaliseESV(ref string) string {
-	parts := strings.Split(ref, ".")  // ["NAH", "1"]
-	usfm := strings.ToUpper(parts[0]) // "NAH"
-	if full, ok := esvBookMap[usfm]; ok {
-		parts[0] = full // "Nahum"
+		c.JSON(http.StatusOK, gin.H{
+			"reference":   bible.FormatReference(ranges, bible.RefFull),
+			"data":        related,
+			"attribution": attribution,
+			"page":        page,
+			"pageSize":    pageSize,
+		})
 	}
-	return strings.Join(parts, " ") // "Nahum 1"
 }
 
-func GetBiblePassage(apiKey string, esvKey string) gin.HandlerFunc {
+// GetBibleTopics lists the topical index by name, narrowed to names
+// starting with ?q=. With ?ref= it lists the topics that verse is key to.
+func GetBibleTopics(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		translationId := c.Param("translationId")
-		reference := c.Query("q")
-
-		if translationId == "ESV" {
-			esvRef := normaliseESV(reference)
-
-			req, _ := http.NewRequest(
-				"GET",
-				"https://api.esv.org/v3/passage/text/?q="+url.QueryEscape(esvRef)+
-					"&include-verse-numbers=true&include-footnotes=false&include-footnote-body=false"+
-					"&include-headings=false&include-short-copyright=true",
-				nil)
-			req.Header.Set("Authorization", "Token "+esvKey)
-
-			resp, err := http.DefaultClient.Do(req)
-			if err != nil || resp.StatusCode != 200 {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ESV passage"})
+		if ref := c.Query("ref"); ref != "" {
+			ranges, err := bible.ParseReference(ref)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reference"})
 				return
 			}
-			defer resp.Body.Close()
+			topics, err := bible.TopicsFor(c.Request.Context(), db, ranges)
+			if err != nil {
+				log.Printf("Failed to fetch topics: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch topics"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"data": topics})
+			return
+		}
 
-			body, _ := io.ReadAll(resp.Body)
-			var result map[string]interface{}
-			json.Unmarshal(body, &result)
+		page, pageSize := biblePage(c)
+		topics, err := bible.Topics(c.Request.Context(), db, c.Query("q"), pageSize, (page-1)*pageSize)
+		if err != nil {
+			log.Printf("Failed to fetch topics: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch topics"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": topics, "page": page, "pageSize": pageSize})
+	}
+}
 
-			passage := ""
-			if passages, ok := result["passages"].([]interface{}); ok && len(passages) > 0 {
-				passage = passages[0].(string)
-			}
+// GetBibleTopic returns a topic with its key verses, most voted first.
+// With ?translation= each carries its text.
+func GetBibleTopic(db *gorm.DB, bibles *bible.Providers) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, pageSize := biblePage(c)
+		topic, verses, err := bible.TopicVerses(c.Request.Context(), db, c.Param("slug"), pageSize, (page-1)*pageSize)
+		if errors.Is(err, bible.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Topic not found"})
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to fetch topic: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch topic"})
+			return
+		}
+		attribution, err := fillVerseText(c, bibles, verses)
+		if err != nil {
+			bibleError(c, err, "Failed to fetch passage")
+			return
+		}
 
-			refDot := strings.ReplaceAll(reference, " ", ".") + "."
-
-			// NEW regex: [12]   (with or without white-space)
-			re := regexp.MustCompile(`\[\s*(\d+)\s*]`)
-			locs := re.FindAllStringSubmatchIndex(passage, -1)
-
-			var verses []map[string]interface{}
-			for i, loc := range locs {
-				verseNum := passage[loc[2]:loc[3]] // capture “12”
-				startTxt := loc[1]                 // char after “]”
-				endTxt := len(passage)
-				if i+1 < len(locs) {
-					endTxt = locs[i+1][0]
-				}
-
-				verseText := strings.TrimSpace(passage[startTxt:endTxt])
-				if verseText == "" {
-					continue
-				}
-
-				verses = append(verses, map[string]interface{}{
-					"name": "verse",
-					"attrs": map[string]interface{}{
-						"sid": fmt.Sprintf("%s%s", refDot, verseNum), // LEV.3.12
-					},
-					"items": []map[string]interface{}{
-						{"type": "text", "text": verseText},
-					},
-				})
-			}
+		c.JSON(http.StatusOK, gin.H{"topic": topic, "data": verses, "attribution": attribution, "page": page, "pageSize": pageSize})
+	}
+}
 
-			// wrap like Scripture API
-			structured := []map[string]interface{}{
-				{"name": "para", "items": verses},
-			}
+// verseTexts fetches the text of each verse ID in the translation given in
+// ?translation=, with the translation's attribution, returning nil when
+// there is none. Verses the translation lacks are left empty.
+func verseTexts(c *gin.Context, bibles *bible.Providers, verseIDs []string) ([]string, *bible.Attribution, error) {
+	translationID := c.Query("translation")
+	if translationID == "" {
+		return nil, nil, nil
+	}
+	attribution := bibles.Attribution(translationID, nil)
+	texts := make([]string, len(verseIDs))
+	for i, id := range verseIDs {
+		ranges, err := bible.ParseReference(id)
+		if err != nil {
+			continue
+		}
+		passage, err := bibles.Range(c.Request.Context(), translationID, ranges)
+		if errors.Is(err, bible.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		attribution = passage.Attribution
+		verses := make([]string, len(passage.Verses))
+		for j, v := range passage.Verses {
+			verses[j] = v.Text
+		}
+		texts[i] = strings.Join(verses, " ")
+	}
+	return texts, attribution, nil
+}
 
-			c.JSON(http.StatusOK, gin.H{"data": map[string]interface{}{"content": structured}})
+// fillVerseText sets the text of ranked verses from ?translation=,
+// returning the translation's attribution.
+func fillVerseText(c *gin.Context, bibles *bible.Providers, verses []bible.RankedVerse) (*bible.Attribution, error) {
+	ids := make([]string, len(verses))
+	for i, v := range verses {
+		ids[i] = v.VerseID
+	}
+	texts, attribution, err := verseTexts(c, bibles, ids)
+	for i, text := range texts {
+		verses[i].Text = text
+	}
+	return attribution, err
+}
+
+// GetBibleWords returns the original-language words of each verse in ref,
+// with lemma, Strong's number, morphology and gloss.
+func GetBibleWords(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ranges, err := bible.ParseReference(c.Query("ref"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reference"})
 			return
 		}
 
-		// Non-ESV (scripture.api.bible)
-		url := fmt.Sprintf("https://api.scripture.api.bible/v1/bibles/%s/chapters/%s?content-type=json", translationId, reference)
-		req, _ := http.NewRequest("GET", url, nil)
-		req.Header.Set("api-key", apiKey)
+		verses, err := bible.Words(c.Request.Context(), db, ranges)
+		if err != nil {
+			log.Printf("Failed to fetch words: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch words"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"reference": bible.FormatReference(ranges, bible.RefFull), "data": verses})
+	}
+}
 
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil || resp.StatusCode != 200 {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch structured chapter"})
+// GetLexiconEntry looks up a Strong's number, e.g. G25 or H157.
+func GetLexiconEntry(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		strongs, ok := bible.ParseStrongs(c.Param("strongs"))
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Strong's number"})
 			return
 		}
-		defer resp.Body.Close()
 
-		body, _ := io.ReadAll(resp.Body)
-		var result map[string]interface{}
-		if err := json.Unmarshal(body, &result); err != nil {
-			log.Printf("Error parsing Scripture JSON: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse JSON"})
+		entry, err := bible.Lexicon(c.Request.Context(), db, strongs)
+		if errors.Is(err, bible.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
 			return
 		}
+		if err != nil {
+			log.Printf("Failed to fetch lexicon entry: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lexicon entry"})
+			return
+		}
+		c.JSON(http.StatusOK, entry)
+	}
+}
 
-		// Just forward it exactly as-is
-		c.JSON(http.StatusOK, result)
+// GetConcordance lists every verse using a Strong's number, in canonical
+// order, with its lexicon entry when there is one. With ?translation= each
+// verse carries its text.
+func GetConcordance(db *gorm.DB, bibles *bible.Providers) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		strongs, ok := bible.ParseStrongs(c.Param("strongs"))
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Strong's number"})
+			return
+		}
+
+		page, pageSize := biblePage(c)
+		verses, total, err := bible.Concordance(c.Request.Context(), db, strongs, pageSize, (page-1)*pageSize)
+		if err != nil {
+			log.Printf("Failed to fetch concordance: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch concordance"})
+			return
+		}
+		entry, err := bible.Lexicon(c.Request.Context(), db, strongs)
+		if err != nil && !errors.Is(err, bible.ErrNotFound) {
+			log.Printf("Failed to fetch lexicon entry: %v", err)
+		}
+
+		ids := make([]string, len(verses))
+		for i, v := range verses {
+			ids[i] = v.VerseID
+		}
+		texts, attribution, err := verseTexts(c, bibles, ids)
+		if err != nil {
+			bibleError(c, err, "Failed to fetch passage")
+			return
+		}
+		for i, text := range texts {
+			verses[i].Text = text
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"strongs":     strongs,
+			"entry":       entry,
+			"total":       total,
+			"data":        verses,
+			"attribution": attribution,
+			"page":        page,
+			"pageSize":    pageSize,
+		})
+	}
+}
+
+// biblePage reads ?page= and ?pageSize=, 20 by default and at most 100.
+func biblePage(c *gin.Context) (page, pageSize int) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err = strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	return page, pageSize
+}
+
+// bibleError reports a provider error, keeping upstream details in the log.
+func bibleError(c *gin.Context, err error, msg string) {
+	var upstream *bible.UpstreamError
+	switch {
+	case errors.Is(err, bible.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	case errors.Is(err, bible.ErrPassageTooLong):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Passage too long"})
+	case errors.Is(err, bible.ErrTooManyTranslations):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many translations"})
+	case errors.Is(err, bible.ErrUnsupported):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not available for this translation"})
+	case errors.As(err, &upstream):
+		log.Printf("%s: %v", msg, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": msg})
+	default:
+		log.Printf("%s: %v", msg, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
 	}
 }
@@ -1,13 +1,121 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"theword/Backend/lib/bible"
 	"theword/Backend/lib/models"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+// normaliseBookmark tidies the user-editable fields, returning false if the
+// verse range is invalid. A single verse may be given as VerseStart alone,
+// and the whole location as a Reference such as "Jn 3:16-18".
+func normaliseBookmark(bookmark *models.Bookmark) bool {
+	if bookmark.Reference != "" {
+		ranges, err := bible.ParseReference(bookmark.Reference)
+		if err != nil || len(ranges) != 1 || ranges[0].StartChapter != ranges[0].EndChapter {
+			return false
+		}
+		r := ranges[0]
+		book, _ := bible.BookByUSFM(r.Book)
+		bookmark.BookID = r.Book
+		bookmark.ChapterID = r.ChapterID()
+		bookmark.VerseStart = r.StartVerse
+		bookmark.VerseEnd = r.EndVerse
+		if bookmark.BookName == "" {
+			bookmark.BookName = book.Name
+		}
+		if bookmark.ChapterName == "" {
+			bookmark.ChapterName = book.Name + " " + strconv.Itoa(r.StartChapter)
+		}
+	}
+	if bookmark.VerseStart < 0 || bookmark.VerseEnd < 0 {
+		return false
+	}
+	if bookmark.VerseStart == 0 {
+		bookmark.VerseEnd = 0
+	} else if bookmark.VerseEnd == 0 {
+		bookmark.VerseEnd = bookmark.VerseStart
+	}
+	if bookmark.VerseEnd < bookmark.VerseStart {
+		return false
+	}
+
+	tags := []string{}
+	for _, tag := range bookmark.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !containsFold(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	bookmark.Tags = tags
+	describeBookmark(bookmark)
+	return true
+}
+
+// describeBookmark fills in Reference for bookmarks in the canon.
+func describeBookmark(bookmark *models.Bookmark) {
+	book, chapter, ok := bible.ParseChapterID(bookmark.ChapterID)
+	if !ok {
+		bookmark.Reference = ""
+		return
+	}
+	bookmark.Reference = bible.Range{
+		Book:         book.USFM,
+		StartChapter: chapter,
+		StartVerse:   bookmark.VerseStart,
+		EndChapter:   chapter,
+		EndVerse:     bookmark.VerseEnd,
+	}.String()
+}
+
+// bookmarkReferenceFilter turns a search such as "Jn 3:16" into a condition
+// matching bookmarks that overlap it, or returns false if q isn't a
+// reference.
+func bookmarkReferenceFilter(q string) (string, []interface{}, bool) {
+	ranges, err := bible.ParseReference(q)
+	if err != nil {
+		return "", nil, false
+	}
+
+	var conds []string
+	var args []interface{}
+	for _, r := range ranges {
+		for _, span := range r.Spans() {
+			chapterID := r.Book + "." + strconv.Itoa(span.Chapter)
+			if span.StartVerse == 0 {
+				conds = append(conds, "chapter_id = ?")
+				args = append(args, chapterID)
+				continue
+			}
+			end := span.EndVerse
+			if end == 0 {
+				end = bible.MaxVerse
+			}
+			conds = append(conds, "(chapter_id = ? AND (verse_start = 0 OR (verse_start <= ? AND verse_end >= ?)))")
+			args = append(args, chapterID, end, span.StartVerse)
+		}
+	}
+	return strings.Join(conds, " OR "), args, true
+}
+
+// checkBookmarkFolder makes sure a folder the user picked is theirs.
+func checkBookmarkFolder(db *gorm.DB, userID uint, folderID *uint) bool {
+	if folderID == nil {
+		return true
+	}
+	var count int64
+	db.Model(&models.BookmarkFolder{}).Where("folder_id = ? AND user_id = ?", *folderID, userID).Count(&count)
+	return count > 0
+}
+
+// CreateBookmark saves a bookmark. Bookmarking a location that is already
+// saved updates the existing bookmark instead of adding a duplicate.
 func CreateBookmark(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID := c.MustGet("userID").(uint)
@@ -17,9 +125,52 @@ func CreateBookmark(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 			return
 		}
+		if !normaliseBookmark(&bookmark) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid verse range"})
+			return
+		}
+		if !checkBookmarkFolder(db, userID, bookmark.FolderID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Folder not found"})
+			return
+		}
+
+		var existing models.Bookmark
+		err := db.Where("user_id = ? AND translation_id = ? AND chapter_id = ? AND verse_start = ? AND verse_end = ?",
+			userID, bookmark.TranslationID, bookmark.ChapterID, bookmark.VerseStart, bookmark.VerseEnd).
+			First(&existing).Error
+		switch {
+		case err == nil:
+			bookmark.BookmarkID = existing.BookmarkID
+			bookmark.Position = existing.Position
+			bookmark.CreatedAt = existing.CreatedAt
+			if bookmark.Title == "" {
+				bookmark.Title = existing.Title
+			}
+			if bookmark.Note == "" {
+				bookmark.Note = existing.Note
+			}
+			if bookmark.Color == "" {
+				bookmark.Color = existing.Color
+			}
+			if len(bookmark.Tags) == 0 {
+				bookmark.Tags = existing.Tags
+			}
+			if bookmark.FolderID == nil {
+				bookmark.FolderID = existing.FolderID
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			bookmark.BookmarkID = 0
+			db.Model(&models.Bookmark{}).Where("user_id = ?", userID).Select("COALESCE(MAX(position), 0) + 1").Scan(&bookmark.Position)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bookmark"})
+			return
+		}
 
 		bookmark.UserID = userID
-		if err := db.Create(&bookmark).Error; err != nil {
+		if err := syncWrite(db, userID, models.SyncEntityBookmark, func(tx *gorm.DB) (uint, error) {
+			err := tx.Save(&bookmark).Error
+			return bookmark.BookmarkID, err
+		}); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bookmark"})
 			return
 		}
@@ -28,20 +179,158 @@ func CreateBookmark(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
+// GetBookmarks lists the user's bookmarks in their chosen order. Filter with
+// ?folder_id= ("none" for unfiled), ?tag=, ?translation_id= and ?q=.
 func GetBookmarks(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID := c.MustGet("userID").(uint)
 		var bookmarks []models.Bookmark
 
-		if err := db.Where("user_id = ?", userID).Find(&bookmarks).Error; err != nil {
+		query := db.Where("user_id = ?", userID)
+		switch folderID := c.Query("folder_id"); folderID {
+		case "":
+		case "none":
+			query = query.Where("folder_id IS NULL")
+		default:
+			query = query.Where("folder_id = ?", folderID)
+		}
+		if tag := strings.ToLower(strings.TrimSpace(c.Query("tag"))); tag != "" {
+			query = query.Where("tags LIKE ?", `%"`+tag+`"%`)
+		}
+		if translationID := c.Query("translation_id"); translationID != "" {
+			query = query.Where("translation_id = ?", translationID)
+		}
+		if q := strings.TrimSpace(c.Query("q")); q != "" {
+			like := "%" + strings.ToLower(q) + "%"
+			match := "LOWER(title) LIKE ? OR LOWER(note) LIKE ? OR LOWER(book_name) LIKE ? OR LOWER(chapter_name) LIKE ?"
+			args := []interface{}{like, like, like, like}
+			// A reference such as "Jn 3:16" also finds bookmarks covering it.
+			if refMatch, refArgs, ok := bookmarkReferenceFilter(q); ok {
+				match += " OR " + refMatch
+				args = append(args, refArgs...)
+			}
+			query = query.Where(match, args...)
+		}
+
+		if err := query.Order("position ASC, created_at DESC").Find(&bookmarks).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bookmarks"})
 			return
 		}
+		for i := range bookmarks {
+			describeBookmark(&bookmarks[i])
+		}
 
 		c.JSON(http.StatusOK, bookmarks)
 	}
 }
 
+func UpdateBookmark(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+		bookmarkID := c.Param("id")
+
+		var bookmark models.Bookmark
+		if err := db.First(&bookmark, "bookmark_id = ? AND user_id = ?", bookmarkID, userID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Bookmark not found"})
+			return
+		}
+
+		var req struct {
+			VerseStart int      `json:"verse_start"`
+			VerseEnd   int      `json:"verse_end"`
+			Title      string   `json:"title"`
+			Note       string   `json:"note"`
+			Color      string   `json:"color"`
+			Tags       []string `json:"tags"`
+			FolderID   *uint    `json:"folder_id"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+
+		bookmark.VerseStart = req.VerseStart
+		bookmark.VerseEnd = req.VerseEnd
+		bookmark.Title = req.Title
+		bookmark.Note = req.Note
+		bookmark.Color = req.Color
+		bookmark.Tags = req.Tags
+		bookmark.FolderID = req.FolderID
+		if !normaliseBookmark(&bookmark) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid verse range"})
+			return
+		}
+		if !checkBookmarkFolder(db, userID, bookmark.FolderID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Folder not found"})
+			return
+		}
+
+		var duplicate int64
+		db.Model(&models.Bookmark{}).
+			Where("user_id = ? AND translation_id = ? AND chapter_id = ? AND verse_start = ? AND verse_end = ? AND bookmark_id <> ?",
+				userID, bookmark.TranslationID, bookmark.ChapterID, bookmark.VerseStart, bookmark.VerseEnd, bookmark.BookmarkID).
+			Count(&duplicate)
+		if duplicate > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "You already have a bookmark here"})
+			return
+		}
+
+		if err := syncWrite(db, userID, models.SyncEntityBookmark, func(tx *gorm.DB) (uint, error) {
+			err := tx.Save(&bookmark).Error
+			return bookmark.BookmarkID, err
+		}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bookmark"})
+			return
+		}
+
+		c.JSON(http.StatusOK, bookmark)
+	}
+}
+
+// reorder sets Position to follow the order of ids, touching only rows the
+// user owns.
+func reorder(c *gin.Context, db *gorm.DB, model interface{}, idColumn, entity string) {
+	userID := c.MustGet("userID").(uint)
+
+	var req struct {
+		IDs []uint `json:"ids"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.IDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ids is required"})
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for i, id := range req.IDs {
+			result := tx.Model(model).
+				Where(idColumn+" = ? AND user_id = ?", id, userID).
+				UpdateColumn("position", i+1)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+			if _, err := recordChange(tx, userID, entity, id, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save order"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order saved"})
+}
+
+func ReorderBookmarks(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reorder(c, db, &models.Bookmark{}, "bookmark_id", models.SyncEntityBookmark)
+	}
+}
+
 func DeleteBookmark(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID := c.MustGet("userID").(uint)
@@ -53,7 +342,9 @@ func DeleteBookmark(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		if err := db.Delete(&bookmark).Error; err != nil {
+		if err := syncDelete(db, userID, models.SyncEntityBookmark, []uint{bookmark.BookmarkID}, func(tx *gorm.DB) error {
+			return tx.Delete(&bookmark).Error
+		}); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete bookmark"})
 			return
 		}
@@ -61,3 +352,108 @@ func DeleteBookmark(db *gorm.DB) gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{"message": "Bookmark deleted successfully"})
 	}
 }
+
+func GetBookmarkFolders(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+
+		var folders []models.BookmarkFolder
+		if err := db.Where("user_id = ?", userID).Order("position ASC, name ASC").Find(&folders).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve folders"})
+			return
+		}
+
+		c.JSON(http.StatusOK, folders)
+	}
+}
+
+func CreateBookmarkFolder(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+
+		var folder models.BookmarkFolder
+		if err := c.ShouldBindJSON(&folder); err != nil || strings.TrimSpace(folder.Name) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Folder name is required"})
+			return
+		}
+
+		folder.FolderID = 0
+		folder.UserID = userID
+		db.Model(&models.BookmarkFolder{}).Where("user_id = ?", userID).Select("COALESCE(MAX(position), 0) + 1").Scan(&folder.Position)
+
+		if err := syncWrite(db, userID, models.SyncEntityBookmarkFolder, func(tx *gorm.DB) (uint, error) {
+			err := tx.Create(&folder).Error
+			return folder.FolderID, err
+		}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create folder"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, folder)
+	}
+}
+
+func UpdateBookmarkFolder(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+
+		var folder models.BookmarkFolder
+		if err := db.First(&folder, "folder_id = ? AND user_id = ?", c.Param("id"), userID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Folder not found"})
+			return
+		}
+
+		var req struct {
+			Name  string `json:"name"`
+			Color string `json:"color"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Folder name is required"})
+			return
+		}
+
+		folder.Name = req.Name
+		folder.Color = req.Color
+		if err := syncWrite(db, userID, models.SyncEntityBookmarkFolder, func(tx *gorm.DB) (uint, error) {
+			err := tx.Save(&folder).Error
+			return folder.FolderID, err
+		}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update folder"})
+			return
+		}
+
+		c.JSON(http.StatusOK, folder)
+	}
+}
+
+// DeleteBookmarkFolder removes a folder. Its bookmarks are kept and become unfiled.
+func DeleteBookmarkFolder(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+
+		var folder models.BookmarkFolder
+		if err := db.First(&folder, "folder_id = ? AND user_id = ?", c.Param("id"), userID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Folder not found"})
+			return
+		}
+
+		err := syncDelete(db, userID, models.SyncEntityBookmarkFolder, []uint{folder.FolderID}, func(tx *gorm.DB) error {
+			if err := unfileBookmarks(tx, userID, folder.FolderID); err != nil {
+				return err
+			}
+			return tx.Delete(&folder).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete folder"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Folder deleted"})
+	}
+}
+
+func ReorderBookmarkFolders(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reorder(c, db, &models.BookmarkFolder{}, "folder_id", models.SyncEntityBookmarkFolder)
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -32,34 +33,48 @@ func GetChurches(db *gorm.DB) gin.HandlerFunc {
 
 func GetChurchDetails(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		churchID := c.Param("id")
+		access := c.MustGet("access").(models.Access)
+
 		var church models.Church
-		if err := db.First(&church, "church_id = ?", churchID).Error; err != nil {
-			church = models.Church{}
+		if err := db.First(&church, "church_id = ?", access.ChurchID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Church not found"})
+			return
 		}
 
-		var groups []models.SmallGroup
-		var events []models.ChurchEvent
-		var messages []models.Message
-		var prayerRequests []models.PrayerRequest
+		groups := []models.SmallGroup{}
+		events := []models.ChurchEvent{}
+		messages := []models.Message{}
+		prayerRequests := []models.PrayerRequest{}
 
-		if err := db.Where("church_id = ?", churchID).Find(&groups).Error; err != nil {
-			log.Printf("Error fetching groups for church %s: %v", churchID, err)
+		// Outsiders only see what the church has marked public
+		scope := func(query *gorm.DB) *gorm.DB {
+			if access.CanReadBoard() {
+				return query
+			}
+			return query.Where("is_public = ?", true)
+		}
+
+		if err := scope(db.Where("church_id = ?", church.ChurchID)).Find(&groups).Error; err != nil {
+			log.Printf("Error fetching groups for church %d: %v", church.ChurchID, err)
 			groups = []models.SmallGroup{}
 		} else {
-			log.Printf("Found %d groups for church %s", len(groups), churchID)
+			log.Printf("Found %d groups for church %d", len(groups), church.ChurchID)
 		}
 
-		if err := db.Where("church_id = ?", churchID).Find(&events).Error; err != nil {
+		if err := scope(db.Where("church_id = ?", church.ChurchID)).Find(&events).Error; err != nil {
 			events = []models.ChurchEvent{}
 		}
 
-		if err := db.Where("church_id = ?", churchID).Find(&messages).Error; err != nil {
+		if err := scope(db.Where("church_id = ? AND parent_message_id IS NULL", church.ChurchID)).Order("is_pinned DESC, created_at DESC").Find(&messages).Error; err != nil {
 			messages = []models.Message{}
 		}
 
-		if err := db.Where("church_id = ?", churchID).Find(&prayerRequests).Error; err != nil {
-			prayerRequests = []models.PrayerRequest{}
+		if access.CanReadBoard() {
+			if err := db.Where("church_id = ? AND status <> ?", church.ChurchID, models.PrayerStatusArchived).Find(&prayerRequests).Error; err != nil {
+				prayerRequests = []models.PrayerRequest{}
+			}
+			viewer := loadPrayerViewer(db, access.UserID, church.ChurchID, 0)
+			prayerRequests = visiblePrayerRequests(viewer, prayerRequests)
 		}
 
 		response := gin.H{
@@ -68,6 +83,8 @@ func GetChurchDetails(db *gorm.DB) gin.HandlerFunc {
 			"events":         events,
 			"messages":       messages,
 			"prayerRequests": prayerRequests,
+			"isMember":       access.IsMember,
+			"isLeader":       access.IsLeader,
 		}
 
 		c.JSON(http.StatusOK, response)
@@ -181,9 +198,13 @@ func DeleteChurch(db *gorm.DB) gin.HandlerFunc {
 
 func GetChurchEvents(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		churchID := c.Param("id")
+		access := c.MustGet("access").(models.Access)
 		var events []models.ChurchEvent
-		if err := db.Where("church_id = ?", churchID).Find(&events).Error; err != nil {
+		query := db.Where("church_id = ?", access.ChurchID)
+		if !access.CanReadBoard() {
+			query = query.Where("is_public = ?", true)
+		}
+		if err := query.Find(&events).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch events"})
 			return
 		}
@@ -209,6 +230,7 @@ func CreateEvent(db *gorm.DB) gin.HandlerFunc {
 
 		event.ChurchID = uint(churchID)
 		event.CreatedBy = userID
+		event.IsPublic = event.IsPublic && c.MustGet("access").(models.Access).IsLeader
 		event.CreatedAt = time.Now()
 		event.UpdatedAt = time.Now()
 
@@ -277,12 +299,12 @@ func DeleteMessage(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		if message.CreatedBy != userID && !user.IsAdmin {
+		if message.CreatedBy != userID && !canModerateMessage(db, user, message) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to delete this message"})
 			return
 		}
 
-		if err := db.Delete(&message).Error; err != nil {
+		if err := deleteMessageThread(db, message); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete message"})
 			return
 		}
@@ -356,13 +378,8 @@ func DeleteEvent(db *gorm.DB) gin.HandlerFunc {
 
 func GetChurchMessages(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		churchID := c.Param("id")
-		var messages []models.Message
-		if err := db.Where("church_id = ?", churchID).Find(&messages).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch messages"})
-			return
-		}
-		c.JSON(http.StatusOK, messages)
+		access := c.MustGet("access").(models.Access)
+		boardMessages(c, db, "church_id", access.ChurchID, !access.CanReadBoard())
 	}
 }
 
@@ -384,9 +401,18 @@ func CreateMessage(db *gorm.DB) gin.HandlerFunc {
 
 		message.ChurchID = uint(churchID)
 		message.CreatedBy = userID
+		message.IsPublic = message.IsPublic && c.MustGet("access").(models.Access).IsLeader
 		message.CreatedAt = time.Now()
 		message.UpdatedAt = time.Now()
 
+		// Replies, pins and edits go through their own endpoints
+		message.ParentMessageID = nil
+		message.StudySessionID = nil
+		message.IsPinned = false
+		message.PinnedBy = 0
+		message.PinnedAt = nil
+		message.EditedAt = nil
+
 		// Get username
 		var user models.User
 		if err := db.First(&user, "user_id = ?", userID).Error; err != nil {
@@ -406,13 +432,17 @@ func CreateMessage(db *gorm.DB) gin.HandlerFunc {
 
 func GetChurchPrayerRequests(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
 		churchID := c.Param("id")
 		var prayerRequests []models.PrayerRequest
-		if err := db.Where("church_id = ?", churchID).Find(&prayerRequests).Error; err != nil {
+		query := filterPrayerStatus(c, db.Where("church_id = ?", churchID))
+		if err := query.Order("created_at DESC").Find(&prayerRequests).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prayer requests"})
 			return
 		}
-		c.JSON(http.StatusOK, prayerRequests)
+
+		viewer := loadPrayerViewer(db, userID, parseUintParam(c, "id"), 0)
+		c.JSON(http.StatusOK, visiblePrayerRequests(viewer, prayerRequests))
 	}
 }
 
@@ -433,20 +463,30 @@ func CreatePrayerRequest(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		prayerRequest.ChurchID = uint(churchID)
-		prayerRequest.CreatedBy = userID
 		prayerRequest.CreatedAt = time.Now()
 		prayerRequest.UpdatedAt = time.Now()
+		prayerRequest.Status = models.PrayerStatusActive
+		prayerRequest.PrayerCount = 0
+		prayerRequest.PraiseReport = ""
+		prayerRequest.AnsweredAt = nil
+		prayerRequest.ArchivedAt = nil
+
+		if prayerRequest.Visibility == "" {
+			prayerRequest.Visibility = models.PrayerVisibilityChurch
+		}
+		if !validPrayerVisibility(prayerRequest.Visibility, false) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid visibility"})
+			return
+		}
 
-		// Get username if not anonymous
-		if !prayerRequest.IsAnonymous {
-			var user models.User
-			if err := db.First(&user, "user_id = ?", userID).Error; err != nil {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		// Anonymous requests don't keep the author's user ID
+		if err := assignPrayerAuthor(db, &prayerRequest, userID); err != nil {
+			if errors.Is(err, errNoPrayerAuthorKey) {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Anonymous requests are not available"})
 				return
 			}
-			prayerRequest.Username = user.Username
-		} else {
-			prayerRequest.Username = "Anonymous"
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return
 		}
 
 		if err := db.Create(&prayerRequest).Error; err != nil {
@@ -454,6 +494,7 @@ func CreatePrayerRequest(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		prayerRequest.IsAuthor = true
 		c.JSON(http.StatusCreated, prayerRequest)
 	}
 }
@@ -633,12 +674,12 @@ func DeleteChurchMessage(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		if message.CreatedBy != userID && !user.IsAdmin {
+		if message.CreatedBy != userID && !canModerateMessage(db, user, message) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to delete this message"})
 			return
 		}
 
-		if err := db.Delete(&message).Error; err != nil {
+		if err := deleteMessageThread(db, message); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete message"})
 			return
 		}
@@ -653,7 +694,7 @@ func DeleteChurchPrayerRequest(db *gorm.DB) gin.HandlerFunc {
 		requestID := c.Param("requestId")
 
 		var request models.PrayerRequest
-		if err := db.First(&request, "prayer_request_id = ?", requestID).Error; err != nil {
+		if err := db.First(&request, "request_id = ?", requestID).Error; err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Prayer request not found"})
 			return
 		}
@@ -664,12 +705,21 @@ func DeleteChurchPrayerRequest(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		if request.CreatedBy != userID && !user.IsAdmin {
+		if !isPrayerAuthor(request, userID) && !loadPrayerViewer(db, userID, request.ChurchID, request.GroupID).IsLeader {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to delete this request"})
 			return
 		}
 
-		if err := db.Delete(&request).Error; err != nil {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("request_id = ?", request.RequestID).Delete(&models.PrayerIntercession{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("request_id = ?", request.RequestID).Delete(&models.PrayerUpdate{}).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&request).Error
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete prayer request"})
 			return
 		}
@@ -0,0 +1,371 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"theword/Backend/lib/bible"
+	"theword/Backend/lib/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/resend/resend-go/v2"
+	"gorm.io/gorm"
+)
+
+const dateLayout = "2006-01-02"
+
+// dailyVerseRotation is the curated list the verse of the day cycles
+// through, one entry a day counted from dailyVerseEpoch. Changing it
+// reshuffles the rotation, so prefer appending.
+var dailyVerseRotation = []string{
+	"JHN.3.16", "ROM.8.28", "PHP.4.13", "JER.29.11", "PRO.3.5-PRO.3.6",
+	"ISA.41.10", "PSA.23.1", "MAT.11.28", "JOS.1.9", "ROM.12.2",
+	"2TI.1.7", "PHP.4.6-PHP.4.7", "PSA.46.1", "ISA.40.31", "MAT.6.33",
+	"ROM.15.13", "1CO.13.4-1CO.13.5", "GAL.5.22-GAL.5.23", "EPH.2.8-EPH.2.9", "HEB.11.1",
+	"2CO.5.17", "PSA.119.105", "LAM.3.22-LAM.3.23", "ZEP.3.17", "MIC.6.8",
+	"1JN.4.19", "JHN.14.27", "JHN.16.33", "ROM.5.8", "ROM.8.38-ROM.8.39",
+	"PSA.27.1", "PSA.34.8", "PSA.37.4", "PSA.55.22", "PSA.91.1-PSA.91.2",
+	"PSA.121.1-PSA.121.2", "PSA.139.14", "PSA.143.8", "PRO.16.3", "PRO.18.10",
+	"ISA.26.3", "ISA.43.2", "ISA.53.5", "ISA.55.8-ISA.55.9", "JER.31.3",
+	"DEU.31.6", "NUM.6.24-NUM.6.26", "1SA.16.7", "2CH.7.14", "NEH.8.10",
+	"MAT.5.14-MAT.5.16", "MAT.5.9", "MAT.6.34", "MAT.7.7", "MAT.22.37-MAT.22.39",
+	"MAT.28.19-MAT.28.20", "MRK.10.27", "MRK.12.30", "LUK.1.37", "LUK.6.31",
+	"JHN.1.5", "JHN.8.12", "JHN.10.10", "JHN.11.25", "JHN.13.34",
+	"JHN.14.6", "JHN.15.5", "JHN.15.13", "ACT.1.8", "ROM.3.23-ROM.3.24",
+	"ROM.6.23", "ROM.10.9", "ROM.12.12", "1CO.10.13", "1CO.16.14",
+	"2CO.4.16-2CO.4.18", "2CO.12.9", "GAL.2.20", "GAL.6.9", "EPH.3.20",
+	"EPH.4.32", "EPH.6.10", "PHP.1.6", "PHP.4.8", "PHP.4.19",
+	"COL.3.2", "COL.3.23", "1TH.5.16-1TH.5.18", "2TI.3.16-2TI.3.17", "HEB.4.16",
+	"HEB.12.1-HEB.12.2", "HEB.13.5", "HEB.13.8", "JAS.1.5", "JAS.1.17",
+	"1PE.5.7", "2PE.3.9", "1JN.1.9", "1JN.4.7-1JN.4.8", "REV.3.20",
+	"REV.21.4", "PSA.16.11", "PSA.30.5", "PSA.62.1-PSA.62.2", "PSA.100.4-PSA.100.5",
+	"PSA.103.2-PSA.103.3", "PSA.118.24", "ISA.9.6", "ISA.12.2", "HAB.3.19",
+	"GEN.1.1", "GEN.28.15", "EXO.14.14", "JOB.19.25", "ECC.3.1",
+	"MAT.19.26", "JHN.6.35", "ROM.8.1", "1CO.15.57", "2CO.9.7",
+	"EPH.2.10", "COL.3.15", "1TI.4.12", "TIT.3.5", "JAS.4.8",
+	"1JN.3.1", "JUD.1.24-JUD.1.25",
+}
+
+var dailyVerseEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// dailyVerse is the verse of the day as shown to one reader.
+type dailyVerse struct {
+	Date          string               `json:"date"`
+	Reference     string               `json:"reference"` // e.g. "John 3:16"
+	VerseID       string               `json:"verse_id"`  // e.g. "JHN.3.16"
+	TranslationID string               `json:"translation_id"`
+	Text          string               `json:"text"`
+	Source        string               `json:"source"` // "rotation" or "church"
+	Note          string               `json:"note,omitempty"`
+	Verses        []bible.PassageVerse `json:"verses"`
+	Attribution   *bible.Attribution   `json:"attribution"`
+}
+
+// rotationVerse picks the curated verse for a calendar day. Every reader
+// whose local date is day gets the same verse.
+func rotationVerse(day time.Time) string {
+	n := daysBetween(dailyVerseEpoch, day) % len(dailyVerseRotation)
+	if n < 0 {
+		n += len(dailyVerseRotation)
+	}
+	return dailyVerseRotation[n]
+}
+
+// userLocation loads a user's time zone, falling back to UTC.
+func userLocation(tz string) *time.Location {
+	if tz != "" {
+		if l, err := time.LoadLocation(tz); err == nil {
+			return l
+		}
+	}
+	return time.UTC
+}
+
+// resolveDailyVerse finds the verse for a church's members on a date, the
+// church's override winning over the rotation, and fetches its text.
+func resolveDailyVerse(ctx context.Context, db *gorm.DB, bibles *bible.Providers, churchID uint, day time.Time, translationID string) (*dailyVerse, error) {
+	v := &dailyVerse{Date: day.Format(dateLayout), TranslationID: translationID, Source: "rotation"}
+	ref := rotationVerse(day)
+	if churchID != 0 {
+		var override models.DailyVerseOverride
+		err := db.Where("church_id = ? AND date = ?", churchID, v.Date).Limit(1).Find(&override).Error
+		if err != nil {
+			return nil, err
+		}
+		if override.OverrideID != 0 {
+			ref, v.Source, v.Note = override.Reference, "church", override.Note
+		}
+	}
+
+	ranges, err := bible.ParseReference(ref)
+	if err != nil {
+		return nil, fmt.Errorf("daily verse %q: %w", ref, err)
+	}
+	passage, err := bibles.Range(ctx, translationID, ranges)
+	if err != nil {
+		return nil, err
+	}
+
+	v.Reference = passage.Reference
+	v.VerseID = bible.FormatReference(ranges, bible.RefID)
+	v.Verses = passage.Verses
+	v.Attribution = passage.Attribution
+	texts := make([]string, len(passage.Verses))
+	for i, verse := range passage.Verses {
+		texts[i] = verse.Text
+	}
+	v.Text = strings.Join(texts, " ")
+	return v, nil
+}
+
+// GetDailyVerse returns today's verse in the reader's time zone and
+// translation. ?tz=, ?translation= and ?date= (YYYY-MM-DD) override them.
+func GetDailyVerse(db *gorm.DB, bibles *bible.Providers) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+		var user models.User
+		if err := db.First(&user, "user_id = ?", userID).Error; err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return
+		}
+
+		day := calendarDay(time.Now().In(userLocation(c.DefaultQuery("tz", user.TimeZone))))
+		if date := c.Query("date"); date != "" {
+			d, err := time.Parse(dateLayout, date)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Date must be YYYY-MM-DD"})
+				return
+			}
+			day = d
+		}
+
+		translationID := c.DefaultQuery("translation", user.TranslationId)
+		if translationID == "" {
+			translationID = "ESV"
+		}
+
+		v, err := resolveDailyVerse(c.Request.Context(), db, bibles, user.ChurchID, day, translationID)
+		if err != nil {
+			bibleError(c, err, "Failed to fetch verse of the day")
+			return
+		}
+		c.JSON(http.StatusOK, v)
+	}
+}
+
+// churchLeader checks that the caller leads the church in :id.
+func churchLeader(c *gin.Context, db *gorm.DB) (uint, bool) {
+	userID := c.MustGet("userID").(uint)
+	churchID := parseUintParam(c, "id")
+
+	var user models.User
+	if err := db.First(&user, "user_id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return 0, false
+	}
+	if !user.IsAdmin || user.ChurchID != churchID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only church leaders can manage the verse of the day"})
+		return 0, false
+	}
+	return churchID, true
+}
+
+// GetChurchDailyVerses lists a church's overrides from ?from= (default
+// today, UTC) onwards, up to ?to= when given.
+func GetChurchDailyVerses(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		churchID, ok := churchLeader(c, db)
+		if !ok {
+			return
+		}
+
+		query := db.Where("church_id = ? AND date >= ?", churchID, c.DefaultQuery("from", time.Now().UTC().Format(dateLayout)))
+		if to := c.Query("to"); to != "" {
+			query = query.Where("date <= ?", to)
+		}
+		overrides := []models.DailyVerseOverride{}
+		if err := query.Order("date").Find(&overrides).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch verses of the day"})
+			return
+		}
+		c.JSON(http.StatusOK, overrides)
+	}
+}
+
+// SetChurchDailyVerse sets the verse of the day for a church on :date.
+func SetChurchDailyVerse(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		churchID, ok := churchLeader(c, db)
+		if !ok {
+			return
+		}
+
+		date := c.Param("date")
+		if _, err := time.Parse(dateLayout, date); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Date must be YYYY-MM-DD"})
+			return
+		}
+
+		var req struct {
+			Reference string `json:"reference" binding:"required"`
+			Note      string `json:"note"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ranges, err := bible.ParseReference(req.Reference)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reference"})
+			return
+		}
+		for _, r := range ranges {
+			if r.StartVerse == 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Verse of the day must be verses, not whole chapters"})
+				return
+			}
+		}
+
+		override := models.DailyVerseOverride{ChurchID: churchID, Date: date}
+		if err := db.Where(override).Limit(1).Find(&override).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set verse of the day"})
+			return
+		}
+		override.Reference = bible.FormatReference(ranges, bible.RefID)
+		override.Note = req.Note
+		override.CreatedBy = c.MustGet("userID").(uint)
+		if err := db.Save(&override).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set verse of the day"})
+			return
+		}
+		c.JSON(http.StatusOK, override)
+	}
+}
+
+// DeleteChurchDailyVerse puts a church back on the rotation for :date.
+func DeleteChurchDailyVerse(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		churchID, ok := churchLeader(c, db)
+		if !ok {
+			return
+		}
+
+		result := db.Where("church_id = ? AND date = ?", churchID, c.Param("date")).Delete(&models.DailyVerseOverride{})
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete verse of the day"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No verse of the day set for this date"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Verse of the day removed"})
+	}
+}
+
+// SendDailyVerseNotifications notifies each opted-in user whose local
+// DailyVerseHour has passed and who has not yet had today's verse. Verses
+// are resolved once per church, day and translation.
+func SendDailyVerseNotifications(db *gorm.DB, bibles *bible.Providers, now time.Time) (int, error) {
+	var users []models.User
+	if err := db.Where("daily_verse_notify = ? OR daily_verse_email = ?", true, true).Find(&users).Error; err != nil {
+		return 0, err
+	}
+
+	resolved := map[string]*dailyVerse{}
+	sent := 0
+	for _, user := range users {
+		local := now.In(userLocation(user.TimeZone))
+		day := calendarDay(local)
+		if user.DailyVerseSentOn == day.Format(dateLayout) || local.Hour() < user.DailyVerseHour {
+			continue
+		}
+
+		translationID := user.TranslationId
+		if translationID == "" {
+			translationID = "ESV"
+		}
+		key := fmt.Sprintf("%d/%s/%s", user.ChurchID, day.Format(dateLayout), translationID)
+		v, ok := resolved[key]
+		if !ok {
+			var err error
+			v, err = resolveDailyVerse(context.Background(), db, bibles, user.ChurchID, day, translationID)
+			if err != nil {
+				log.Printf("Failed to resolve verse of the day for user %d: %v", user.UserID, err)
+				continue
+			}
+			resolved[key] = v
+		}
+
+		if user.DailyVerseNotify {
+			notification := models.Notification{
+				UserID:  user.UserID,
+				Content: fmt.Sprintf("Verse of the day: %s — %s", v.Reference, v.Text),
+			}
+			if err := db.Create(&notification).Error; err != nil {
+				log.Printf("Failed to notify user %d of the verse of the day: %v", user.UserID, err)
+				continue
+			}
+		}
+		if user.DailyVerseEmail && user.Email != "" {
+			if err := sendDailyVerseEmail(user.Email, v); err != nil {
+				log.Printf("Failed to email verse of the day to user %d: %v", user.UserID, err)
+			}
+		}
+
+		if err := db.Model(&user).Update("daily_verse_sent_on", v.Date).Error; err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+func sendDailyVerseEmail(toEmail string, v *dailyVerse) error {
+	if os.Getenv("RESEND_API_KEY") == "" {
+		return errors.New("RESEND_API_KEY is not set")
+	}
+	body := fmt.Sprintf("<p>%s</p><p><strong>%s</strong> (%s)</p>",
+		html.EscapeString(v.Text), html.EscapeString(v.Reference), html.EscapeString(v.TranslationID))
+	text := fmt.Sprintf("%s\n\n%s (%s)", v.Text, v.Reference, v.TranslationID)
+	if v.Attribution != nil && v.Attribution.Copyright != "" {
+		body += "<p><small>" + html.EscapeString(v.Attribution.Copyright) + "</small></p>"
+		text += "\n\n" + v.Attribution.Copyright
+	}
+
+	client := resend.NewClient(os.Getenv("RESEND_API_KEY"))
+	_, err := client.Emails.Send(&resend.SendEmailRequest{
+		From:    os.Getenv("EMAIL_ADDRESS"),
+		To:      []string{toEmail},
+		Subject: "Verse of the day: " + v.Reference,
+		Html:    body,
+		Text:    text,
+	})
+	return err
+}
+
+// StartDailyVerseNotifier sends verse of the day notifications every quarter
+// hour in the background.
+func StartDailyVerseNotifier(db *gorm.DB, bibles *bible.Providers) {
+	go func() {
+		ticker := time.NewTicker(15 * time.Minute)
+		defer ticker.Stop()
+		for ; true; <-ticker.C {
+			n, err := SendDailyVerseNotifications(db, bibles, time.Now())
+			if err != nil {
+				log.Printf("Failed to send verse of the day notifications: %v", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("Sent verse of the day to %d users", n)
+			}
+		}
+	}()
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"theword/Backend/lib/bible"
+	"theword/Backend/lib/models"
+
+	"gorm.io/gorm"
+)
+
+func TestRotationVerse(t *testing.T) {
+	auckland, _ := time.LoadLocation("Pacific/Auckland")
+	losAngeles, _ := time.LoadLocation("America/Los_Angeles")
+	last := len(dailyVerseRotation) - 1
+
+	tests := []struct {
+		name string
+		day  time.Time
+		want string
+	}{
+		{"epoch", dailyVerseEpoch, dailyVerseRotation[0]},
+		{"day after the epoch", dailyVerseEpoch.AddDate(0, 0, 1), dailyVerseRotation[1]},
+		{"day before the epoch", dailyVerseEpoch.AddDate(0, 0, -1), dailyVerseRotation[last]},
+		{"one full rotation on", dailyVerseEpoch.AddDate(0, 0, len(dailyVerseRotation)), dailyVerseRotation[0]},
+		{"one full rotation back", dailyVerseEpoch.AddDate(0, 0, -len(dailyVerseRotation)), dailyVerseRotation[0]},
+		// 2024-01-01 05:00 UTC is the 1st in Auckland but still New Year's
+		// Eve in Los Angeles.
+		{"ahead of UTC", calendarDay(time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC).In(auckland)), dailyVerseRotation[0]},
+		{"behind UTC", calendarDay(time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC).In(losAngeles)), dailyVerseRotation[last]},
+		// 2024-01-01 23:30 in Los Angeles is the 2nd in UTC.
+		{"late evening behind UTC", calendarDay(time.Date(2024, 1, 1, 23, 30, 0, 0, losAngeles)), dailyVerseRotation[0]},
+		{"same instant in UTC", calendarDay(time.Date(2024, 1, 1, 23, 30, 0, 0, losAngeles).UTC()), dailyVerseRotation[1]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rotationVerse(tt.day); got != tt.want {
+				t.Errorf("rotationVerse(%s) = %s, want %s", tt.day.Format(dateLayout), got, tt.want)
+			}
+		})
+	}
+}
+
+// stubBible stands in for API.Bible, serving every chapter with 40 verses
+// whose text is their ID, and counts the chapters fetched.
+func stubBible(t *testing.T, db *gorm.DB) (*bible.Providers, *atomic.Int32) {
+	fetches := &atomic.Int32{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		chapterID := path.Base(r.URL.Path)
+		book, chapter, _ := strings.Cut(chapterID, ".")
+		content := []interface{}{}
+		for n := 1; n <= 40; n++ {
+			id := chapterID + "." + strconv.Itoa(n)
+			content = append(content,
+				map[string]interface{}{"name": "verse", "type": "tag", "attrs": map[string]interface{}{
+					"number": strconv.Itoa(n), "sid": book + " " + chapter + ":" + strconv.Itoa(n)}},
+				map[string]interface{}{"text": id, "type": "text", "attrs": map[string]interface{}{"verseId": id}})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"content": content}})
+	}))
+	t.Cleanup(srv.Close)
+
+	return &bible.Providers{
+		Local:    &bible.Local{DB: db},
+		APIBible: &bible.APIBible{BaseURL: srv.URL, Key: "test", Client: srv.Client()},
+	}, fetches
+}
+
+func TestDailyVerseOverride(t *testing.T) {
+	db := newTestDB(t, &models.BibleTranslation{}, &models.DailyVerseOverride{})
+	bibles, _ := stubBible(t, db)
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	db.Create(&models.DailyVerseOverride{ChurchID: 1, Date: day.Format(dateLayout), Reference: "PSA.23.1-PSA.23.2", Note: "Sermon text"})
+
+	tests := []struct {
+		name     string
+		churchID uint
+		day      time.Time
+		verseID  string
+		source   string
+	}{
+		{"church with an override", 1, day, "PSA.23.1-PSA.23.2", "church"},
+		{"another church", 2, day, rotationVerse(day), "rotation"},
+		{"no church", 0, day, rotationVerse(day), "rotation"},
+		{"override's church, next day", 1, day.AddDate(0, 0, 1), rotationVerse(day.AddDate(0, 0, 1)), "rotation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := resolveDailyVerse(context.Background(), db, bibles, tt.churchID, tt.day, "TST")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v.VerseID != tt.verseID || v.Source != tt.source {
+				t.Errorf("verse %s from %s, want %s from %s", v.VerseID, v.Source, tt.verseID, tt.source)
+			}
+			if tt.source == "church" && (v.Note != "Sermon text" || v.Text != "PSA.23.1 PSA.23.2") {
+				t.Errorf("override note %q, text %q", v.Note, v.Text)
+			}
+		})
+	}
+}
+
+func TestSendDailyVerseNotifications(t *testing.T) {
+	db := newTestDB(t, &models.User{}, &models.Notification{}, &models.BibleTranslation{}, &models.DailyVerseOverride{})
+	bibles, fetches := stubBible(t, db)
+
+	// Two readers in one church, translation and time zone share a verse;
+	// Auckland is 13 hours ahead of UTC in January.
+	db.Create(&[]models.User{
+		{UserID: 1, Email: "a@example.com", ChurchID: 1, TranslationId: "TST", TimeZone: "UTC", DailyVerseNotify: true, DailyVerseHour: 8},
+		{UserID: 2, Email: "b@example.com", ChurchID: 1, TranslationId: "TST", TimeZone: "UTC", DailyVerseNotify: true, DailyVerseHour: 8},
+		{UserID: 3, Email: "c@example.com", ChurchID: 1, TranslationId: "TST", TimeZone: "Pacific/Auckland", DailyVerseNotify: true, DailyVerseHour: 8},
+		{UserID: 4, Email: "d@example.com", ChurchID: 1, TranslationId: "TST", TimeZone: "UTC"},
+	})
+
+	runs := []struct {
+		at       time.Time
+		notified []uint
+		fetches  int32 // chapters fetched by the run
+	}{
+		// Before 08:00 in UTC; 20:00 on the 10th in Auckland.
+		{time.Date(2024, 1, 10, 7, 0, 0, 0, time.UTC), []uint{3}, 1},
+		// Past 08:00 in UTC, and still the 10th in Auckland: the two UTC
+		// readers share one fetch.
+		{time.Date(2024, 1, 10, 9, 0, 0, 0, time.UTC), []uint{1, 2}, 1},
+		{time.Date(2024, 1, 10, 9, 15, 0, 0, time.UTC), nil, 0},
+		// The 11th has begun in Auckland but not in UTC.
+		{time.Date(2024, 1, 10, 23, 0, 0, 0, time.UTC), []uint{3}, 1},
+		{time.Date(2024, 1, 11, 8, 0, 0, 0, time.UTC), []uint{1, 2}, 1},
+	}
+	for _, run := range runs {
+		before := fetches.Load()
+		var seen int64
+		db.Model(&models.Notification{}).Count(&seen)
+
+		n, err := SendDailyVerseNotifications(db, bibles, run.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var notified []uint
+		db.Model(&models.Notification{}).Offset(int(seen)).Order("notification_id").Pluck("user_id", &notified)
+		if n != len(run.notified) || len(notified) != len(run.notified) {
+			t.Errorf("%s: sent %d, notified %v, want %v", run.at.Format(time.RFC3339), n, notified, run.notified)
+			continue
+		}
+		for i := range notified {
+			if notified[i] != run.notified[i] {
+				t.Errorf("%s: notified %v, want %v", run.at.Format(time.RFC3339), notified, run.notified)
+				break
+			}
+		}
+		if got := fetches.Load() - before; got != run.fetches {
+			t.Errorf("%s: fetched %d chapters, want %d", run.at.Format(time.RFC3339), got, run.fetches)
+		}
+	}
+
+	var users []models.User
+	db.Order("user_id").Find(&users)
+	for _, u := range users {
+		want := map[uint]string{1: "2024-01-11", 2: "2024-01-11", 3: "2024-01-11"}[u.UserID]
+		if u.DailyVerseSentOn != want {
+			t.Errorf("user %d DailyVerseSentOn = %q, want %q", u.UserID, u.DailyVerseSentOn, want)
+		}
+	}
+}
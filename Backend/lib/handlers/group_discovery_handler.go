@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"theword/Backend/lib/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// churchGroupsQuery scopes a group listing to the church in the access
+// context, hiding unlisted groups from people outside the church.
+func churchGroupsQuery(db *gorm.DB, access models.Access) *gorm.DB {
+	query := db.Model(&models.SmallGroup{}).Where("church_id = ?", access.ChurchID)
+	if !access.CanReadBoard() {
+		query = query.Where("is_public = ?", true)
+	}
+	return query
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func SearchChurchGroups(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		access := c.MustGet("access").(models.Access)
+		query := churchGroupsQuery(db, access)
+
+		filters := map[string]string{
+			"life_stage":     c.Query("life_stage"),
+			"topic":          c.Query("topic"),
+			"language":       c.Query("language"),
+			"meeting_format": c.Query("format"),
+		}
+		for column, value := range filters {
+			if values := splitList(value); len(values) > 0 {
+				query = query.Where("LOWER("+column+") IN ?", lowerAll(values))
+			}
+		}
+
+		if days := splitList(c.Query("day")); len(days) > 0 {
+			query = query.Where("LOWER(meeting_day) IN ?", lowerAll(days))
+		}
+		if c.Query("childcare") == "true" {
+			query = query.Where("childcare = ?", true)
+		}
+		if c.Query("has_space") == "true" {
+			query = query.Where("max_members = 0 OR member_count < max_members")
+		}
+		if q := strings.TrimSpace(c.Query("q")); q != "" {
+			like := "%" + strings.ToLower(q) + "%"
+			query = query.Where("LOWER(name) LIKE ? OR LOWER(description) LIKE ? OR LOWER(meeting_location) LIKE ?", like, like, like)
+		}
+
+		var groups []models.SmallGroup
+		if err := query.Order("name ASC").Find(&groups).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search groups"})
+			return
+		}
+
+		c.JSON(http.StatusOK, groups)
+	}
+}
+
+func lowerAll(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, v := range values {
+		lowered[i] = strings.ToLower(v)
+	}
+	return lowered
+}
+
+// scoreGroup ranks a group against the user's preferences. Each matching
+// attribute adds weight and explains itself in reasons.
+func scoreGroup(group models.SmallGroup, prefs models.GroupPreference, friends int) (float64, []string) {
+	score := 0.0
+	reasons := []string{}
+
+	if prefs.LifeStage != "" && strings.EqualFold(group.LifeStage, prefs.LifeStage) {
+		score += 3
+		reasons = append(reasons, "Matches your life stage")
+	}
+	if group.Topic != "" && containsFold(splitList(prefs.Topics), group.Topic) {
+		score += 2
+		reasons = append(reasons, "Covers a topic you're interested in")
+	}
+	if prefs.Language != "" && group.Language != "" {
+		if strings.EqualFold(group.Language, prefs.Language) {
+			score += 2
+			reasons = append(reasons, "Meets in your language")
+		} else {
+			score -= 3
+		}
+	}
+	if group.MeetingDay != "" && containsFold(splitList(prefs.MeetingDays), group.MeetingDay) {
+		score += 2
+		reasons = append(reasons, "Meets on a day that suits you")
+	}
+	if prefs.MeetingFormat != "" && (strings.EqualFold(group.MeetingFormat, prefs.MeetingFormat) || group.MeetingFormat == models.MeetingHybrid) {
+		score += 1
+		reasons = append(reasons, "Meets the way you prefer")
+	}
+	if prefs.NeedsChildcare {
+		if group.Childcare {
+			score += 2
+			reasons = append(reasons, "Offers childcare")
+		} else {
+			score -= 2
+		}
+	}
+	if friends > 0 {
+		score += 1.5 * float64(min(friends, 3))
+		reasons = append(reasons, "Friends of yours are in this group")
+	}
+	if group.MaxMembers > 0 && group.MemberCount >= group.MaxMembers {
+		score -= 1
+	}
+
+	return score, reasons
+}
+
+func GetRecommendedGroups(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		access := c.MustGet("access").(models.Access)
+
+		var prefs models.GroupPreference
+		db.First(&prefs, "user_id = ?", access.UserID)
+
+		var groups []models.SmallGroup
+		if err := churchGroupsQuery(db, access).
+			Where("group_id NOT IN (?)", db.Model(&models.GroupMember{}).Select("group_id").Where("user_id = ?", access.UserID)).
+			Find(&groups).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch groups"})
+			return
+		}
+
+		// Count the user's accepted friends in each group
+		var friendCounts []struct {
+			GroupID uint
+			Count   int
+		}
+		db.Raw(`
+		SELECT gm.group_id, COUNT(*) AS count
+		FROM group_members gm
+		JOIN friends f ON (
+			(f.user_id = ? AND f.friend_id = gm.user_id) OR
+			(f.friend_id = ? AND f.user_id = gm.user_id)
+		)
+		WHERE f.status = 'accepted' AND gm.status = ?
+		GROUP BY gm.group_id
+	`, access.UserID, access.UserID, models.GroupMemberActive).Scan(&friendCounts)
+
+		friendsByGroup := make(map[uint]int, len(friendCounts))
+		for _, fc := range friendCounts {
+			friendsByGroup[fc.GroupID] = fc.Count
+		}
+
+		recommendations := make([]models.GroupRecommendation, 0, len(groups))
+		for _, group := range groups {
+			friends := friendsByGroup[group.GroupID]
+			score, reasons := scoreGroup(group, prefs, friends)
+			recommendations = append(recommendations, models.GroupRecommendation{
+				SmallGroup:   group,
+				Score:        score,
+				FriendsCount: friends,
+				Reasons:      reasons,
+			})
+		}
+
+		sort.SliceStable(recommendations, func(i, j int) bool {
+			if recommendations[i].Score != recommendations[j].Score {
+				return recommendations[i].Score > recommendations[j].Score
+			}
+			return recommendations[i].Name < recommendations[j].Name
+		})
+
+		c.JSON(http.StatusOK, recommendations)
+	}
+}
+
+func GetGroupPreferences(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+
+		prefs := models.GroupPreference{UserID: userID}
+		db.First(&prefs, "user_id = ?", userID)
+
+		c.JSON(http.StatusOK, prefs)
+	}
+}
+
+func UpdateGroupPreferences(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+
+		var prefs models.GroupPreference
+		if err := c.ShouldBindJSON(&prefs); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		prefs.UserID = userID
+		prefs.UpdatedAt = time.Now()
+
+		if err := db.Save(&prefs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save group preferences"})
+			return
+		}
+
+		c.JSON(http.StatusOK, prefs)
+	}
+}
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"theword/Backend/lib/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+	errGroupFull     = errors.New("group is full")
+	errAlreadyMember = errors.New("already a member of the group")
+)
+
+// takeGroupSeat claims a spot in the group, failing with errGroupFull when
+// MaxMembers has been reached. The check and increment are one statement so
+// concurrent joins can't overfill the group.
+func takeGroupSeat(tx *gorm.DB, groupID uint) error {
+	result := tx.Model(&models.SmallGroup{}).
+		Where("group_id = ? AND (max_members = 0 OR member_count < max_members)", groupID).
+		UpdateColumn("member_count", gorm.Expr("member_count + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errGroupFull
+	}
+	return nil
+}
+
+// releaseGroupSeat gives a spot back and hands it to the longest-waiting
+// waitlisted member, if any.
+func releaseGroupSeat(tx *gorm.DB, groupID uint) error {
+	if err := tx.Model(&models.SmallGroup{}).
+		Where("group_id = ? AND member_count > 0", groupID).
+		UpdateColumn("member_count", gorm.Expr("member_count - 1")).Error; err != nil {
+		return err
+	}
+
+	var next models.GroupMember
+	if err := tx.Where("group_id = ? AND status = ?", groupID, models.GroupMemberWaitlisted).
+		Order("joined_at ASC").First(&next).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if err := takeGroupSeat(tx, groupID); err != nil {
+		if errors.Is(err, errGroupFull) {
+			return nil
+		}
+		return err
+	}
+	return tx.Model(&next).Updates(map[string]interface{}{
+		"status":    models.GroupMemberActive,
+		"joined_at": time.Now(),
+	}).Error
+}
+
+// syncGroupMemberCount recomputes MemberCount from the active members.
+func syncGroupMemberCount(tx *gorm.DB, groupID uint) error {
+	return tx.Model(&models.SmallGroup{}).Where("group_id = ?", groupID).
+		UpdateColumn("member_count", tx.Model(&models.GroupMember{}).
+			Select("COUNT(*)").
+			Where("group_id = ? AND status = ?", groupID, models.GroupMemberActive)).Error
+}
+
+// RemoveDuplicateGroupMembers keeps the earliest of any memberships a user
+// has twice in one group, so the unique index on GroupMember can be created.
+// It runs before AutoMigrate; SyncGroupMemberCounts fixes the counts after.
+func RemoveDuplicateGroupMembers(db *gorm.DB) {
+	if !db.Migrator().HasTable(&models.GroupMember{}) {
+		return
+	}
+	if err := db.Exec(`DELETE FROM group_members WHERE id NOT IN
+		(SELECT MIN(id) FROM group_members GROUP BY group_id, user_id)`).Error; err != nil {
+		log.Printf("Failed to remove duplicate group members: %v", err)
+	}
+}
+
+// SyncGroupMemberCounts brings every group's MemberCount in line with its
+// active members. Counts weren't maintained before capacity limits existed.
+func SyncGroupMemberCounts(db *gorm.DB) {
+	var groupIDs []uint
+	if err := db.Model(&models.SmallGroup{}).Pluck("group_id", &groupIDs).Error; err != nil {
+		log.Printf("Failed to load groups for member count sync: %v", err)
+		return
+	}
+	for _, id := range groupIDs {
+		if err := syncGroupMemberCount(db, id); err != nil {
+			log.Printf("Failed to sync member count for group %d: %v", id, err)
+		}
+	}
+}
+
+// loadGroupLeaderTarget checks the requester leads the group in the access
+// context and loads the membership named by the :userId param. The group's
+// own leader can only be managed by themselves, not by co-leaders.
+func loadGroupLeaderTarget(c *gin.Context, db *gorm.DB) (models.GroupMember, bool) {
+	access := c.MustGet("access").(models.Access)
+
+	var member models.GroupMember
+	if !access.IsLeader {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only group leaders can manage members"})
+		return member, false
+	}
+
+	if err := db.Where("group_id = ? AND user_id = ?", access.GroupID, c.Param("userId")).First(&member).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return member, false
+	}
+
+	var group models.SmallGroup
+	if err := db.Select("leader_id").First(&group, "group_id = ?", access.GroupID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch group"})
+		return member, false
+	}
+	if member.UserID == group.LeaderID && access.UserID != group.LeaderID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the group leader can change their own membership"})
+		return member, false
+	}
+	return member, true
+}
+
+func GetGroupMembers(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		access := c.MustGet("access").(models.Access)
+
+		// Only leaders see who is pending or waitlisted
+		status := models.GroupMemberActive
+		if access.IsLeader && c.Query("status") != "" {
+			status = c.Query("status")
+		}
+
+		var members []struct {
+			models.GroupMember
+			Username  string `json:"username"`
+			AvatarURL string `json:"avatar_url"`
+		}
+		if err := db.Table("group_members").
+			Select("group_members.*, users.username, users.avatar_url").
+			Joins("JOIN users ON users.user_id = group_members.user_id").
+			Where("group_members.group_id = ? AND group_members.status = ?", access.GroupID, status).
+			Order("group_members.joined_at ASC").
+			Scan(&members).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch members"})
+			return
+		}
+
+		c.JSON(http.StatusOK, members)
+	}
+}
+
+func ApproveGroupMember(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		member, ok := loadGroupLeaderTarget(c, db)
+		if !ok {
+			return
+		}
+
+		if member.Status != models.GroupMemberPending {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Member is not awaiting approval"})
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			status := models.GroupMemberActive
+			if err := takeGroupSeat(tx, member.GroupID); err != nil {
+				if !errors.Is(err, errGroupFull) {
+					return err
+				}
+				status = models.GroupMemberWaitlisted
+			}
+			member.Status = status
+			member.JoinedAt = time.Now()
+			return tx.Save(&member).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve member"})
+			return
+		}
+
+		c.JSON(http.StatusOK, member)
+	}
+}
+
+func RemoveGroupMember(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		member, ok := loadGroupLeaderTarget(c, db)
+		if !ok {
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Delete(&member).Error; err != nil {
+				return err
+			}
+			if member.Status == models.GroupMemberActive {
+				return releaseGroupSeat(tx, member.GroupID)
+			}
+			return nil
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Member removed"})
+	}
+}
+
+func UpdateGroupMemberRole(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		member, ok := loadGroupLeaderTarget(c, db)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Role string `json:"role"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		switch req.Role {
+		case models.GroupRoleMember, models.GroupRoleCoLeader, models.GroupRoleLeader:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+			return
+		}
+
+		if member.Status != models.GroupMemberActive {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Only active members can be promoted"})
+			return
+		}
+
+		if err := db.Model(&member).Update("role", req.Role).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+			return
+		}
+
+		c.JSON(http.StatusOK, member)
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"theword/Backend/lib/middleware"
+	"theword/Backend/lib/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// send makes a request as user with an optional JSON body and returns the
+// status code.
+func send(r http.Handler, method string, user uint, path, body string) int {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("X-User-ID", strconv.Itoa(int(user)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestJoinGroupOnce(t *testing.T) {
+	db := newTestDB(t, &models.User{}, &models.Church{}, &models.SmallGroup{}, &models.GroupMember{})
+	db.Create(&models.SmallGroup{GroupID: 1, Name: "Open", IsPublic: true})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/groups/:id/join", asUser, JoinGroup(db))
+
+	if code := send(r, http.MethodPost, 7, "/api/groups/1/join", ""); code != http.StatusOK {
+		t.Fatalf("first join: %d", code)
+	}
+	if code := send(r, http.MethodPost, 7, "/api/groups/1/join", ""); code != http.StatusBadRequest {
+		t.Errorf("second join: %d, want 400", code)
+	}
+
+	// The index holds even when the existence check is raced.
+	if err := db.Create(&models.GroupMember{GroupID: 1, UserID: 7, Status: models.GroupMemberActive}).Error; err == nil {
+		t.Error("duplicate membership inserted")
+	}
+
+	var group models.SmallGroup
+	db.First(&group, 1)
+	if group.MemberCount != 1 {
+		t.Errorf("MemberCount = %d, want 1", group.MemberCount)
+	}
+}
+
+func TestGroupLeaderProtected(t *testing.T) {
+	const leader, coLeader, member uint = 1, 2, 3
+	db := newTestDB(t, &models.User{}, &models.Church{}, &models.SmallGroup{}, &models.GroupMember{})
+	db.Create(&models.SmallGroup{GroupID: 1, Name: "Study", LeaderID: leader, MemberCount: 3})
+	db.Create(&[]models.GroupMember{
+		{GroupID: 1, UserID: leader, Role: models.GroupRoleLeader, Status: models.GroupMemberActive},
+		{GroupID: 1, UserID: coLeader, Role: models.GroupRoleCoLeader, Status: models.GroupMemberActive},
+		{GroupID: 1, UserID: member, Role: models.GroupRoleMember, Status: models.GroupMemberActive},
+	})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.DELETE("/api/groups/:id/members/:userId", asUser, middleware.GroupAccess(db), RemoveGroupMember(db))
+	r.PUT("/api/groups/:id/members/:userId/role", asUser, middleware.GroupAccess(db), UpdateGroupMemberRole(db))
+
+	if code := send(r, http.MethodPut, coLeader, "/api/groups/1/members/1/role", `{"role":"member"}`); code != http.StatusForbidden {
+		t.Errorf("co-leader demoting the leader: %d, want 403", code)
+	}
+	if code := send(r, http.MethodDelete, coLeader, "/api/groups/1/members/1", ""); code != http.StatusForbidden {
+		t.Errorf("co-leader removing the leader: %d, want 403", code)
+	}
+	if code := send(r, http.MethodPut, coLeader, "/api/groups/1/members/3/role", `{"role":"co_leader"}`); code != http.StatusOK {
+		t.Errorf("co-leader promoting a member: %d, want 200", code)
+	}
+	if code := send(r, http.MethodDelete, leader, "/api/groups/1/members/2", ""); code != http.StatusOK {
+		t.Errorf("leader removing a co-leader: %d, want 200", code)
+	}
+
+	var remaining []models.GroupMember
+	db.Order("user_id").Find(&remaining)
+	if len(remaining) != 2 || remaining[0].Role != models.GroupRoleLeader || remaining[1].Role != models.GroupRoleCoLeader {
+		t.Errorf("members = %+v", remaining)
+	}
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"theword/Backend/lib/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const maxHighlightVerses = 200
+
+// highlightRequest names verses either as a list of verse IDs or as a range
+// within one chapter.
+type highlightRequest struct {
+	TranslationID string   `json:"translation_id"`
+	VerseIDs      []string `json:"verse_ids"`
+	ChapterID     string   `json:"chapter_id"`
+	VerseStart    int      `json:"verse_start"`
+	VerseEnd      int      `json:"verse_end"`
+	Color         *int     `json:"color"` // defaults to the user's HighlightColor
+}
+
+// verses expands the request into normalised verse IDs, keyed to their chapter.
+func (req highlightRequest) verses() (map[string]string, string) {
+	if req.TranslationID == "" {
+		return nil, "translation_id is required"
+	}
+
+	verses := map[string]string{}
+	for _, id := range req.VerseIDs {
+		verseID, chapterID, ok := normaliseVerseReference(id)
+		if !ok {
+			return nil, "Invalid verse ID: " + id
+		}
+		verses[verseID] = chapterID
+	}
+
+	if req.ChapterID != "" {
+		chapterID, ok := normaliseChapterReference(req.ChapterID)
+		end := req.VerseEnd
+		if end == 0 {
+			end = req.VerseStart
+		}
+		if !ok || req.VerseStart < 1 || end < req.VerseStart || end-req.VerseStart >= maxHighlightVerses {
+			return nil, "Invalid verse range"
+		}
+		for v := req.VerseStart; v <= end; v++ {
+			verses[chapterID+"."+strconv.Itoa(v)] = chapterID
+		}
+	}
+
+	if len(verses) == 0 {
+		return nil, "No verses given"
+	}
+	if len(verses) > maxHighlightVerses {
+		return nil, "Too many verses"
+	}
+	return verses, ""
+}
+
+// CreateHighlights highlights one or more verses, recolouring any that are
+// already highlighted.
+func CreateHighlights(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+
+		var req highlightRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		verses, msg := req.verses()
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		color := 0
+		if req.Color != nil {
+			color = *req.Color
+		} else {
+			var user models.User
+			if err := db.First(&user, "user_id = ?", userID).Error; err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+				return
+			}
+			color = user.HighlightColor
+		}
+
+		now := time.Now()
+		highlights := make([]models.Highlight, 0, len(verses))
+		for verseID, chapterID := range verses {
+			highlights = append(highlights, models.Highlight{
+				UserID:        userID,
+				TranslationID: req.TranslationID,
+				ChapterID:     chapterID,
+				VerseID:       verseID,
+				Color:         color,
+				CreatedAt:     now,
+				UpdatedAt:     now,
+			})
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}, {Name: "translation_id"}, {Name: "verse_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"color", "updated_at"}),
+			}).Create(&highlights).Error; err != nil {
+				return err
+			}
+			for _, h := range highlights {
+				if _, err := recordChange(tx, userID, models.SyncEntityHighlight, h.HighlightID, false); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save highlights"})
+			return
+		}
+
+		c.JSON(http.StatusOK, highlights)
+	}
+}
+
+func DeleteHighlights(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+
+		var req highlightRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		verses, msg := req.verses()
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		ids := make([]string, 0, len(verses))
+		for verseID := range verses {
+			ids = append(ids, verseID)
+		}
+
+		var highlightIDs []uint
+		scope := db.Model(&models.Highlight{}).Where("user_id = ? AND translation_id = ? AND verse_id IN ?", userID, req.TranslationID, ids)
+		if err := scope.Pluck("highlight_id", &highlightIDs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete highlights"})
+			return
+		}
+
+		if err := syncDelete(db, userID, models.SyncEntityHighlight, highlightIDs, func(tx *gorm.DB) error {
+			if len(highlightIDs) == 0 {
+				return nil
+			}
+			return tx.Where("highlight_id IN ?", highlightIDs).Delete(&models.Highlight{}).Error
+		}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete highlights"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Highlights removed", "deleted": len(highlightIDs)})
+	}
+}
+
+// GetChapterHighlights returns the user's highlights in a chapter for the
+// translation given in ?translation_id=, so the reader can overlay them.
+func GetChapterHighlights(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+
+		chapterID, ok := normaliseChapterReference(c.Param("chapterId"))
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid chapter ID"})
+			return
+		}
+		translationID := c.Query("translation_id")
+		if translationID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "translation_id is required"})
+			return
+		}
+
+		var highlights []models.Highlight
+		if err := db.Where("user_id = ? AND translation_id = ? AND chapter_id = ?", userID, translationID, chapterID).
+			Find(&highlights).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch highlights"})
+			return
+		}
+
+		c.JSON(http.StatusOK, highlights)
+	}
+}
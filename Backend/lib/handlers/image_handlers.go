@@ -18,6 +18,25 @@ import (
 	"gorm.io/gorm"
 )
 
+// NewWasabiClient connects to the Wasabi bucket store with the WASABI_*
+// settings. Avatars and S3 audio share it.
+func NewWasabiClient() (*s3.S3, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region:           aws.String(os.Getenv("WASABI_REGION")),
+		Endpoint:         aws.String(os.Getenv("WASABI_ENDPOINT")),
+		S3ForcePathStyle: aws.Bool(true),
+		Credentials: credentials.NewStaticCredentials(
+			os.Getenv("WASABI_ACCESS_KEY"),
+			os.Getenv("WASABI_SECRET_KEY"),
+			"",
+		),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s3.New(sess), nil
+}
+
 // Upload user profile avatar
 func UploadUserAvatarHandler(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -88,21 +107,11 @@ func handleAvatarUpload(c *gin.Context, db *gorm.DB, folder, id, table, idColumn
 	extension := filepath.Ext(header.Filename)
 	newKey := fmt.Sprintf("%s/%s-%d%s", folder, id, time.Now().Unix(), extension)
 
-	sess, err := session.NewSession(&aws.Config{
-		Region:           aws.String(os.Getenv("WASABI_REGION")),
-		Endpoint:         aws.String(os.Getenv("WASABI_ENDPOINT")),
-		S3ForcePathStyle: aws.Bool(true),
-		Credentials: credentials.NewStaticCredentials(
-			os.Getenv("WASABI_ACCESS_KEY"),
-			os.Getenv("WASABI_SECRET_KEY"),
-			"",
-		),
-	})
+	svc, err := NewWasabiClient()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to Wasabi"})
 		return
 	}
-	svc := s3.New(sess)
 
 	buf := new(bytes.Buffer)
 	_, err = buf.ReadFrom(file)
@@ -268,23 +277,12 @@ func GetAvatarHandler(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		sess, err := session.NewSession(&aws.Config{
-			Region:           aws.String(os.Getenv("WASABI_REGION")),
-			Endpoint:         aws.String(os.Getenv("WASABI_ENDPOINT")),
-			S3ForcePathStyle: aws.Bool(true),
-			Credentials: credentials.NewStaticCredentials(
-				os.Getenv("WASABI_ACCESS_KEY"),
-				os.Getenv("WASABI_SECRET_KEY"),
-				"",
-			),
-		})
+		svc, err := NewWasabiClient()
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to Wasabi"})
 			return
 		}
 
-		svc := s3.New(sess)
-
 		obj, err := svc.GetObject(&s3.GetObjectInput{
 			Bucket: aws.String(os.Getenv("WASABI_BUCKET")),
 			Key:    aws.String(key),
@@ -0,0 +1,407 @@
+package handlers
+
+import (
+	"net/http"
+	"theword/Backend/lib/middleware"
+	"theword/Backend/lib/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// messageAccess resolves the user's access to the board a message was posted on.
+func messageAccess(db *gorm.DB, userID uint, message models.Message) models.Access {
+	if message.GroupID != 0 {
+		access, _ := middleware.ResolveGroupAccess(db, userID, message.GroupID)
+		return access
+	}
+	return middleware.ResolveChurchAccess(db, userID, message.ChurchID)
+}
+
+// loadReadableMessage fetches the message in the :id param, writing the error
+// response and returning false if the user can't read it. Public posts (and
+// the replies under them) are readable by anyone.
+func loadReadableMessage(c *gin.Context, db *gorm.DB) (models.Message, models.Access, bool) {
+	userID := c.MustGet("userID").(uint)
+
+	var message models.Message
+	if err := db.First(&message, "message_id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+		return message, models.Access{}, false
+	}
+
+	access := messageAccess(db, userID, message)
+	if access.CanReadBoard() {
+		return message, access, true
+	}
+
+	root := message
+	if message.ParentMessageID != nil {
+		db.First(&root, "message_id = ?", *message.ParentMessageID)
+	}
+	if !root.IsPublic {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Members only"})
+		return message, access, false
+	}
+	return message, access, true
+}
+
+// canModerateMessage reports whether the user may pin or remove other
+// people's posts on the board the message belongs to.
+func canModerateMessage(db *gorm.DB, user models.User, message models.Message) bool {
+	return messageAccess(db, user.UserID, message).IsLeader
+}
+
+// withMessageMeta attaches reply counts and reaction tallies to a page of messages.
+func withMessageMeta(db *gorm.DB, messages []models.Message, userID uint) ([]models.MessageWithMeta, error) {
+	result := make([]models.MessageWithMeta, len(messages))
+	if len(messages) == 0 {
+		return result, nil
+	}
+
+	ids := make([]uint, len(messages))
+	for i, m := range messages {
+		ids[i] = m.MessageID
+		result[i] = models.MessageWithMeta{Message: m, Reactions: []models.ReactionCount{}}
+	}
+
+	var replyCounts []struct {
+		ParentMessageID uint
+		Count           int
+	}
+	if err := db.Model(&models.Message{}).
+		Select("parent_message_id, COUNT(*) AS count").
+		Where("parent_message_id IN ?", ids).
+		Group("parent_message_id").
+		Scan(&replyCounts).Error; err != nil {
+		return nil, err
+	}
+
+	var reactionCounts []struct {
+		MessageID uint
+		Emoji     string
+		Count     int
+		Reacted   int
+	}
+	if err := db.Model(&models.MessageReaction{}).
+		Select("message_id, emoji, COUNT(*) AS count, SUM(CASE WHEN user_id = ? THEN 1 ELSE 0 END) AS reacted", userID).
+		Where("message_id IN ?", ids).
+		Group("message_id, emoji").
+		Order("count DESC").
+		Scan(&reactionCounts).Error; err != nil {
+		return nil, err
+	}
+
+	index := make(map[uint]int, len(result))
+	for i, m := range result {
+		index[m.MessageID] = i
+	}
+	for _, rc := range replyCounts {
+		result[index[rc.ParentMessageID]].ReplyCount = rc.Count
+	}
+	for _, rc := range reactionCounts {
+		i := index[rc.MessageID]
+		result[i].Reactions = append(result[i].Reactions, models.ReactionCount{
+			Emoji:   rc.Emoji,
+			Count:   rc.Count,
+			Reacted: rc.Reacted > 0,
+		})
+	}
+
+	return result, nil
+}
+
+// boardMessages lists the top-level posts of a church or group board, pinned
+// first. publicOnly limits the list to posts marked public.
+func boardMessages(c *gin.Context, db *gorm.DB, column string, id uint, publicOnly bool) {
+	userID := c.MustGet("userID").(uint)
+
+	query := db.Where(column+" = ? AND parent_message_id IS NULL AND study_session_id IS NULL", id)
+	if publicOnly {
+		query = query.Where("is_public = ?", true)
+	}
+
+	var messages []models.Message
+	if err := query.Order("is_pinned DESC, pinned_at DESC, created_at DESC").Find(&messages).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch messages"})
+		return
+	}
+
+	result, err := withMessageMeta(db, messages, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch message details"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// deleteMessageThread removes a message together with its replies and reactions.
+func deleteMessageThread(db *gorm.DB, message models.Message) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var replyIDs []uint
+		if err := tx.Model(&models.Message{}).Where("parent_message_id = ?", message.MessageID).Pluck("message_id", &replyIDs).Error; err != nil {
+			return err
+		}
+
+		ids := append(replyIDs, message.MessageID)
+		if err := tx.Where("message_id IN ?", ids).Delete(&models.MessageReaction{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("parent_message_id = ?", message.MessageID).Delete(&models.Message{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&message).Error
+	})
+}
+
+func GetMessageReplies(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+
+		parent, _, ok := loadReadableMessage(c, db)
+		if !ok {
+			return
+		}
+
+		var replies []models.Message
+		if err := db.Where("parent_message_id = ?", parent.MessageID).Order("created_at ASC").Find(&replies).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch replies"})
+			return
+		}
+
+		result, err := withMessageMeta(db, replies, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reply details"})
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+func ReplyToMessage(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+
+		parent, access, ok := loadReadableMessage(c, db)
+		if !ok {
+			return
+		}
+		if !access.CanReadBoard() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only members can reply"})
+			return
+		}
+
+		// Replies are one level deep: replying to a reply threads onto the root post.
+		rootID := parent.MessageID
+		if parent.ParentMessageID != nil {
+			rootID = *parent.ParentMessageID
+		}
+
+		var req struct {
+			Content string `json:"content"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || req.Content == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Reply content is required"})
+			return
+		}
+
+		var user models.User
+		if err := db.First(&user, "user_id = ?", userID).Error; err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return
+		}
+
+		reply := models.Message{
+			Content:         req.Content,
+			ChurchID:        parent.ChurchID,
+			GroupID:         parent.GroupID,
+			ParentMessageID: &rootID,
+			StudySessionID:  parent.StudySessionID,
+			CreatedBy:       userID,
+			Username:        user.Username,
+			CreatedAt:       time.Now(),
+			UpdatedAt:       time.Now(),
+		}
+
+		if err := db.Create(&reply).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reply"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, reply)
+	}
+}
+
+func UpdateMessage(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+		messageID := c.Param("id")
+
+		var message models.Message
+		if err := db.First(&message, "message_id = ?", messageID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+			return
+		}
+
+		if message.CreatedBy != userID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to edit this message"})
+			return
+		}
+
+		var req struct {
+			Title   *string `json:"title"`
+			Content *string `json:"content"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if req.Title != nil {
+			message.Title = *req.Title
+		}
+		if req.Content != nil {
+			message.Content = *req.Content
+		}
+
+		now := time.Now()
+		message.EditedAt = &now
+		message.UpdatedAt = now
+
+		if err := db.Save(&message).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update message"})
+			return
+		}
+
+		c.JSON(http.StatusOK, message)
+	}
+}
+
+func ToggleMessageReaction(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+
+		var req struct {
+			Emoji string `json:"emoji"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || req.Emoji == "" || len(req.Emoji) > 32 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A single emoji is required"})
+			return
+		}
+
+		message, access, ok := loadReadableMessage(c, db)
+		if !ok {
+			return
+		}
+		if !access.CanReadBoard() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only members can react"})
+			return
+		}
+		messageID := message.MessageID
+
+		var reaction models.MessageReaction
+		if err := db.Where("message_id = ? AND user_id = ? AND emoji = ?", messageID, userID, req.Emoji).First(&reaction).Error; err == nil {
+			if err := db.Delete(&reaction).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove reaction"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"message": "Reaction removed"})
+			return
+		}
+
+		reaction = models.MessageReaction{
+			MessageID: messageID,
+			UserID:    userID,
+			Emoji:     req.Emoji,
+			CreatedAt: time.Now(),
+		}
+		if err := db.Create(&reaction).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add reaction"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Reaction added"})
+	}
+}
+
+func GetMessageReactions(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		message, _, ok := loadReadableMessage(c, db)
+		if !ok {
+			return
+		}
+
+		var reactions []struct {
+			models.MessageReaction
+			Username string `json:"username"`
+		}
+		if err := db.Table("message_reactions").
+			Select("message_reactions.*, users.username").
+			Joins("LEFT JOIN users ON users.user_id = message_reactions.user_id").
+			Where("message_reactions.message_id = ?", message.MessageID).
+			Order("message_reactions.created_at ASC").
+			Scan(&reactions).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reactions"})
+			return
+		}
+
+		c.JSON(http.StatusOK, reactions)
+	}
+}
+
+func setMessagePinned(db *gorm.DB, pinned bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+		messageID := c.Param("id")
+
+		var message models.Message
+		if err := db.First(&message, "message_id = ?", messageID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+			return
+		}
+
+		if message.ParentMessageID != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Only top-level messages can be pinned"})
+			return
+		}
+
+		var user models.User
+		if err := db.First(&user, "user_id = ?", userID).Error; err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return
+		}
+
+		if !canModerateMessage(db, user, message) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only leaders can pin messages"})
+			return
+		}
+
+		updates := map[string]interface{}{
+			"is_pinned": pinned,
+			"pinned_by": uint(0),
+			"pinned_at": nil,
+		}
+		if pinned {
+			updates["pinned_by"] = userID
+			updates["pinned_at"] = time.Now()
+		}
+
+		if err := db.Model(&message).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update message"})
+			return
+		}
+
+		c.JSON(http.StatusOK, message)
+	}
+}
+
+func PinMessage(db *gorm.DB) gin.HandlerFunc {
+	return setMessagePinned(db, true)
+}
+
+func UnpinMessage(db *gorm.DB) gin.HandlerFunc {
+	return setMessagePinned(db, false)
+}
@@ -0,0 +1,587 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"theword/Backend/lib/middleware"
+	"theword/Backend/lib/models"
+	"theword/Backend/lib/secrets"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// prayerTokenVersion marks author tokens keyed with PRAYER_AUTHOR_KEY.
+// Version 0 tokens were keyed with the JWT key, which is in the source.
+const prayerTokenVersion = 1
+
+// errNoPrayerAuthorKey is returned for anonymous requests when
+// PRAYER_AUTHOR_KEY is unset: without a secret key an author token could be
+// traced back to its user by anyone reading the table.
+var errNoPrayerAuthorKey = errors.New("PRAYER_AUTHOR_KEY is not set")
+
+// prayerAuthorKey is the secret anonymous author tokens are keyed with. It
+// lives only in the server's environment, never in the database or the
+// source, so reading prayer_requests can't reveal an author.
+func prayerAuthorKey() []byte {
+	return []byte(os.Getenv("PRAYER_AUTHOR_KEY"))
+}
+
+// prayerAuthorToken derives the opaque author marker stored on anonymous
+// requests. Without the key the token can't be traced to a user.
+func prayerAuthorToken(key []byte, salt string, userID uint) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(fmt.Sprintf("%s:%d", salt, userID)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func isPrayerAuthor(request models.PrayerRequest, userID uint) bool {
+	if request.IsAnonymous {
+		key := prayerAuthorKey()
+		if request.AuthorToken == "" || len(key) == 0 || request.TokenVersion != prayerTokenVersion {
+			return false
+		}
+		return hmac.Equal([]byte(request.AuthorToken), []byte(prayerAuthorToken(key, request.AuthorSalt, userID)))
+	}
+	return request.CreatedBy == userID
+}
+
+// assignPrayerAuthor records who wrote the request. Anonymous requests keep
+// only a salted token so the author stays hidden from every query.
+func assignPrayerAuthor(db *gorm.DB, request *models.PrayerRequest, userID uint) error {
+	if !request.IsAnonymous {
+		var user models.User
+		if err := db.First(&user, "user_id = ?", userID).Error; err != nil {
+			return err
+		}
+		request.CreatedBy = userID
+		request.Username = user.Username
+		request.AuthorSalt = ""
+		request.AuthorToken = ""
+		request.TokenVersion = 0
+		return nil
+	}
+
+	key := prayerAuthorKey()
+	if len(key) == 0 {
+		return errNoPrayerAuthorKey
+	}
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return err
+	}
+	request.CreatedBy = 0
+	request.Username = "Anonymous"
+	request.NotifyOnPrayer = false // there is nobody we could notify
+	request.AuthorSalt = hex.EncodeToString(buf)
+	request.AuthorToken = prayerAuthorToken(key, request.AuthorSalt, userID)
+	request.TokenVersion = prayerTokenVersion
+	return nil
+}
+
+// validPrayerVisibility checks a visibility level against the board a
+// request is posted to; church boards have no "group" level.
+func validPrayerVisibility(visibility string, groupBoard bool) bool {
+	switch visibility {
+	case models.PrayerVisibilityLeaders, models.PrayerVisibilityPrayerTeam:
+		return true
+	case models.PrayerVisibilityChurch:
+		return !groupBoard
+	case models.PrayerVisibilityGroup:
+		return groupBoard
+	}
+	return false
+}
+
+type prayerViewer struct {
+	models.Access
+	IsPrayerTeam bool
+}
+
+// loadPrayerViewer works out what the user may see on a church board
+// (groupID == 0) or a group board.
+func loadPrayerViewer(db *gorm.DB, userID, churchID, groupID uint) prayerViewer {
+	var access models.Access
+	if groupID != 0 {
+		access, _ = middleware.ResolveGroupAccess(db, userID, groupID)
+	} else {
+		access = middleware.ResolveChurchAccess(db, userID, churchID)
+	}
+
+	viewer := prayerViewer{Access: access}
+
+	var user models.User
+	if err := db.First(&user, "user_id = ?", userID).Error; err == nil && access.ChurchID != 0 && user.ChurchID == access.ChurchID {
+		viewer.IsPrayerTeam = user.IsPrayerTeam
+	}
+	return viewer
+}
+
+func (v prayerViewer) canView(request models.PrayerRequest) bool {
+	if isPrayerAuthor(request, v.UserID) {
+		return true
+	}
+	if !v.CanReadBoard() {
+		return false
+	}
+	switch request.Visibility {
+	case models.PrayerVisibilityLeaders:
+		return v.IsLeader
+	case models.PrayerVisibilityPrayerTeam:
+		return v.IsLeader || v.IsPrayerTeam
+	}
+	return true
+}
+
+// visiblePrayerRequests drops the requests the viewer isn't allowed to read
+// and flags the ones they wrote.
+func visiblePrayerRequests(viewer prayerViewer, requests []models.PrayerRequest) []models.PrayerRequest {
+	visible := []models.PrayerRequest{}
+	for _, r := range requests {
+		if !viewer.canView(r) {
+			continue
+		}
+		r.IsAuthor = isPrayerAuthor(r, viewer.UserID)
+		visible = append(visible, r)
+	}
+	return visible
+}
+
+// loadVisiblePrayerRequest fetches a request by the :id param, writing the
+// error response and returning false if it's missing or hidden from the user.
+func loadVisiblePrayerRequest(c *gin.Context, db *gorm.DB) (models.PrayerRequest, bool) {
+	userID := c.MustGet("userID").(uint)
+
+	var request models.PrayerRequest
+	if err := db.First(&request, "request_id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Prayer request not found"})
+		return request, false
+	}
+
+	viewer := loadPrayerViewer(db, userID, request.ChurchID, request.GroupID)
+	if !viewer.canView(request) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Prayer request not found"})
+		return request, false
+	}
+
+	request.IsAuthor = isPrayerAuthor(request, userID)
+	return request, true
+}
+
+func parseUintParam(c *gin.Context, name string) uint {
+	id, _ := strconv.ParseUint(c.Param(name), 10, 32)
+	return uint(id)
+}
+
+// filterPrayerStatus applies the ?status= query to a prayer request listing.
+// Archived requests are hidden unless asked for; "all" disables the filter.
+func filterPrayerStatus(c *gin.Context, query *gorm.DB) *gorm.DB {
+	status := c.Query("status")
+	switch status {
+	case "":
+		return query.Where("status <> ?", models.PrayerStatusArchived)
+	case "all":
+		return query
+	default:
+		return query.Where("status IN ?", strings.Split(status, ","))
+	}
+}
+
+func PrayForRequest(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+
+		request, ok := loadVisiblePrayerRequest(c, db)
+		if !ok {
+			return
+		}
+
+		// One tap per person per day keeps the count meaningful
+		since := time.Now().Add(-24 * time.Hour)
+		var recent int64
+		db.Model(&models.PrayerIntercession{}).
+			Where("request_id = ? AND user_id = ? AND created_at > ?", request.RequestID, userID, since).
+			Count(&recent)
+		if recent > 0 {
+			c.JSON(http.StatusOK, gin.H{"message": "Already prayed today", "prayer_count": request.PrayerCount})
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&models.PrayerIntercession{
+				RequestID: request.RequestID,
+				UserID:    userID,
+				CreatedAt: time.Now(),
+			}).Error; err != nil {
+				return err
+			}
+			return tx.Model(&models.PrayerRequest{}).
+				Where("request_id = ?", request.RequestID).
+				UpdateColumn("prayer_count", gorm.Expr("prayer_count + 1")).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record prayer"})
+			return
+		}
+
+		if request.NotifyOnPrayer && request.CreatedBy != 0 && request.CreatedBy != userID {
+			notification := models.Notification{
+				UserID:          request.CreatedBy,
+				Content:         "Someone prayed for your request.",
+				PrayerRequestID: &request.RequestID,
+			}
+			if err := db.Create(&notification).Error; err != nil {
+				log.Printf("Failed to create prayer notification: %v", err)
+			}
+		}
+
+		var intercessors int64
+		db.Model(&models.PrayerIntercession{}).Where("request_id = ?", request.RequestID).Distinct("user_id").Count(&intercessors)
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":      "Prayer recorded",
+			"prayer_count": request.PrayerCount + 1,
+			"intercessors": intercessors,
+		})
+	}
+}
+
+func GetPrayerUpdates(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		request, ok := loadVisiblePrayerRequest(c, db)
+		if !ok {
+			return
+		}
+
+		var updates []models.PrayerUpdate
+		if err := db.Where("request_id = ?", request.RequestID).Order("created_at ASC").Find(&updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prayer updates"})
+			return
+		}
+
+		c.JSON(http.StatusOK, updates)
+	}
+}
+
+func AddPrayerUpdate(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+		requestID := c.Param("id")
+
+		var request models.PrayerRequest
+		if err := db.First(&request, "request_id = ?", requestID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Prayer request not found"})
+			return
+		}
+
+		if !isPrayerAuthor(request, userID) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only the author can post updates"})
+			return
+		}
+
+		var req struct {
+			Content string `json:"content"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || req.Content == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Update content is required"})
+			return
+		}
+
+		update := models.PrayerUpdate{
+			RequestID: request.RequestID,
+			Content:   req.Content,
+			CreatedBy: request.CreatedBy, // stays 0 for anonymous requests
+			CreatedAt: time.Now(),
+		}
+		if err := db.Create(&update).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create prayer update"})
+			return
+		}
+
+		// An update counts as activity, so it resets the archive clock
+		updates := map[string]interface{}{"updated_at": time.Now()}
+		if request.Status == models.PrayerStatusArchived {
+			updates["status"] = models.PrayerStatusActive
+			updates["archived_at"] = nil
+		}
+		db.Model(&request).Updates(updates)
+
+		c.JSON(http.StatusCreated, update)
+	}
+}
+
+func UpdatePrayerRequest(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+		requestID := c.Param("id")
+
+		var request models.PrayerRequest
+		if err := db.First(&request, "request_id = ?", requestID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Prayer request not found"})
+			return
+		}
+
+		if !isPrayerAuthor(request, userID) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to edit this request"})
+			return
+		}
+
+		var req struct {
+			Content        *string `json:"content"`
+			Visibility     *string `json:"visibility"`
+			IsAnonymous    *bool   `json:"is_anonymous"`
+			NotifyOnPrayer *bool   `json:"notify_on_prayer"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if req.Content != nil {
+			request.Content = *req.Content
+		}
+		if req.Visibility != nil {
+			if !validPrayerVisibility(*req.Visibility, request.GroupID != 0) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid visibility"})
+				return
+			}
+			request.Visibility = *req.Visibility
+		}
+		if req.NotifyOnPrayer != nil {
+			request.NotifyOnPrayer = *req.NotifyOnPrayer
+		}
+		if req.IsAnonymous != nil && *req.IsAnonymous != request.IsAnonymous {
+			request.IsAnonymous = *req.IsAnonymous
+			if err := assignPrayerAuthor(db, &request, userID); err != nil {
+				if errors.Is(err, errNoPrayerAuthorKey) {
+					c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Anonymous requests are not available"})
+					return
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update prayer request"})
+				return
+			}
+			if err := db.Model(&models.PrayerUpdate{}).Where("request_id = ?", request.RequestID).
+				Update("created_by", request.CreatedBy).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update prayer request"})
+				return
+			}
+		}
+
+		request.UpdatedAt = time.Now()
+		if err := db.Save(&request).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update prayer request"})
+			return
+		}
+
+		request.IsAuthor = true
+		c.JSON(http.StatusOK, request)
+	}
+}
+
+func AddPrayerTeamMember(db *gorm.DB) gin.HandlerFunc {
+	return setPrayerTeamMember(db, true)
+}
+
+func RemovePrayerTeamMember(db *gorm.DB) gin.HandlerFunc {
+	return setPrayerTeamMember(db, false)
+}
+
+func setPrayerTeamMember(db *gorm.DB, member bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+		churchID := parseUintParam(c, "id")
+
+		var user models.User
+		if err := db.First(&user, "user_id = ?", userID).Error; err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return
+		}
+
+		if !user.IsAdmin || user.ChurchID != churchID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only church leaders can manage the prayer team"})
+			return
+		}
+
+		var target models.User
+		if err := db.First(&target, "user_id = ? AND church_id = ?", c.Param("userId"), churchID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User is not a member of this church"})
+			return
+		}
+
+		if err := db.Model(&target).Update("is_prayer_team", member).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update prayer team"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Prayer team updated"})
+	}
+}
+
+func GetPrayerTeam(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		churchID := c.Param("id")
+
+		var team []models.UserResponse
+		if err := db.Model(&models.User{}).
+			Select("user_id, username, church_id").
+			Where("church_id = ? AND is_prayer_team = ?", churchID, true).
+			Scan(&team).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prayer team"})
+			return
+		}
+
+		c.JSON(http.StatusOK, team)
+	}
+}
+
+func MarkPrayerAnswered(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+		requestID := c.Param("id")
+
+		var request models.PrayerRequest
+		if err := db.First(&request, "request_id = ?", requestID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Prayer request not found"})
+			return
+		}
+
+		if !isPrayerAuthor(request, userID) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only the author can mark a request answered"})
+			return
+		}
+
+		var req struct {
+			PraiseReport string `json:"praise_report"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		now := time.Now()
+		request.Status = models.PrayerStatusAnswered
+		request.PraiseReport = req.PraiseReport
+		request.AnsweredAt = &now
+		request.ArchivedAt = nil
+		request.UpdatedAt = now
+
+		if err := db.Save(&request).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update prayer request"})
+			return
+		}
+
+		request.IsAuthor = true
+		c.JSON(http.StatusOK, request)
+	}
+}
+
+// AnonymizePrayerRequests moves anonymous requests written before author
+// tokens existed off CreatedBy, so old rows are as private as new ones, and
+// re-keys tokens made with the old, public key. It does nothing without
+// PRAYER_AUTHOR_KEY.
+func AnonymizePrayerRequests(db *gorm.DB) {
+	if len(prayerAuthorKey()) == 0 {
+		log.Printf("Anonymous prayer requests are disabled: %v", errNoPrayerAuthorKey)
+		return
+	}
+
+	var requests []models.PrayerRequest
+	if err := db.Where("is_anonymous = ? AND created_by <> 0", true).Find(&requests).Error; err != nil {
+		log.Printf("Failed to load anonymous prayer requests: %v", err)
+		return
+	}
+	for _, request := range requests {
+		author := request.CreatedBy
+		if err := assignPrayerAuthor(db, &request, author); err != nil {
+			log.Printf("Failed to anonymize prayer request %d: %v", request.RequestID, err)
+			continue
+		}
+		savePrayerAuthor(db, &request)
+		db.Model(&models.PrayerUpdate{}).Where("request_id = ?", request.RequestID).Update("created_by", 0)
+	}
+
+	var legacy []models.PrayerRequest
+	err := db.Where("is_anonymous = ? AND author_token <> '' AND token_version < ?", true, prayerTokenVersion).
+		Find(&legacy).Error
+	if err != nil || len(legacy) == 0 {
+		if err != nil {
+			log.Printf("Failed to load prayer requests to re-key: %v", err)
+		}
+		return
+	}
+	var userIDs []uint
+	if err := db.Model(&models.User{}).Pluck("user_id", &userIDs).Error; err != nil {
+		log.Printf("Failed to load users to re-key prayer requests: %v", err)
+		return
+	}
+	for _, request := range legacy {
+		author := legacyPrayerAuthor(request, userIDs)
+		if author == 0 {
+			// The author is gone; keep no token anyone could match.
+			request.AuthorSalt, request.AuthorToken, request.TokenVersion = "", "", prayerTokenVersion
+		} else if err := assignPrayerAuthor(db, &request, author); err != nil {
+			log.Printf("Failed to re-key prayer request %d: %v", request.RequestID, err)
+			continue
+		}
+		savePrayerAuthor(db, &request)
+	}
+	log.Printf("Re-keyed %d anonymous prayer requests", len(legacy))
+}
+
+// legacyPrayerAuthor finds which user a token keyed with the old JWT key
+// belongs to, or 0.
+func legacyPrayerAuthor(request models.PrayerRequest, userIDs []uint) uint {
+	for _, id := range userIDs {
+		if hmac.Equal([]byte(request.AuthorToken), []byte(prayerAuthorToken(secrets.JwtKey, request.AuthorSalt, id))) {
+			return id
+		}
+	}
+	return 0
+}
+
+func savePrayerAuthor(db *gorm.DB, request *models.PrayerRequest) {
+	db.Model(request).
+		Select("created_by", "username", "notify_on_prayer", "author_salt", "author_token", "token_version").
+		Updates(request)
+}
+
+// ArchiveStalePrayerRequests archives requests with no activity for longer
+// than maxAge and returns how many were archived.
+func ArchiveStalePrayerRequests(db *gorm.DB, maxAge time.Duration) (int64, error) {
+	now := time.Now()
+	result := db.Model(&models.PrayerRequest{}).
+		Where("status IN ? AND updated_at < ?", []string{models.PrayerStatusActive, models.PrayerStatusAnswered}, now.Add(-maxAge)).
+		Updates(map[string]interface{}{
+			"status":      models.PrayerStatusArchived,
+			"archived_at": now,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// StartPrayerArchiver runs ArchiveStalePrayerRequests once an hour.
+func StartPrayerArchiver(db *gorm.DB, maxAge time.Duration) {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for ; true; <-ticker.C {
+			n, err := ArchiveStalePrayerRequests(db, maxAge)
+			if err != nil {
+				log.Printf("Failed to archive prayer requests: %v", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("Archived %d prayer requests", n)
+			}
+		}
+	}()
+}
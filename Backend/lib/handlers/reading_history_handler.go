@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"theword/Backend/lib/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Reads of the same chapter closer together than this count as one sitting.
+const readingSessionGap = 30 * time.Minute
+
+func RecordChapterRead(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+
+		var req struct {
+			TranslationID   string  `json:"translation_id"`
+			TranslationName string  `json:"translation_name"`
+			ChapterID       string  `json:"chapter_id"`
+			Verse           int     `json:"verse"`
+			ScrollOffset    float64 `json:"scroll_offset"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		chapterID, ok := normaliseChapterReference(req.ChapterID)
+		if !ok || req.TranslationID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "translation_id and a valid chapter_id are required"})
+			return
+		}
+
+		now := time.Now()
+		var read models.ChapterRead
+		err := db.Where("user_id = ?", userID).Order("read_at DESC").First(&read).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record reading"})
+			return
+		}
+
+		sameSitting := err == nil &&
+			read.TranslationID == req.TranslationID &&
+			read.ChapterID == chapterID &&
+			now.Sub(read.ReadAt) < readingSessionGap
+		if !sameSitting {
+			read = models.ChapterRead{
+				UserID:          userID,
+				TranslationID:   req.TranslationID,
+				TranslationName: req.TranslationName,
+				BookID:          strings.SplitN(chapterID, ".", 2)[0],
+				ChapterID:       chapterID,
+			}
+		}
+		read.Verse = req.Verse
+		read.ScrollOffset = req.ScrollOffset
+		read.ReadAt = now
+
+		if err := db.Save(&read).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record reading"})
+			return
+		}
+
+		c.JSON(http.StatusOK, read)
+	}
+}
+
+func GetReadingHistory(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit < 1 || limit > 200 {
+			limit = 50
+		}
+
+		query := db.Where("user_id = ?", userID)
+		if translationID := c.Query("translation_id"); translationID != "" {
+			query = query.Where("translation_id = ?", translationID)
+		}
+		if before := c.Query("before"); before != "" {
+			t, err := time.Parse(time.RFC3339, before)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "before must be an RFC 3339 timestamp"})
+				return
+			}
+			query = query.Where("read_at < ?", t)
+		}
+
+		var history []models.ChapterRead
+		if err := query.Order("read_at DESC").Limit(limit).Find(&history).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reading history"})
+			return
+		}
+
+		c.JSON(http.StatusOK, history)
+	}
+}
+
+func ClearReadingHistory(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+
+		if err := db.Where("user_id = ?", userID).Delete(&models.ChapterRead{}).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear reading history"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Reading history cleared"})
+	}
+}
+
+// GetResumePositions returns the last place read in each translation, most
+// recent first, or just the one asked for with ?translation_id=.
+func GetResumePositions(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+
+		latest := db.Model(&models.ChapterRead{}).
+			Select("MAX(read_id)").
+			Where("user_id = ?", userID).
+			Group("translation_id")
+		query := db.Where("read_id IN (?)", latest)
+		if translationID := c.Query("translation_id"); translationID != "" {
+			query = query.Where("translation_id = ?", translationID)
+		}
+
+		var positions []models.ChapterRead
+		if err := query.Order("read_at DESC").Find(&positions).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch resume position"})
+			return
+		}
+
+		c.JSON(http.StatusOK, positions)
+	}
+}
+
+// GetReadingStats reports how much of the Bible the user has read across all
+// translations, overall and per book.
+func GetReadingStats(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+
+		var perBook []struct {
+			BookID string
+			Count  int
+		}
+		if err := db.Model(&models.ChapterRead{}).
+			Select("book_id, COUNT(DISTINCT chapter_id) AS count").
+			Where("user_id = ?", userID).
+			Group("book_id").
+			Scan(&perBook).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reading stats"})
+			return
+		}
+		read := make(map[string]int, len(perBook))
+		for _, b := range perBook {
+			read[b.BookID] = b.Count
+		}
+
+		stats := models.ReadingStats{Books: []models.BookReadingStats{}}
+		db.Model(&models.ChapterRead{}).Where("user_id = ?", userID).Count(&stats.TotalReads)
+
+		for _, book := range bibleBooks {
+			stats.TotalChapters += book.Chapters
+			stats.ChaptersRead += read[book.USFM]
+			if read[book.USFM] == 0 {
+				continue
+			}
+			stats.Books = append(stats.Books, models.BookReadingStats{
+				BookID:        book.USFM,
+				ChaptersRead:  read[book.USFM],
+				TotalChapters: book.Chapters,
+				Percent:       percentOf(read[book.USFM], book.Chapters),
+			})
+		}
+		stats.Percent = percentOf(stats.ChaptersRead, stats.TotalChapters)
+
+		c.JSON(http.StatusOK, stats)
+	}
+}
+
+func percentOf(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part*1000/whole) / 10
+}
@@ -0,0 +1,637 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"theword/Backend/lib/bible"
+	"theword/Backend/lib/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// splitReadings spreads refs as evenly as possible over the given number of days.
+func splitReadings(refs []string, days int) []models.ReadingPlanDay {
+	plan := make([]models.ReadingPlanDay, days)
+	for i := range plan {
+		plan[i] = models.ReadingPlanDay{
+			Day:        i + 1,
+			References: refs[i*len(refs)/days : (i+1)*len(refs)/days],
+		}
+	}
+	return plan
+}
+
+func builtInReadingPlans() []models.ReadingPlan {
+	var nt []bible.BookInfo
+	for _, b := range bibleBooks {
+		if b.Testament == bible.NewTestament {
+			nt = append(nt, b)
+		}
+	}
+
+	psalms, _ := findBibleBook("PSA")
+	monthly := splitReadings(bookChapters([]bible.BookInfo{psalms}), 31)
+	for i := range monthly {
+		monthly[i].References = append(monthly[i].References, "PRO."+strconv.Itoa(i+1))
+	}
+
+	return []models.ReadingPlan{
+		{
+			Slug:        "bible-in-a-year",
+			Title:       "Bible in a Year",
+			Description: "Read the whole Bible, Genesis to Revelation, in 365 days.",
+			Days:        splitReadings(bookChapters(bibleBooks), 365),
+		},
+		{
+			Slug:        "nt-90-days",
+			Title:       "New Testament in 90 Days",
+			Description: "Read through the New Testament in three months.",
+			Days:        splitReadings(bookChapters(nt), 90),
+		},
+		{
+			Slug:        "psalms-proverbs-monthly",
+			Title:       "Psalms & Proverbs Monthly",
+			Description: "All 150 psalms and a chapter of Proverbs each day for a month.",
+			Days:        monthly,
+		},
+	}
+}
+
+// SeedReadingPlans creates any built-in plans missing from the database.
+func SeedReadingPlans(db *gorm.DB) {
+	for _, plan := range builtInReadingPlans() {
+		var count int64
+		db.Model(&models.ReadingPlan{}).Where("slug = ? AND is_built_in = ?", plan.Slug, true).Count(&count)
+		if count > 0 {
+			continue
+		}
+		plan.IsBuiltIn = true
+		plan.TotalDays = len(plan.Days)
+		if err := db.Create(&plan).Error; err != nil {
+			log.Printf("Failed to seed reading plan %s: %v", plan.Slug, err)
+		}
+	}
+}
+
+// readingToday is the user's current calendar day. Clients pass their IANA
+// zone in ?tz= so streaks follow local midnight; UTC is the fallback.
+func readingToday(c *gin.Context) time.Time {
+	loc := time.UTC
+	if tz := c.Query("tz"); tz != "" {
+		if l, err := time.LoadLocation(tz); err == nil {
+			loc = l
+		}
+	}
+	return calendarDay(time.Now().In(loc))
+}
+
+func calendarDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(calendarDay(to).Sub(calendarDay(from)).Hours() / 24)
+}
+
+// summariseEnrollment works out where a reader is in their plan as of today.
+func summariseEnrollment(e models.ReadingPlanEnrollment, plan models.ReadingPlan, completed map[int]bool, today time.Time) models.ReadingPlanSummary {
+	summary := models.ReadingPlanSummary{
+		ReadingPlanEnrollment: e,
+		Title:                 plan.Title,
+		TotalDays:             plan.TotalDays,
+		CompletedDays:         len(completed),
+		CurrentDay:            min(daysBetween(e.StartDate, today)+1, plan.TotalDays),
+	}
+	if summary.CurrentDay < 0 {
+		summary.CurrentDay = 0
+	}
+
+	for day := 1; day <= plan.TotalDays; day++ {
+		if completed[day] {
+			continue
+		}
+		if summary.NextDay == 0 {
+			summary.NextDay = day
+		}
+		if day < summary.CurrentDay {
+			summary.DaysBehind++
+		}
+	}
+
+	// A streak survives until a whole day passes without reading.
+	if e.LastReadOn == nil || daysBetween(*e.LastReadOn, today) > 1 {
+		summary.CurrentStreak = 0
+	}
+	return summary
+}
+
+func completedReadingDays(db *gorm.DB, enrollmentIDs []uint) map[uint]map[int]bool {
+	var progress []models.ReadingPlanProgress
+	db.Where("enrollment_id IN ?", enrollmentIDs).Find(&progress)
+
+	completed := make(map[uint]map[int]bool, len(enrollmentIDs))
+	for _, id := range enrollmentIDs {
+		completed[id] = map[int]bool{}
+	}
+	for _, p := range progress {
+		completed[p.EnrollmentID][p.Day] = true
+	}
+	return completed
+}
+
+func loadEnrollment(c *gin.Context, db *gorm.DB) (models.ReadingPlanEnrollment, models.ReadingPlan, bool) {
+	userID := c.MustGet("userID").(uint)
+
+	var enrollment models.ReadingPlanEnrollment
+	var plan models.ReadingPlan
+	if err := db.Where("enrollment_id = ? AND user_id = ?", c.Param("enrollmentId"), userID).First(&enrollment).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reading plan not found"})
+		return enrollment, plan, false
+	}
+	if err := db.First(&plan, "plan_id = ?", enrollment.PlanID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reading plan not found"})
+		return enrollment, plan, false
+	}
+	return enrollment, plan, true
+}
+
+func deleteReadingEnrollments(tx *gorm.DB, query *gorm.DB) error {
+	var ids []uint
+	if err := query.Model(&models.ReadingPlanEnrollment{}).Pluck("enrollment_id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := tx.Where("enrollment_id IN ?", ids).Delete(&models.ReadingPlanProgress{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("enrollment_id IN ?", ids).Delete(&models.ReadingPlanEnrollment{}).Error
+}
+
+func GetReadingPlans(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+
+		var plans []models.ReadingPlan
+		if err := db.Where("is_built_in = ? OR created_by = ?", true, userID).
+			Order("is_built_in DESC, created_at ASC").
+			Find(&plans).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reading plans"})
+			return
+		}
+
+		c.JSON(http.StatusOK, plans)
+	}
+}
+
+func GetReadingPlan(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var plan models.ReadingPlan
+		if err := db.Preload("Days", func(tx *gorm.DB) *gorm.DB { return tx.Order("day ASC") }).
+			First(&plan, "plan_id = ?", c.Param("id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Reading plan not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, plan)
+	}
+}
+
+// CreateReadingPlan builds a custom plan either from an explicit day-by-day
+// schedule or by spreading whole books over a number of days.
+func CreateReadingPlan(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+
+		var req struct {
+			Title       string     `json:"title"`
+			Description string     `json:"description"`
+			Schedule    [][]string `json:"schedule"`
+			Books       []string   `json:"books"`
+			TotalDays   int        `json:"total_days"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if strings.TrimSpace(req.Title) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Title is required"})
+			return
+		}
+
+		plan := models.ReadingPlan{
+			Title:       req.Title,
+			Description: req.Description,
+			CreatedBy:   userID,
+		}
+
+		switch {
+		case len(req.Schedule) > 0:
+			for i, refs := range req.Schedule {
+				day := models.ReadingPlanDay{Day: i + 1, References: []string{}}
+				for _, ref := range refs {
+					normalised, ok := normaliseChapterReference(ref)
+					if !ok {
+						c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid chapter reference: " + ref})
+						return
+					}
+					day.References = append(day.References, normalised)
+				}
+				plan.Days = append(plan.Days, day)
+			}
+		case len(req.Books) > 0:
+			var books []bible.BookInfo
+			for _, id := range req.Books {
+				book, ok := findBibleBook(strings.ToUpper(strings.TrimSpace(id)))
+				if !ok {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown book: " + id})
+					return
+				}
+				books = append(books, book)
+			}
+			chapters := bookChapters(books)
+			if req.TotalDays < 1 || req.TotalDays > len(chapters) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "total_days must be between 1 and the number of chapters"})
+				return
+			}
+			plan.Days = splitReadings(chapters, req.TotalDays)
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Provide a schedule or a list of books"})
+			return
+		}
+		plan.TotalDays = len(plan.Days)
+
+		if err := db.Create(&plan).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reading plan"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, plan)
+	}
+}
+
+func DeleteReadingPlan(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+
+		var plan models.ReadingPlan
+		if err := db.First(&plan, "plan_id = ?", c.Param("id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Reading plan not found"})
+			return
+		}
+		if plan.IsBuiltIn || plan.CreatedBy != userID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You can only delete your own plans"})
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := deleteReadingEnrollments(tx, tx.Where("plan_id = ?", plan.PlanID)); err != nil {
+				return err
+			}
+			if err := tx.Where("plan_id = ?", plan.PlanID).Delete(&models.ReadingPlanDay{}).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&plan).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete reading plan"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Reading plan deleted"})
+	}
+}
+
+// JoinReadingPlan enrolls the user in a plan. Passing friend_id starts the
+// plan on the same schedule as that friend so they read together.
+func JoinReadingPlan(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+
+		var plan models.ReadingPlan
+		if err := db.First(&plan, "plan_id = ?", c.Param("id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Reading plan not found"})
+			return
+		}
+
+		var req struct {
+			StartDate string `json:"start_date"` // YYYY-MM-DD, defaults to today
+			FriendID  uint   `json:"friend_id"`
+		}
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		var existing models.ReadingPlanEnrollment
+		if err := db.Where("user_id = ? AND plan_id = ? AND completed_at IS NULL", userID, plan.PlanID).First(&existing).Error; err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "You are already following this plan"})
+			return
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join reading plan"})
+			return
+		}
+
+		start := readingToday(c)
+		if req.StartDate != "" {
+			parsed, err := time.Parse("2006-01-02", req.StartDate)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "start_date must be YYYY-MM-DD"})
+				return
+			}
+			start = parsed
+		}
+		if req.FriendID != 0 {
+			if !areFriends(db, userID, req.FriendID) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "You can only read along with friends"})
+				return
+			}
+			var theirs models.ReadingPlanEnrollment
+			if err := db.Where("user_id = ? AND plan_id = ?", req.FriendID, plan.PlanID).Order("created_at DESC").First(&theirs).Error; err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Your friend isn't following this plan"})
+				return
+			}
+			start = theirs.StartDate
+		}
+
+		enrollment := models.ReadingPlanEnrollment{
+			UserID:    userID,
+			PlanID:    plan.PlanID,
+			StartDate: start,
+		}
+		if err := db.Create(&enrollment).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join reading plan"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, summariseEnrollment(enrollment, plan, map[int]bool{}, readingToday(c)))
+	}
+}
+
+func areFriends(db *gorm.DB, userID, otherID uint) bool {
+	var count int64
+	db.Model(&models.Friend{}).
+		Where("status = 'accepted' AND ((user_id = ? AND friend_id = ?) OR (user_id = ? AND friend_id = ?))", userID, otherID, otherID, userID).
+		Count(&count)
+	return count > 0
+}
+
+func GetMyReadingPlans(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+
+		var enrollments []models.ReadingPlanEnrollment
+		if err := db.Where("user_id = ?", userID).Order("completed_at IS NOT NULL, created_at DESC").Find(&enrollments).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reading plans"})
+			return
+		}
+
+		ids := make([]uint, len(enrollments))
+		planIDs := make([]uint, len(enrollments))
+		for i, e := range enrollments {
+			ids[i] = e.EnrollmentID
+			planIDs[i] = e.PlanID
+		}
+
+		var plans []models.ReadingPlan
+		db.Where("plan_id IN ?", planIDs).Find(&plans)
+		plansByID := make(map[uint]models.ReadingPlan, len(plans))
+		for _, p := range plans {
+			plansByID[p.PlanID] = p
+		}
+
+		completed := completedReadingDays(db, ids)
+		today := readingToday(c)
+		summaries := make([]models.ReadingPlanSummary, len(enrollments))
+		for i, e := range enrollments {
+			summaries[i] = summariseEnrollment(e, plansByID[e.PlanID], completed[e.EnrollmentID], today)
+		}
+
+		c.JSON(http.StatusOK, summaries)
+	}
+}
+
+func GetMyReadingPlan(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		enrollment, plan, ok := loadEnrollment(c, db)
+		if !ok {
+			return
+		}
+
+		var days []models.ReadingPlanDay
+		if err := db.Where("plan_id = ?", plan.PlanID).Order("day ASC").Find(&days).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch readings"})
+			return
+		}
+
+		completed := completedReadingDays(db, []uint{enrollment.EnrollmentID})[enrollment.EnrollmentID]
+		schedule := make([]models.ReadingPlanDayStatus, len(days))
+		for i, d := range days {
+			schedule[i] = models.ReadingPlanDayStatus{
+				ReadingPlanDay: d,
+				DueOn:          enrollment.StartDate.AddDate(0, 0, d.Day-1),
+				Completed:      completed[d.Day],
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"summary":  summariseEnrollment(enrollment, plan, completed, readingToday(c)),
+			"schedule": schedule,
+		})
+	}
+}
+
+func LeaveReadingPlan(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		enrollment, _, ok := loadEnrollment(c, db)
+		if !ok {
+			return
+		}
+
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			return deleteReadingEnrollments(tx, tx.Where("enrollment_id = ?", enrollment.EnrollmentID))
+		}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to leave reading plan"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Left reading plan"})
+	}
+}
+
+func loadReadingDay(c *gin.Context, plan models.ReadingPlan) (int, bool) {
+	day, err := strconv.Atoi(c.Param("day"))
+	if err != nil || day < 1 || day > plan.TotalDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid day"})
+		return 0, false
+	}
+	return day, true
+}
+
+func CompleteReadingDay(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		enrollment, plan, ok := loadEnrollment(c, db)
+		if !ok {
+			return
+		}
+		day, ok := loadReadingDay(c, plan)
+		if !ok {
+			return
+		}
+
+		today := readingToday(c)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			progress := models.ReadingPlanProgress{EnrollmentID: enrollment.EnrollmentID, Day: day}
+			if err := tx.Where(progress).Attrs(models.ReadingPlanProgress{CompletedAt: time.Now()}).FirstOrCreate(&progress).Error; err != nil {
+				return err
+			}
+
+			// Reading on consecutive calendar days builds the streak
+			switch {
+			case enrollment.LastReadOn != nil && daysBetween(*enrollment.LastReadOn, today) == 0:
+			case enrollment.LastReadOn != nil && daysBetween(*enrollment.LastReadOn, today) == 1:
+				enrollment.CurrentStreak++
+			default:
+				enrollment.CurrentStreak = 1
+			}
+			enrollment.LongestStreak = max(enrollment.LongestStreak, enrollment.CurrentStreak)
+			enrollment.LastReadOn = &today
+
+			var done int64
+			if err := tx.Model(&models.ReadingPlanProgress{}).Where("enrollment_id = ?", enrollment.EnrollmentID).Count(&done).Error; err != nil {
+				return err
+			}
+			if int(done) >= plan.TotalDays && enrollment.CompletedAt == nil {
+				now := time.Now()
+				enrollment.CompletedAt = &now
+			}
+			return tx.Save(&enrollment).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save progress"})
+			return
+		}
+
+		completed := completedReadingDays(db, []uint{enrollment.EnrollmentID})[enrollment.EnrollmentID]
+		c.JSON(http.StatusOK, summariseEnrollment(enrollment, plan, completed, today))
+	}
+}
+
+func UncompleteReadingDay(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		enrollment, plan, ok := loadEnrollment(c, db)
+		if !ok {
+			return
+		}
+		day, ok := loadReadingDay(c, plan)
+		if !ok {
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("enrollment_id = ? AND day = ?", enrollment.EnrollmentID, day).Delete(&models.ReadingPlanProgress{}).Error; err != nil {
+				return err
+			}
+			enrollment.CompletedAt = nil
+			return tx.Save(&enrollment).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save progress"})
+			return
+		}
+
+		completed := completedReadingDays(db, []uint{enrollment.EnrollmentID})[enrollment.EnrollmentID]
+		c.JSON(http.StatusOK, summariseEnrollment(enrollment, plan, completed, readingToday(c)))
+	}
+}
+
+// CatchUpReadingPlan reschedules the plan so the first unread day falls on
+// today, clearing the backlog of missed days.
+func CatchUpReadingPlan(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		enrollment, plan, ok := loadEnrollment(c, db)
+		if !ok {
+			return
+		}
+
+		today := readingToday(c)
+		completed := completedReadingDays(db, []uint{enrollment.EnrollmentID})[enrollment.EnrollmentID]
+		summary := summariseEnrollment(enrollment, plan, completed, today)
+		if summary.NextDay == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Reading plan is already complete"})
+			return
+		}
+
+		enrollment.StartDate = today.AddDate(0, 0, -(summary.NextDay - 1))
+		if err := db.Model(&enrollment).Update("start_date", enrollment.StartDate).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reschedule reading plan"})
+			return
+		}
+
+		c.JSON(http.StatusOK, summariseEnrollment(enrollment, plan, completed, today))
+	}
+}
+
+// GetReadingPlanFriends lists the user's friends following the same plan and
+// how far along they are.
+func GetReadingPlanFriends(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+
+		var plan models.ReadingPlan
+		if err := db.First(&plan, "plan_id = ?", c.Param("id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Reading plan not found"})
+			return
+		}
+
+		var rows []struct {
+			models.ReadingPlanEnrollment
+			Username  string
+			AvatarURL string
+		}
+		if err := db.Table("reading_plan_enrollments").
+			Select("reading_plan_enrollments.*, users.username, users.avatar_url").
+			Joins("JOIN users ON users.user_id = reading_plan_enrollments.user_id").
+			Where("reading_plan_enrollments.plan_id = ?", plan.PlanID).
+			Where(`reading_plan_enrollments.user_id IN (
+				SELECT friend_id FROM friends WHERE user_id = ? AND status = 'accepted'
+				UNION
+				SELECT user_id FROM friends WHERE friend_id = ? AND status = 'accepted'
+			)`, userID, userID).
+			Scan(&rows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch friends"})
+			return
+		}
+
+		ids := make([]uint, len(rows))
+		for i, r := range rows {
+			ids[i] = r.EnrollmentID
+		}
+		completed := completedReadingDays(db, ids)
+		today := readingToday(c)
+
+		friends := make([]models.FriendReadingProgress, len(rows))
+		for i, r := range rows {
+			summary := summariseEnrollment(r.ReadingPlanEnrollment, plan, completed[r.EnrollmentID], today)
+			friends[i] = models.FriendReadingProgress{
+				UserID:        r.UserID,
+				Username:      r.Username,
+				AvatarURL:     r.AvatarURL,
+				CompletedDays: summary.CompletedDays,
+				CurrentStreak: summary.CurrentStreak,
+				DaysBehind:    summary.DaysBehind,
+			}
+		}
+
+		c.JSON(http.StatusOK, friends)
+	}
+}
@@ -1,20 +1,23 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"theword/Backend/lib/middleware"
 	"theword/Backend/lib/models"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func GetChurchGroups(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		churchID := c.Param("id")
+		access := c.MustGet("access").(models.Access)
 		var groups []models.SmallGroup
-		if err := db.Where("church_id = ?", churchID).Find(&groups).Error; err != nil {
+		if err := churchGroupsQuery(db, access).Find(&groups).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch groups"})
 			return
 		}
@@ -24,41 +27,44 @@ func GetChurchGroups(db *gorm.DB) gin.HandlerFunc {
 
 func GetGroupDetails(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		groupID := c.Param("id")
-		userID := c.MustGet("userID").(uint)
+		access := c.MustGet("access").(models.Access)
 
 		var group models.SmallGroup
-		if err := db.First(&group, "group_id = ?", groupID).Error; err != nil {
+		if err := db.First(&group, "group_id = ?", access.GroupID).Error; err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
 			return
 		}
 
-		// Get associated data
-		var events []models.ChurchEvent
-		var messages []models.Message
-		var prayerRequests []models.PrayerRequest
-
-		db.Where("group_id = ?", groupID).Find(&events)
-		db.Where("group_id = ?", groupID).Find(&messages)
-		db.Where("group_id = ?", groupID).Find(&prayerRequests)
-
-		// Check if the user is a member
-		var isMember bool
-		var member models.GroupMember
-		if err := db.Where("group_id = ? AND user_id = ?", groupID, userID).First(&member).Error; err == nil {
-			isMember = true
+		// People from the group's church can see the group so they can join it;
+		// everyone else only sees groups listed publicly.
+		churchMember := middleware.ResolveChurchAccess(db, access.UserID, group.ChurchID).IsMember
+		if !access.CanReadBoard() && !churchMember && !group.IsPublic {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Members only"})
+			return
 		}
 
-		// Check if the user is the leader
-		isLeader := group.LeaderID == userID
+		// Get associated data
+		events := []models.ChurchEvent{}
+		messages := []models.Message{}
+		prayerRequests := []models.PrayerRequest{}
+
+		if access.CanReadBoard() {
+			db.Where("group_id = ?", group.GroupID).Find(&events)
+			db.Where("group_id = ? AND parent_message_id IS NULL AND study_session_id IS NULL", group.GroupID).Order("is_pinned DESC, created_at DESC").Find(&messages)
+			db.Where("group_id = ? AND status <> ?", group.GroupID, models.PrayerStatusArchived).Find(&prayerRequests)
+			prayerRequests = visiblePrayerRequests(loadPrayerViewer(db, access.UserID, 0, group.GroupID), prayerRequests)
+		} else {
+			db.Where("group_id = ? AND is_public = ?", group.GroupID, true).Find(&events)
+			db.Where("group_id = ? AND parent_message_id IS NULL AND study_session_id IS NULL AND is_public = ?", group.GroupID, true).Order("is_pinned DESC, created_at DESC").Find(&messages)
+		}
 
 		response := gin.H{
 			"group":          group,
 			"events":         events,
 			"messages":       messages,
 			"prayerRequests": prayerRequests,
-			"isMember":       isMember,
-			"isLeader":       isLeader,
+			"isMember":       access.IsMember,
+			"isLeader":       access.IsLeader,
 		}
 
 		c.JSON(http.StatusOK, response)
@@ -94,10 +100,31 @@ func CreateGroup(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		group.ChurchID = uint(churchID)
+		group.MemberCount = 0
 		group.CreatedAt = time.Now()
 		group.UpdatedAt = time.Now()
 
-		if err := db.Create(&group).Error; err != nil {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&group).Error; err != nil {
+				return err
+			}
+			if group.LeaderID == 0 {
+				return nil
+			}
+			// The named leader is the group's first member
+			if err := tx.Create(&models.GroupMember{
+				GroupID:  group.GroupID,
+				UserID:   group.LeaderID,
+				Role:     models.GroupRoleLeader,
+				Status:   models.GroupMemberActive,
+				JoinedAt: time.Now(),
+			}).Error; err != nil {
+				return err
+			}
+			group.MemberCount = 1
+			return tx.Model(&group).UpdateColumn("member_count", 1).Error
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create group"})
 			return
 		}
@@ -129,11 +156,14 @@ func UpdateGroup(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		memberCount := group.MemberCount
 		if err := c.ShouldBindJSON(&group); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
+		// MemberCount is maintained by joins and leaves only
+		group.MemberCount = memberCount
 		group.UpdatedAt = time.Now()
 
 		if err := db.Save(&group).Error; err != nil {
@@ -162,7 +192,16 @@ func DeleteGroup(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		if err := db.Delete(&models.SmallGroup{}, "group_id = ?", groupID).Error; err != nil {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("group_id = ?", groupID).Delete(&models.GroupMember{}).Error; err != nil {
+				return err
+			}
+			if err := deleteGroupStudyPlans(tx, groupID); err != nil {
+				return err
+			}
+			return tx.Delete(&models.SmallGroup{}, "group_id = ?", groupID).Error
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete group"})
 			return
 		}
@@ -173,9 +212,13 @@ func DeleteGroup(db *gorm.DB) gin.HandlerFunc {
 
 func GetGroupEvents(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		groupID := c.Param("id")
+		access := c.MustGet("access").(models.Access)
 		var events []models.ChurchEvent
-		if err := db.Where("group_id = ?", groupID).Find(&events).Error; err != nil {
+		query := db.Where("group_id = ?", access.GroupID)
+		if !access.CanReadBoard() {
+			query = query.Where("is_public = ?", true)
+		}
+		if err := query.Find(&events).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch events"})
 			return
 		}
@@ -200,20 +243,30 @@ func CreateGroupPrayerRequest(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		prayerRequest.GroupID = uint(groupID)
-		prayerRequest.CreatedBy = userID
 		prayerRequest.CreatedAt = time.Now()
 		prayerRequest.UpdatedAt = time.Now()
+		prayerRequest.Status = models.PrayerStatusActive
+		prayerRequest.PrayerCount = 0
+		prayerRequest.PraiseReport = ""
+		prayerRequest.AnsweredAt = nil
+		prayerRequest.ArchivedAt = nil
 
-		// Get username if not anonymous
-		if !prayerRequest.IsAnonymous {
-			var user models.User
-			if err := db.First(&user, "user_id = ?", userID).Error; err != nil {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		if prayerRequest.Visibility == "" {
+			prayerRequest.Visibility = models.PrayerVisibilityGroup
+		}
+		if !validPrayerVisibility(prayerRequest.Visibility, true) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid visibility"})
+			return
+		}
+
+		// Anonymous requests don't keep the author's user ID
+		if err := assignPrayerAuthor(db, &prayerRequest, userID); err != nil {
+			if errors.Is(err, errNoPrayerAuthorKey) {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Anonymous requests are not available"})
 				return
 			}
-			prayerRequest.Username = user.Username
-		} else {
-			prayerRequest.Username = "Anonymous"
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return
 		}
 
 		if err := db.Create(&prayerRequest).Error; err != nil {
@@ -221,6 +274,7 @@ func CreateGroupPrayerRequest(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		prayerRequest.IsAuthor = true
 		c.JSON(http.StatusCreated, prayerRequest)
 	}
 
@@ -228,25 +282,24 @@ func CreateGroupPrayerRequest(db *gorm.DB) gin.HandlerFunc {
 
 func GetGroupPrayerRequests(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
 		groupID := c.Param("id")
 		var prayerRequests []models.PrayerRequest
-		if err := db.Where("group_id = ?", groupID).Find(&prayerRequests).Error; err != nil {
+		query := filterPrayerStatus(c, db.Where("group_id = ?", groupID))
+		if err := query.Order("created_at DESC").Find(&prayerRequests).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prayer requests"})
 			return
 		}
-		c.JSON(http.StatusOK, prayerRequests)
+
+		viewer := loadPrayerViewer(db, userID, 0, parseUintParam(c, "id"))
+		c.JSON(http.StatusOK, visiblePrayerRequests(viewer, prayerRequests))
 	}
 }
 
 func GetGroupMessages(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		groupID := c.Param("id")
-		var messages []models.Message
-		if err := db.Where("group_id = ?", groupID).Find(&messages).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch messages"})
-			return
-		}
-		c.JSON(http.StatusOK, messages)
+		access := c.MustGet("access").(models.Access)
+		boardMessages(c, db, "group_id", access.GroupID, !access.CanReadBoard())
 	}
 }
 
@@ -268,9 +321,18 @@ func CreateGroupMessage(db *gorm.DB) gin.HandlerFunc {
 
 		message.GroupID = uint(groupID)
 		message.CreatedBy = userID
+		message.IsPublic = message.IsPublic && c.MustGet("access").(models.Access).IsLeader
 		message.CreatedAt = time.Now()
 		message.UpdatedAt = time.Now()
 
+		// Replies, pins and edits go through their own endpoints
+		message.ParentMessageID = nil
+		message.StudySessionID = nil
+		message.IsPinned = false
+		message.PinnedBy = 0
+		message.PinnedAt = nil
+		message.EditedAt = nil
+
 		// Get username
 		var user models.User
 		if err := db.First(&user, "user_id = ?", userID).Error; err != nil {
@@ -298,27 +360,66 @@ func JoinGroup(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		var group models.SmallGroup
+		if err := db.First(&group, "group_id = ?", groupID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+			return
+		}
+
 		// Check if already a member
 		var existing models.GroupMember
 		err = db.Where("group_id = ? AND user_id = ?", groupID, userID).First(&existing).Error
 		if err == nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Already a member"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Already a member", "status": existing.Status})
 			return
 		}
 
 		member := models.GroupMember{
-			GroupID:  uint(groupID),
+			GroupID:  group.GroupID,
 			UserID:   userID,
-			Role:     "member",
+			Role:     models.GroupRoleMember,
+			Status:   models.GroupMemberPending,
 			JoinedAt: time.Now(),
 		}
 
-		if err := db.Create(&member).Error; err != nil {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if !group.RequireApproval {
+				member.Status = models.GroupMemberActive
+				if err := takeGroupSeat(tx, group.GroupID); err != nil {
+					if !errors.Is(err, errGroupFull) {
+						return err
+					}
+					member.Status = models.GroupMemberWaitlisted
+				}
+			}
+			// A concurrent join may have got in first; rolling back returns
+			// the seat taken above.
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&member)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errAlreadyMember
+			}
+			return nil
+		})
+		if errors.Is(err, errAlreadyMember) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Already a member"})
+			return
+		}
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join group"})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": "Successfully joined group"})
+		switch member.Status {
+		case models.GroupMemberPending:
+			c.JSON(http.StatusAccepted, gin.H{"message": "Join request sent to the group leaders", "status": member.Status})
+		case models.GroupMemberWaitlisted:
+			c.JSON(http.StatusAccepted, gin.H{"message": "Group is full, you've been added to the waitlist", "status": member.Status})
+		default:
+			c.JSON(http.StatusOK, gin.H{"message": "Successfully joined group", "status": member.Status})
+		}
 	}
 }
 
@@ -332,7 +433,22 @@ func LeaveGroup(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		if err := db.Where("group_id = ? AND user_id = ?", groupID, userID).Delete(&models.GroupMember{}).Error; err != nil {
+		var member models.GroupMember
+		if err := db.Where("group_id = ? AND user_id = ?", groupID, userID).First(&member).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Not a member of this group"})
+			return
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Delete(&member).Error; err != nil {
+				return err
+			}
+			if member.Status == models.GroupMemberActive {
+				return releaseGroupSeat(tx, member.GroupID)
+			}
+			return nil
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to leave group"})
 			return
 		}
@@ -352,15 +468,13 @@ func CreateGroupEvent(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Allow church-admins or the group leader only
+		// Allow church-admins or group leaders only
 		var group models.SmallGroup
 		if err := db.First(&group, "group_id = ?", groupID).Error; err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
 			return
 		}
-		var user models.User
-		db.First(&user, "user_id = ?", userID)
-		if !(user.IsAdmin || group.LeaderID == userID) {
+		if !c.MustGet("access").(models.Access).IsLeader {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized"})
 			return
 		}
@@ -372,6 +486,7 @@ func CreateGroupEvent(db *gorm.DB) gin.HandlerFunc {
 		}
 		ev.GroupID = uint(groupID)
 		ev.ChurchID = group.ChurchID // keep linkage
+		ev.CreatedBy = userID
 		ev.CreatedAt = time.Now()
 		ev.UpdatedAt = time.Now()
 
@@ -400,12 +515,12 @@ func DeleteGroupMessage(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		if message.CreatedBy != userID && !user.IsAdmin {
+		if message.CreatedBy != userID && !canModerateMessage(db, user, message) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to delete this message"})
 			return
 		}
 
-		if err := db.Delete(&message).Error; err != nil {
+		if err := deleteMessageThread(db, message); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete message"})
 			return
 		}
@@ -451,7 +566,7 @@ func DeleteGroupPrayerRequest(db *gorm.DB) gin.HandlerFunc {
 		requestID := c.Param("requestId")
 
 		var request models.PrayerRequest
-		if err := db.First(&request, "prayer_request_id = ?", requestID).Error; err != nil {
+		if err := db.First(&request, "request_id = ?", requestID).Error; err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Prayer request not found"})
 			return
 		}
@@ -462,12 +577,21 @@ func DeleteGroupPrayerRequest(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		if request.CreatedBy != userID && !user.IsAdmin {
+		if !isPrayerAuthor(request, userID) && !loadPrayerViewer(db, userID, request.ChurchID, request.GroupID).IsLeader {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to delete this request"})
 			return
 		}
 
-		if err := db.Delete(&request).Error; err != nil {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("request_id = ?", request.RequestID).Delete(&models.PrayerIntercession{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("request_id = ?", request.RequestID).Delete(&models.PrayerUpdate{}).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&request).Error
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete prayer request"})
 			return
 		}
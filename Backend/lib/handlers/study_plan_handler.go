@@ -0,0 +1,511 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"theword/Backend/lib/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type studySessionRequest struct {
+	Title        string    `json:"title"`
+	Reference    string    `json:"reference"`
+	ScheduledFor time.Time `json:"scheduled_for"`
+	Questions    []string  `json:"questions"`
+}
+
+// apply validates the request and copies it onto the session.
+func (req studySessionRequest) apply(session *models.StudySession) bool {
+	ref, ok := normaliseChapterReference(req.Reference)
+	if !ok {
+		return false
+	}
+	session.Title = req.Title
+	session.Reference = ref
+	session.ScheduledFor = req.ScheduledFor
+	session.Questions = []string{}
+	for _, q := range req.Questions {
+		if q = strings.TrimSpace(q); q != "" {
+			session.Questions = append(session.Questions, q)
+		}
+	}
+	return true
+}
+
+func requireGroupLeader(c *gin.Context) (models.Access, bool) {
+	access := c.MustGet("access").(models.Access)
+	if !access.IsLeader {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only group leaders can manage study plans"})
+		return access, false
+	}
+	return access, true
+}
+
+func loadStudyPlan(c *gin.Context, db *gorm.DB, groupID uint) (models.StudyPlan, bool) {
+	var plan models.StudyPlan
+	if err := db.Where("plan_id = ? AND group_id = ?", c.Param("planId"), groupID).First(&plan).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Study plan not found"})
+		return plan, false
+	}
+	return plan, true
+}
+
+func loadStudySession(c *gin.Context, db *gorm.DB, groupID uint) (models.StudySession, bool) {
+	var session models.StudySession
+	if err := db.Where("session_id = ? AND group_id = ?", c.Param("sessionId"), groupID).First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Study session not found"})
+		return session, false
+	}
+	return session, true
+}
+
+// deleteStudySessions removes sessions along with their progress and
+// discussion threads.
+func deleteStudySessions(tx *gorm.DB, sessionIDs []uint) error {
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+	if err := tx.Where("session_id IN ?", sessionIDs).Delete(&models.StudyProgress{}).Error; err != nil {
+		return err
+	}
+	messageIDs := tx.Model(&models.Message{}).Select("message_id").Where("study_session_id IN ?", sessionIDs)
+	if err := tx.Where("message_id IN (?)", messageIDs).Delete(&models.MessageReaction{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("study_session_id IN ?", sessionIDs).Delete(&models.Message{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("session_id IN ?", sessionIDs).Delete(&models.StudySession{}).Error
+}
+
+// deleteGroupStudyPlans removes every study plan belonging to a group.
+func deleteGroupStudyPlans(tx *gorm.DB, groupID string) error {
+	var sessionIDs []uint
+	if err := tx.Model(&models.StudySession{}).Where("group_id = ?", groupID).Pluck("session_id", &sessionIDs).Error; err != nil {
+		return err
+	}
+	if err := deleteStudySessions(tx, sessionIDs); err != nil {
+		return err
+	}
+	return tx.Where("group_id = ?", groupID).Delete(&models.StudyPlan{}).Error
+}
+
+func GetGroupStudyPlans(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		access := c.MustGet("access").(models.Access)
+
+		var plans []models.StudyPlan
+		if err := db.Where("group_id = ?", access.GroupID).
+			Preload("Sessions", func(tx *gorm.DB) *gorm.DB { return tx.Order("position ASC") }).
+			Order("created_at DESC").
+			Find(&plans).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch study plans"})
+			return
+		}
+
+		c.JSON(http.StatusOK, plans)
+	}
+}
+
+func CreateStudyPlan(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		access, ok := requireGroupLeader(c)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Title         string                `json:"title"`
+			Description   string                `json:"description"`
+			TranslationID string                `json:"translation_id"`
+			Sessions      []studySessionRequest `json:"sessions"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if strings.TrimSpace(req.Title) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Title is required"})
+			return
+		}
+
+		plan := models.StudyPlan{
+			GroupID:       access.GroupID,
+			Title:         req.Title,
+			Description:   req.Description,
+			TranslationID: req.TranslationID,
+			CreatedBy:     access.UserID,
+		}
+		for i, s := range req.Sessions {
+			session := models.StudySession{GroupID: access.GroupID, Position: i + 1}
+			if !s.apply(&session) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid passage reference: " + s.Reference})
+				return
+			}
+			plan.Sessions = append(plan.Sessions, session)
+		}
+
+		if err := db.Create(&plan).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create study plan"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, plan)
+	}
+}
+
+func GetStudyPlan(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		access := c.MustGet("access").(models.Access)
+
+		plan, ok := loadStudyPlan(c, db, access.GroupID)
+		if !ok {
+			return
+		}
+
+		var sessions []models.StudySession
+		if err := db.Where("plan_id = ?", plan.PlanID).Order("position ASC").Find(&sessions).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+			return
+		}
+
+		ids := make([]uint, len(sessions))
+		for i, s := range sessions {
+			ids[i] = s.SessionID
+		}
+
+		var progressCounts []struct {
+			SessionID uint
+			Count     int
+			Mine      int
+		}
+		db.Model(&models.StudyProgress{}).
+			Select("session_id, COUNT(*) AS count, SUM(CASE WHEN user_id = ? THEN 1 ELSE 0 END) AS mine", access.UserID).
+			Where("session_id IN ?", ids).
+			Group("session_id").
+			Scan(&progressCounts)
+
+		var messageCounts []struct {
+			StudySessionID uint
+			Count          int
+		}
+		db.Model(&models.Message{}).
+			Select("study_session_id, COUNT(*) AS count").
+			Where("study_session_id IN ?", ids).
+			Group("study_session_id").
+			Scan(&messageCounts)
+
+		result := make([]models.StudySessionWithProgress, len(sessions))
+		index := make(map[uint]int, len(sessions))
+		for i, s := range sessions {
+			result[i] = models.StudySessionWithProgress{StudySession: s}
+			index[s.SessionID] = i
+		}
+		for _, pc := range progressCounts {
+			result[index[pc.SessionID]].CompletedCount = pc.Count
+			result[index[pc.SessionID]].Completed = pc.Mine > 0
+		}
+		for _, mc := range messageCounts {
+			result[index[mc.StudySessionID]].MessageCount = mc.Count
+		}
+
+		c.JSON(http.StatusOK, gin.H{"plan": plan, "sessions": result})
+	}
+}
+
+func UpdateStudyPlan(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		access, ok := requireGroupLeader(c)
+		if !ok {
+			return
+		}
+		plan, ok := loadStudyPlan(c, db, access.GroupID)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Title         string `json:"title"`
+			Description   string `json:"description"`
+			TranslationID string `json:"translation_id"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if strings.TrimSpace(req.Title) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Title is required"})
+			return
+		}
+
+		plan.Title = req.Title
+		plan.Description = req.Description
+		plan.TranslationID = req.TranslationID
+		if err := db.Save(&plan).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update study plan"})
+			return
+		}
+
+		c.JSON(http.StatusOK, plan)
+	}
+}
+
+func DeleteStudyPlan(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		access, ok := requireGroupLeader(c)
+		if !ok {
+			return
+		}
+		plan, ok := loadStudyPlan(c, db, access.GroupID)
+		if !ok {
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var sessionIDs []uint
+			if err := tx.Model(&models.StudySession{}).Where("plan_id = ?", plan.PlanID).Pluck("session_id", &sessionIDs).Error; err != nil {
+				return err
+			}
+			if err := deleteStudySessions(tx, sessionIDs); err != nil {
+				return err
+			}
+			return tx.Delete(&plan).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete study plan"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Study plan deleted"})
+	}
+}
+
+func AddStudySession(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		access, ok := requireGroupLeader(c)
+		if !ok {
+			return
+		}
+		plan, ok := loadStudyPlan(c, db, access.GroupID)
+		if !ok {
+			return
+		}
+
+		var req studySessionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var last int
+		db.Model(&models.StudySession{}).Where("plan_id = ?", plan.PlanID).Select("COALESCE(MAX(position), 0)").Scan(&last)
+
+		session := models.StudySession{PlanID: plan.PlanID, GroupID: plan.GroupID, Position: last + 1}
+		if !req.apply(&session) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid passage reference: " + req.Reference})
+			return
+		}
+
+		if err := db.Create(&session).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add session"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, session)
+	}
+}
+
+func UpdateStudySession(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		access, ok := requireGroupLeader(c)
+		if !ok {
+			return
+		}
+		session, ok := loadStudySession(c, db, access.GroupID)
+		if !ok {
+			return
+		}
+
+		var req studySessionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !req.apply(&session) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid passage reference: " + req.Reference})
+			return
+		}
+
+		if err := db.Save(&session).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update session"})
+			return
+		}
+
+		c.JSON(http.StatusOK, session)
+	}
+}
+
+func DeleteStudySession(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		access, ok := requireGroupLeader(c)
+		if !ok {
+			return
+		}
+		session, ok := loadStudySession(c, db, access.GroupID)
+		if !ok {
+			return
+		}
+
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			return deleteStudySessions(tx, []uint{session.SessionID})
+		}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete session"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Session deleted"})
+	}
+}
+
+func GetStudySessionMessages(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		access := c.MustGet("access").(models.Access)
+		session, ok := loadStudySession(c, db, access.GroupID)
+		if !ok {
+			return
+		}
+
+		var messages []models.Message
+		if err := db.Where("study_session_id = ? AND parent_message_id IS NULL", session.SessionID).
+			Order("is_pinned DESC, pinned_at DESC, created_at ASC").
+			Find(&messages).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch messages"})
+			return
+		}
+
+		result, err := withMessageMeta(db, messages, access.UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch message details"})
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+func CreateStudySessionMessage(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		access := c.MustGet("access").(models.Access)
+		session, ok := loadStudySession(c, db, access.GroupID)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Content string `json:"content"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Content) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Message content is required"})
+			return
+		}
+
+		var user models.User
+		if err := db.First(&user, "user_id = ?", access.UserID).Error; err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return
+		}
+
+		message := models.Message{
+			Content:        req.Content,
+			GroupID:        session.GroupID,
+			StudySessionID: &session.SessionID,
+			CreatedBy:      access.UserID,
+			Username:       user.Username,
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
+		}
+		if err := db.Create(&message).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create message"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, message)
+	}
+}
+
+func CompleteStudySession(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		access := c.MustGet("access").(models.Access)
+		session, ok := loadStudySession(c, db, access.GroupID)
+		if !ok {
+			return
+		}
+
+		progress := models.StudyProgress{SessionID: session.SessionID, UserID: access.UserID}
+		if err := db.Where(progress).Attrs(models.StudyProgress{CompletedAt: time.Now()}).FirstOrCreate(&progress).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save progress"})
+			return
+		}
+
+		c.JSON(http.StatusOK, progress)
+	}
+}
+
+func UncompleteStudySession(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		access := c.MustGet("access").(models.Access)
+		session, ok := loadStudySession(c, db, access.GroupID)
+		if !ok {
+			return
+		}
+
+		if err := db.Where("session_id = ? AND user_id = ?", session.SessionID, access.UserID).Delete(&models.StudyProgress{}).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save progress"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Session marked incomplete"})
+	}
+}
+
+// GetStudyPlanProgress shows leaders how far each active member has got
+// through a plan.
+func GetStudyPlanProgress(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		access, ok := requireGroupLeader(c)
+		if !ok {
+			return
+		}
+		plan, ok := loadStudyPlan(c, db, access.GroupID)
+		if !ok {
+			return
+		}
+
+		var total int64
+		db.Model(&models.StudySession{}).Where("plan_id = ?", plan.PlanID).Count(&total)
+
+		var progress []models.StudyMemberProgress
+		if err := db.Raw(`
+			SELECT gm.user_id, u.username, COUNT(sp.id) AS completed
+			FROM group_members gm
+			JOIN users u ON u.user_id = gm.user_id
+			LEFT JOIN study_sessions ss ON ss.plan_id = ?
+			LEFT JOIN study_progresses sp ON sp.session_id = ss.session_id AND sp.user_id = gm.user_id
+			WHERE gm.group_id = ? AND gm.status = ?
+			GROUP BY gm.user_id, u.username
+			ORDER BY completed DESC, u.username ASC
+		`, plan.PlanID, access.GroupID, models.GroupMemberActive).Scan(&progress).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch progress"})
+			return
+		}
+
+		for i := range progress {
+			progress[i].Total = int(total)
+		}
+
+		c.JSON(http.StatusOK, progress)
+	}
+}
@@ -0,0 +1,614 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"theword/Backend/lib/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Offline sync
+//
+// Every write to a bookmark, bookmark folder, highlight or saved verse bumps
+// the user's revision and records it in their change log. Devices pull
+// GET /api/sync?since=<revision> for everything changed after the revision
+// they last saw (since=0 returns a full snapshot) and push batched edits to
+// POST /api/sync.
+//
+// Conflict policy. A pushed change conflicts when the entity has changed on
+// the server since the device's base_revision. Conflicts resolve as follows:
+//
+//   - Edit vs edit: the later edit wins, comparing the device's updated_at
+//     with the time the server accepted its last write. Ties go to the server.
+//   - Saved verse notes are resolved on their own, using the base_note the
+//     device pushes (the note as it was at base_revision). A side edited the
+//     note when its note differs from base_note; clearing the note counts as
+//     an edit. When only one side edited the note, its note is kept. When both
+//     did, the server's text is kept followed by the device's, separated by a
+//     blank line (unless one contains the other, in which case the longer one
+//     is kept, so a note cleared on one side and edited on the other keeps the
+//     edit). Without base_note the note follows edit vs edit. Other
+//     saved-verse fields follow edit vs edit.
+//   - Delete vs edit: the server's state wins. A delete of an entity edited on
+//     the server is discarded, and an edit of an entity deleted on the server
+//     is discarded.
+//   - Creating a bookmark at a location that is already bookmarked, or
+//     highlighting a verse that is already highlighted, edits the existing
+//     entity.
+//
+// When the server's version is kept the result has status "conflict" and
+// carries the server's data so the device can overwrite its copy.
+
+const maxSyncBatch = 500
+
+// nextSyncRevision bumps and returns the user's revision.
+func nextSyncRevision(tx *gorm.DB, userID uint) (uint64, error) {
+	state := models.SyncState{UserID: userID}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&state).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&state).Where("user_id = ?", userID).UpdateColumn("revision", gorm.Expr("revision + 1")).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.First(&state, "user_id = ?", userID).Error; err != nil {
+		return 0, err
+	}
+	return state.Revision, nil
+}
+
+// recordChange logs a write to a synced entity so other devices pick it up.
+func recordChange(tx *gorm.DB, userID uint, entity string, entityID uint, deleted bool) (uint64, error) {
+	revision, err := nextSyncRevision(tx, userID)
+	if err != nil {
+		return 0, err
+	}
+	change := models.SyncChange{
+		UserID:    userID,
+		Entity:    entity,
+		EntityID:  entityID,
+		Revision:  revision,
+		Deleted:   deleted,
+		ChangedAt: time.Now(),
+	}
+	err = tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "entity"}, {Name: "entity_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"revision", "deleted", "changed_at"}),
+	}).Create(&change).Error
+	return revision, err
+}
+
+func entityRevision(tx *gorm.DB, userID uint, entity string, entityID uint) uint64 {
+	var change models.SyncChange
+	tx.Where("user_id = ? AND entity = ? AND entity_id = ?", userID, entity, entityID).Limit(1).Find(&change)
+	return change.Revision
+}
+
+// syncEntity describes how to load a synced table for one user.
+type syncEntity struct {
+	load func(db *gorm.DB, userID uint, ids []uint) (map[uint]interface{}, error)
+	push func(tx *gorm.DB, userID uint, change models.SyncPush) (models.SyncResult, error)
+}
+
+var syncEntities = map[string]syncEntity{
+	models.SyncEntityBookmark:       {load: loadSyncRows[models.Bookmark]("bookmark_id", func(b models.Bookmark) uint { return b.BookmarkID }), push: pushBookmark},
+	models.SyncEntityBookmarkFolder: {load: loadSyncRows[models.BookmarkFolder]("folder_id", func(f models.BookmarkFolder) uint { return f.FolderID }), push: pushBookmarkFolder},
+	models.SyncEntityHighlight:      {load: loadSyncRows[models.Highlight]("highlight_id", func(h models.Highlight) uint { return h.HighlightID }), push: pushHighlight},
+	models.SyncEntityUserVerse:      {load: loadSyncRows[models.UserVerse]("user_verse_id", func(v models.UserVerse) uint { return v.UserVerseID }), push: pushUserVerse},
+}
+
+// loadSyncRows loads the user's rows of T, all of them when ids is nil.
+func loadSyncRows[T any](idColumn string, id func(T) uint) func(db *gorm.DB, userID uint, ids []uint) (map[uint]interface{}, error) {
+	return func(db *gorm.DB, userID uint, ids []uint) (map[uint]interface{}, error) {
+		query := db.Where("user_id = ?", userID)
+		if ids != nil {
+			query = query.Where(idColumn+" IN ?", ids)
+		}
+		var rows []T
+		if err := query.Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		byID := make(map[uint]interface{}, len(rows))
+		for _, row := range rows {
+			byID[id(row)] = row
+		}
+		return byID, nil
+	}
+}
+
+func GetSyncChanges(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+
+		since, err := strconv.ParseUint(c.DefaultQuery("since", "0"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "since must be a revision number"})
+			return
+		}
+
+		var state models.SyncState
+		db.Where("user_id = ?", userID).Limit(1).Find(&state)
+
+		var changes []models.SyncChange
+		query := db.Where("user_id = ? AND revision > ?", userID, since).Order("revision ASC")
+		if since > 0 {
+			query = query.Limit(maxSyncBatch + 1)
+		}
+		if err := query.Find(&changes).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load changes"})
+			return
+		}
+
+		hasMore := since > 0 && len(changes) > maxSyncBatch
+		if hasMore {
+			changes = changes[:maxSyncBatch]
+		}
+
+		ids := map[string][]uint{}
+		revisions := map[string]map[uint]uint64{}
+		for _, ch := range changes {
+			if revisions[ch.Entity] == nil {
+				revisions[ch.Entity] = map[uint]uint64{}
+			}
+			revisions[ch.Entity][ch.EntityID] = ch.Revision
+			ids[ch.Entity] = append(ids[ch.Entity], ch.EntityID)
+		}
+
+		items := []models.SyncItem{}
+		for _, name := range []string{models.SyncEntityBookmarkFolder, models.SyncEntityBookmark, models.SyncEntityHighlight, models.SyncEntityUserVerse} {
+			// A snapshot includes rows written before sync existed, which
+			// have no change log entry yet.
+			var want []uint
+			if since > 0 {
+				if len(ids[name]) == 0 {
+					continue
+				}
+				want = ids[name]
+			}
+
+			rows, err := syncEntities[name].load(db, userID, want)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load changes"})
+				return
+			}
+			for id, row := range rows {
+				items = append(items, models.SyncItem{Entity: name, ID: id, Revision: revisions[name][id], Data: row})
+			}
+			if since > 0 {
+				for _, id := range want {
+					if _, ok := rows[id]; !ok {
+						items = append(items, models.SyncItem{Entity: name, ID: id, Revision: revisions[name][id], Deleted: true})
+					}
+				}
+			}
+		}
+
+		revision := state.Revision
+		if hasMore {
+			revision = changes[len(changes)-1].Revision
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"revision": revision,
+			"changes":  items,
+			"has_more": hasMore,
+		})
+	}
+}
+
+func PushSyncChanges(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uint)
+
+		var req struct {
+			Changes []models.SyncPush `json:"changes"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		if len(req.Changes) > maxSyncBatch {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Too many changes in one batch"})
+			return
+		}
+
+		results := make([]models.SyncResult, len(req.Changes))
+		for i, change := range req.Changes {
+			entity, ok := syncEntities[change.Entity]
+			if !ok {
+				results[i] = rejectSync(change, "Unknown entity")
+				continue
+			}
+
+			var result models.SyncResult
+			err := db.Transaction(func(tx *gorm.DB) error {
+				var err error
+				result, err = entity.push(tx, userID, change)
+				return err
+			})
+			if err != nil {
+				result = rejectSync(change, "Failed to apply change")
+			}
+			result.Entity = change.Entity
+			result.ClientID = change.ClientID
+			results[i] = result
+		}
+
+		var state models.SyncState
+		db.Where("user_id = ?", userID).Limit(1).Find(&state)
+
+		c.JSON(http.StatusOK, gin.H{"revision": state.Revision, "results": results})
+	}
+}
+
+func rejectSync(change models.SyncPush, msg string) models.SyncResult {
+	return models.SyncResult{Entity: change.Entity, ClientID: change.ClientID, ID: change.ID, Status: models.SyncRejected, Error: msg}
+}
+
+// syncDecision applies the conflict policy to an entity that exists on the
+// server and reports whether the device's change should be stored.
+func syncDecision(tx *gorm.DB, userID uint, change models.SyncPush, entityID uint, serverUpdatedAt time.Time) (conflict, deviceWins bool) {
+	if entityRevision(tx, userID, change.Entity, entityID) <= change.BaseRevision {
+		return false, true
+	}
+	if change.Deleted {
+		return true, false
+	}
+	return true, change.UpdatedAt.After(serverUpdatedAt)
+}
+
+// keepServer reports the server's copy back to the device.
+func keepServer(tx *gorm.DB, userID uint, change models.SyncPush, id uint, data interface{}) models.SyncResult {
+	return models.SyncResult{ID: id, Revision: entityRevision(tx, userID, change.Entity, id), Status: models.SyncConflict, Data: data}
+}
+
+// goneOnServer answers a change to an entity the server no longer has.
+func goneOnServer(tx *gorm.DB, userID uint, change models.SyncPush) models.SyncResult {
+	status := models.SyncConflict
+	if change.Deleted {
+		status = models.SyncApplied
+	}
+	return models.SyncResult{ID: change.ID, Revision: entityRevision(tx, userID, change.Entity, change.ID), Status: status, Deleted: true}
+}
+
+func storedSync(tx *gorm.DB, userID uint, change models.SyncPush, id uint, status string, data interface{}) (models.SyncResult, error) {
+	revision, err := recordChange(tx, userID, change.Entity, id, change.Deleted)
+	if err != nil {
+		return models.SyncResult{}, err
+	}
+	if change.Deleted {
+		data = nil
+	}
+	return models.SyncResult{ID: id, Revision: revision, Status: status, Deleted: change.Deleted, Data: data}, nil
+}
+
+func pushBookmark(tx *gorm.DB, userID uint, change models.SyncPush) (models.SyncResult, error) {
+	var incoming models.Bookmark
+	if !change.Deleted {
+		if err := json.Unmarshal(change.Data, &incoming); err != nil {
+			return rejectSync(change, "Invalid bookmark data"), nil
+		}
+		if !normaliseBookmark(&incoming) {
+			return rejectSync(change, "Invalid verse range"), nil
+		}
+		if !checkBookmarkFolder(tx, userID, incoming.FolderID) {
+			return rejectSync(change, "Folder not found"), nil
+		}
+	}
+
+	var existing models.Bookmark
+	found := false
+	if change.ID != 0 {
+		found = tx.Where("bookmark_id = ? AND user_id = ?", change.ID, userID).Limit(1).Find(&existing).RowsAffected > 0
+		if !found {
+			return goneOnServer(tx, userID, change), nil
+		}
+	} else if !change.Deleted {
+		found = tx.Where("user_id = ? AND translation_id = ? AND chapter_id = ? AND verse_start = ? AND verse_end = ?",
+			userID, incoming.TranslationID, incoming.ChapterID, incoming.VerseStart, incoming.VerseEnd).
+			Limit(1).Find(&existing).RowsAffected > 0
+	}
+
+	if !found {
+		if change.Deleted {
+			return rejectSync(change, "Nothing to delete"), nil
+		}
+		incoming.BookmarkID = 0
+		incoming.UserID = userID
+		if incoming.Position == 0 {
+			tx.Model(&models.Bookmark{}).Where("user_id = ?", userID).Select("COALESCE(MAX(position), 0) + 1").Scan(&incoming.Position)
+		}
+		if err := tx.Create(&incoming).Error; err != nil {
+			return models.SyncResult{}, err
+		}
+		return storedSync(tx, userID, change, incoming.BookmarkID, models.SyncApplied, incoming)
+	}
+
+	_, deviceWins := syncDecision(tx, userID, change, existing.BookmarkID, existing.UpdatedAt)
+	if !deviceWins {
+		return keepServer(tx, userID, change, existing.BookmarkID, existing), nil
+	}
+
+	if change.Deleted {
+		if err := tx.Delete(&existing).Error; err != nil {
+			return models.SyncResult{}, err
+		}
+		return storedSync(tx, userID, change, existing.BookmarkID, models.SyncApplied, nil)
+	}
+
+	var duplicate int64
+	tx.Model(&models.Bookmark{}).
+		Where("user_id = ? AND translation_id = ? AND chapter_id = ? AND verse_start = ? AND verse_end = ? AND bookmark_id <> ?",
+			userID, incoming.TranslationID, incoming.ChapterID, incoming.VerseStart, incoming.VerseEnd, existing.BookmarkID).
+		Count(&duplicate)
+	if duplicate > 0 {
+		return rejectSync(change, "You already have a bookmark here"), nil
+	}
+
+	incoming.BookmarkID = existing.BookmarkID
+	incoming.UserID = userID
+	incoming.CreatedAt = existing.CreatedAt
+	if err := tx.Save(&incoming).Error; err != nil {
+		return models.SyncResult{}, err
+	}
+	return storedSync(tx, userID, change, incoming.BookmarkID, models.SyncApplied, incoming)
+}
+
+func pushBookmarkFolder(tx *gorm.DB, userID uint, change models.SyncPush) (models.SyncResult, error) {
+	var incoming models.BookmarkFolder
+	if !change.Deleted {
+		if err := json.Unmarshal(change.Data, &incoming); err != nil || strings.TrimSpace(incoming.Name) == "" {
+			return rejectSync(change, "Folder name is required"), nil
+		}
+	}
+
+	if change.ID == 0 {
+		if change.Deleted {
+			return rejectSync(change, "Nothing to delete"), nil
+		}
+		incoming.FolderID = 0
+		incoming.UserID = userID
+		if err := tx.Create(&incoming).Error; err != nil {
+			return models.SyncResult{}, err
+		}
+		return storedSync(tx, userID, change, incoming.FolderID, models.SyncApplied, incoming)
+	}
+
+	var existing models.BookmarkFolder
+	if tx.Where("folder_id = ? AND user_id = ?", change.ID, userID).Limit(1).Find(&existing).RowsAffected == 0 {
+		return goneOnServer(tx, userID, change), nil
+	}
+
+	_, deviceWins := syncDecision(tx, userID, change, existing.FolderID, existing.UpdatedAt)
+	if !deviceWins {
+		return keepServer(tx, userID, change, existing.FolderID, existing), nil
+	}
+
+	if change.Deleted {
+		if err := unfileBookmarks(tx, userID, existing.FolderID); err != nil {
+			return models.SyncResult{}, err
+		}
+		if err := tx.Delete(&existing).Error; err != nil {
+			return models.SyncResult{}, err
+		}
+		return storedSync(tx, userID, change, existing.FolderID, models.SyncApplied, nil)
+	}
+
+	existing.Name = incoming.Name
+	existing.Color = incoming.Color
+	existing.Position = incoming.Position
+	if err := tx.Save(&existing).Error; err != nil {
+		return models.SyncResult{}, err
+	}
+	return storedSync(tx, userID, change, existing.FolderID, models.SyncApplied, existing)
+}
+
+// unfileBookmarks moves a folder's bookmarks out of it before the folder is deleted.
+func unfileBookmarks(tx *gorm.DB, userID, folderID uint) error {
+	var ids []uint
+	if err := tx.Model(&models.Bookmark{}).Where("folder_id = ?", folderID).Pluck("bookmark_id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := tx.Model(&models.Bookmark{}).Where("bookmark_id IN ?", ids).Update("folder_id", nil).Error; err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, err := recordChange(tx, userID, models.SyncEntityBookmark, id, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func pushHighlight(tx *gorm.DB, userID uint, change models.SyncPush) (models.SyncResult, error) {
+	var incoming models.Highlight
+	if !change.Deleted || change.ID == 0 {
+		if err := json.Unmarshal(change.Data, &incoming); err != nil || incoming.TranslationID == "" {
+			return rejectSync(change, "Invalid highlight data"), nil
+		}
+		verseID, chapterID, ok := normaliseVerseReference(incoming.VerseID)
+		if !ok {
+			return rejectSync(change, "Invalid verse ID"), nil
+		}
+		incoming.VerseID = verseID
+		incoming.ChapterID = chapterID
+	}
+
+	// Highlights are keyed by verse, so a device may refer to one by
+	// translation and verse instead of ID.
+	var existing models.Highlight
+	var found bool
+	if change.ID != 0 {
+		found = tx.Where("highlight_id = ? AND user_id = ?", change.ID, userID).Limit(1).Find(&existing).RowsAffected > 0
+		if !found {
+			return goneOnServer(tx, userID, change), nil
+		}
+	} else {
+		found = tx.Where("user_id = ? AND translation_id = ? AND verse_id = ?", userID, incoming.TranslationID, incoming.VerseID).
+			Limit(1).Find(&existing).RowsAffected > 0
+	}
+
+	if !found {
+		if change.Deleted {
+			return rejectSync(change, "Nothing to delete"), nil
+		}
+		incoming.HighlightID = 0
+		incoming.UserID = userID
+		if err := tx.Create(&incoming).Error; err != nil {
+			return models.SyncResult{}, err
+		}
+		return storedSync(tx, userID, change, incoming.HighlightID, models.SyncApplied, incoming)
+	}
+
+	_, deviceWins := syncDecision(tx, userID, change, existing.HighlightID, existing.UpdatedAt)
+	if !deviceWins {
+		return keepServer(tx, userID, change, existing.HighlightID, existing), nil
+	}
+
+	if change.Deleted {
+		if err := tx.Delete(&existing).Error; err != nil {
+			return models.SyncResult{}, err
+		}
+		return storedSync(tx, userID, change, existing.HighlightID, models.SyncApplied, nil)
+	}
+
+	existing.Color = incoming.Color
+	if err := tx.Save(&existing).Error; err != nil {
+		return models.SyncResult{}, err
+	}
+	return storedSync(tx, userID, change, existing.HighlightID, models.SyncApplied, existing)
+}
+
+// resolveNote picks the note after a conflicting push. base is the note the
+// device started from, nil if it didn't send one.
+func resolveNote(server, device string, base *string, deviceWins bool) string {
+	if base == nil {
+		if deviceWins {
+			return device
+		}
+		return server
+	}
+	deviceEdited, serverEdited := device != *base, server != *base
+	switch {
+	case deviceEdited && serverEdited:
+		return mergeNotes(server, device)
+	case deviceEdited:
+		return device
+	default:
+		return server
+	}
+}
+
+// mergeNotes combines two edits of the same note without losing either.
+func mergeNotes(server, device string) string {
+	switch {
+	case server == device || strings.Contains(server, device):
+		return server
+	case strings.Contains(device, server):
+		return device
+	default:
+		return server + "\n\n" + device
+	}
+}
+
+func pushUserVerse(tx *gorm.DB, userID uint, change models.SyncPush) (models.SyncResult, error) {
+	var incoming models.UserVerse
+	if !change.Deleted {
+		if err := json.Unmarshal(change.Data, &incoming); err != nil {
+			return rejectSync(change, "Invalid verse data"), nil
+		}
+		// Stored as sent, like SaveVerse, so the app can match it
+		if _, _, ok := normaliseVerseReference(incoming.VerseID); !ok {
+			return rejectSync(change, "Invalid verse ID"), nil
+		}
+	}
+
+	if change.ID == 0 {
+		if change.Deleted {
+			return rejectSync(change, "Nothing to delete"), nil
+		}
+		incoming.UserVerseID = 0
+		incoming.UserID = userID
+		if err := tx.Create(&incoming).Error; err != nil {
+			return models.SyncResult{}, err
+		}
+		return storedSync(tx, userID, change, incoming.UserVerseID, models.SyncApplied, incoming)
+	}
+
+	var existing models.UserVerse
+	if tx.Where("user_verse_id = ? AND user_id = ?", change.ID, userID).Limit(1).Find(&existing).RowsAffected == 0 {
+		return goneOnServer(tx, userID, change), nil
+	}
+
+	conflict, deviceWins := syncDecision(tx, userID, change, existing.UserVerseID, existing.UpdatedAt)
+	if change.Deleted {
+		if !deviceWins {
+			return keepServer(tx, userID, change, existing.UserVerseID, existing), nil
+		}
+		if err := tx.Delete(&existing).Error; err != nil {
+			return models.SyncResult{}, err
+		}
+		return storedSync(tx, userID, change, existing.UserVerseID, models.SyncApplied, nil)
+	}
+
+	status := models.SyncApplied
+	note := incoming.Note
+	if conflict {
+		note = resolveNote(existing.Note, incoming.Note, change.BaseNote, deviceWins)
+		switch {
+		case deviceWins && note != incoming.Note, !deviceWins && note != existing.Note:
+			status = models.SyncMerged
+		case !deviceWins:
+			status = models.SyncConflict
+		}
+		if !deviceWins {
+			// Only the note is resolved; the server's other fields stand
+			incoming = existing
+		}
+	}
+
+	existing.VerseID = incoming.VerseID
+	existing.Content = incoming.Content
+	existing.Verse = incoming.Verse
+	existing.IsPublished = incoming.IsPublished
+	existing.Note = note
+	if err := tx.Save(&existing).Error; err != nil {
+		return models.SyncResult{}, err
+	}
+	return storedSync(tx, userID, change, existing.UserVerseID, status, existing)
+}
+
+// syncWrite runs a REST write and logs it for sync in one transaction.
+func syncWrite(db *gorm.DB, userID uint, entity string, write func(tx *gorm.DB) (uint, error)) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		id, err := write(tx)
+		if err != nil {
+			return err
+		}
+		_, err = recordChange(tx, userID, entity, id, false)
+		return err
+	})
+}
+
+// syncDelete runs a REST delete and leaves tombstones for the removed rows.
+func syncDelete(db *gorm.DB, userID uint, entity string, ids []uint, remove func(tx *gorm.DB) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := remove(tx); err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if _, err := recordChange(tx, userID, entity, id, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"theword/Backend/lib/models"
+)
+
+func TestPushUserVerseNote(t *testing.T) {
+	base := "first thoughts"
+	tests := []struct {
+		name       string
+		serverNote string // the note after the server's edit
+		deviceNote string
+		baseNote   *string
+		deviceWins bool
+		want       string
+		status     string
+	}{
+		{"only the server edited the note", "server edit", base, &base, true, "server edit", models.SyncMerged},
+		{"only the device edited the note", base, "device edit", &base, false, "device edit", models.SyncMerged},
+		{"both edited the note", "server edit", "device edit", &base, true, "server edit\n\ndevice edit", models.SyncMerged},
+		{"device cleared an unchanged note", base, "", &base, false, "", models.SyncMerged},
+		{"device cleared a note the server edited", "server edit", "", &base, true, "server edit", models.SyncMerged},
+		{"neither edited the note", base, base, &base, false, base, models.SyncConflict},
+		{"no base note, device wins", "server edit", "device edit", nil, true, "device edit", models.SyncApplied},
+		{"no base note, server wins", "server edit", "device edit", nil, false, "server edit", models.SyncConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const userID = 1
+			db := newTestDB(t, &models.UserVerse{}, &models.SyncState{}, &models.SyncChange{})
+			serverEdit := time.Now()
+			verse := models.UserVerse{VerseID: "JHN.3.16", UserID: userID, Note: base, Content: "server content"}
+			db.Create(&verse)
+			baseRevision, _ := recordChange(db, userID, models.SyncEntityUserVerse, verse.UserVerseID, false)
+			db.Model(&verse).Update("note", tt.serverNote)
+			recordChange(db, userID, models.SyncEntityUserVerse, verse.UserVerseID, false)
+
+			deviceEdit := serverEdit.Add(-time.Hour)
+			if tt.deviceWins {
+				deviceEdit = serverEdit.Add(time.Hour)
+			}
+			data, _ := json.Marshal(models.UserVerse{VerseID: "JHN.3.16", Note: tt.deviceNote, Content: "device content"})
+			result, err := pushUserVerse(db, userID, models.SyncPush{
+				Entity:       models.SyncEntityUserVerse,
+				ID:           verse.UserVerseID,
+				BaseRevision: baseRevision,
+				BaseNote:     tt.baseNote,
+				UpdatedAt:    deviceEdit,
+				Data:         data,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var stored models.UserVerse
+			db.First(&stored, verse.UserVerseID)
+			if stored.Note != tt.want || result.Status != tt.status {
+				t.Errorf("note %q, status %s; want %q, %s", stored.Note, result.Status, tt.want, tt.status)
+			}
+			if wantContent := map[bool]string{true: "device content", false: "server content"}[tt.deviceWins]; stored.Content != wantContent {
+				t.Errorf("content %q, want %q", stored.Content, wantContent)
+			}
+		})
+	}
+}
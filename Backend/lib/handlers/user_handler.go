@@ -107,6 +107,12 @@ func GetUserSettings(db *gorm.DB) gin.HandlerFunc {
 			"translation_id":   user.TranslationId,
 			"translation_name": user.TranslationName,
 			"avatar_url":       user.AvatarURL,
+			"time_zone":        user.TimeZone,
+			"daily_verse": gin.H{
+				"notify": user.DailyVerseNotify,
+				"email":  user.DailyVerseEmail,
+				"hour":   user.DailyVerseHour,
+			},
 		})
 	}
 }
@@ -115,12 +121,18 @@ func UpdateUserSettingsHandler(db *gorm.DB) gin.HandlerFunc {
 		userID := c.MustGet("userID").(uint)
 
 		var req struct {
-			PrimaryColor    int    `json:"primary_color"`
-			HighlightColor  int    `json:"highlight_color"`
-			DarkMode        bool   `json:"dark_mode"`
-			PublicProfile   bool   `json:"public_profile"`
-			TranslationId   string `json:"translation_id"`
-			TranslationName string `json:"translation_name"`
+			PrimaryColor    int     `json:"primary_color"`
+			HighlightColor  int     `json:"highlight_color"`
+			DarkMode        bool    `json:"dark_mode"`
+			PublicProfile   bool    `json:"public_profile"`
+			TranslationId   string  `json:"translation_id"`
+			TranslationName string  `json:"translation_name"`
+			TimeZone        *string `json:"time_zone"`
+			DailyVerse      *struct {
+				Notify *bool `json:"notify"`
+				Email  *bool `json:"email"`
+				Hour   *int  `json:"hour"`
+			} `json:"daily_verse"`
 		}
 
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -137,6 +149,30 @@ func UpdateUserSettingsHandler(db *gorm.DB) gin.HandlerFunc {
 			"translation_name": req.TranslationName,
 		}
 
+		// Older clients don't send these, so only change them when present.
+		if req.TimeZone != nil {
+			if _, err := time.LoadLocation(*req.TimeZone); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown time zone"})
+				return
+			}
+			updates["time_zone"] = *req.TimeZone
+		}
+		if dv := req.DailyVerse; dv != nil {
+			if dv.Notify != nil {
+				updates["daily_verse_notify"] = *dv.Notify
+			}
+			if dv.Email != nil {
+				updates["daily_verse_email"] = *dv.Email
+			}
+			if dv.Hour != nil {
+				if *dv.Hour < 0 || *dv.Hour > 23 {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Hour must be between 0 and 23"})
+					return
+				}
+				updates["daily_verse_hour"] = *dv.Hour
+			}
+		}
+
 		if err := db.Model(&models.User{}).Where("user_id = ?", userID).Updates(updates).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -212,6 +248,11 @@ func DeleteUser(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete notifications"})
 			return
 		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.MessageReaction{}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete message reactions"})
+			return
+		}
 		if err := tx.Where("created_by = ?", userID).Delete(&models.Message{}).Error; err != nil {
 			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete messages"})
@@ -222,16 +263,80 @@ func DeleteUser(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete events"})
 			return
 		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.PrayerIntercession{}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete prayer activity"})
+			return
+		}
+		if err := tx.Where("created_by = ?", userID).Delete(&models.PrayerUpdate{}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete prayer updates"})
+			return
+		}
 		if err := tx.Where("created_by = ?", userID).Delete(&models.PrayerRequest{}).Error; err != nil {
 			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete prayer requests"})
 			return
 		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.GroupPreference{}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete group preferences"})
+			return
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.StudyProgress{}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete study progress"})
+			return
+		}
+		if err := deleteReadingEnrollments(tx, tx.Where("user_id = ?", userID)); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete reading plans"})
+			return
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.ChapterRead{}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete reading history"})
+			return
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.Bookmark{}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete bookmarks"})
+			return
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.BookmarkFolder{}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete bookmark folders"})
+			return
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.Highlight{}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete highlights"})
+			return
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.SyncChange{}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete sync history"})
+			return
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.SyncState{}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete sync history"})
+			return
+		}
+		var groupIDs []uint
+		tx.Model(&models.GroupMember{}).Where("user_id = ?", userID).Pluck("group_id", &groupIDs)
 		if err := tx.Where("user_id = ?", userID).Delete(&models.GroupMember{}).Error; err != nil {
 			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete group memberships"})
 			return
 		}
+		for _, groupID := range groupIDs {
+			if err := syncGroupMemberCount(tx, groupID); err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update group member counts"})
+				return
+			}
+		}
 
 		// Step 2: Delete the user
 		if err := tx.Delete(&models.User{}, "user_id = ?", userID).Error; err != nil {
@@ -37,7 +37,14 @@ func SearchSavedVerses(db *gorm.DB) gin.HandlerFunc {
 		if searchQuery != "" {
 			// Apply the search filter
 			searchString := "%" + searchQuery + "%"
-			query = query.Where("CAST(verse_id AS CHAR) LIKE ? OR content LIKE ? OR note LIKE ?", searchString, searchString, searchString)
+			match := "CAST(verse_id AS CHAR) LIKE ? OR content LIKE ? OR note LIKE ?"
+			args := []interface{}{searchString, searchString, searchString}
+			// A reference such as "Jn 3" also finds the verses it covers.
+			if refMatch, refArgs, ok := verseReferenceFilter("verse_id", searchQuery); ok {
+				match += " OR " + refMatch
+				args = append(args, refArgs...)
+			}
+			query = query.Where(match, args...)
 		}
 
 		if err := query.Find(&verses).Error; err != nil {
@@ -72,6 +79,15 @@ func SearchPublicVerses(db *gorm.DB) gin.HandlerFunc {
 
 		offset := (pageInt - 1) * pageSizeInt
 
+		like := "%" + searchQuery + "%"
+		match := "CAST(uv.user_verse_id AS CHAR) LIKE ? OR uv.content LIKE ? OR uv.note LIKE ?"
+		args := []interface{}{userID, userID, userID, like, like, like}
+		if refMatch, refArgs, ok := verseReferenceFilter("uv.verse_id", searchQuery); ok {
+			match += " OR " + refMatch
+			args = append(args, refArgs...)
+		}
+		args = append(args, pageSizeInt, offset)
+
 		// Modify the query to include search conditions for VerseID, Content, and Note
 		err = db.Raw(`
 		SELECT uv.*, u.username 
@@ -85,14 +101,10 @@ func SearchPublicVerses(db *gorm.DB) gin.HandlerFunc {
 			u.user_id = ? OR 
 			u.public_profile = true OR 
 			f.status = 'accepted'
-		) AND (
-			CAST(uv.user_verse_id AS CHAR) LIKE ? OR 
-			uv.content LIKE ? OR 
-			uv.note LIKE ?
-		)
+		) AND (`+match+`)
 		ORDER BY uv.user_verse_id DESC
 		LIMIT ? OFFSET ?
-	`, userID, userID, userID, "%"+searchQuery+"%", "%"+searchQuery+"%", "%"+searchQuery+"%", pageSizeInt, offset).Scan(&verses).Error
+	`, args...).Scan(&verses).Error
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to search verses: %v", err)})
@@ -200,7 +212,9 @@ func PublishVerse(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		verse.IsPublished = req.IsPublished
-		if err := db.Save(&verse).Error; err != nil {
+		if err := syncWrite(db, userID, models.SyncEntityUserVerse, func(tx *gorm.DB) (uint, error) {
+			return verse.UserVerseID, tx.Save(&verse).Error
+		}); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update verse publication status"})
 			return
 		}
@@ -221,7 +235,9 @@ func UnpublishVerse(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		verse.IsPublished = false
-		if err := db.Save(&verse).Error; err != nil {
+		if err := syncWrite(db, userID, models.SyncEntityUserVerse, func(tx *gorm.DB) (uint, error) {
+			return verse.UserVerseID, tx.Save(&verse).Error
+		}); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update verse publication status"})
 			return
 		}
@@ -238,9 +254,17 @@ func SaveVerse(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		// The app matches saved verses by the ID it sent, so it's stored as is
+		if _, _, ok := normaliseVerseReference(verse.VerseID); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid verse reference"})
+			return
+		}
 		verse.UserID = userID
 
-		if err := db.Save(&verse).Error; err != nil {
+		if err := syncWrite(db, userID, models.SyncEntityUserVerse, func(tx *gorm.DB) (uint, error) {
+			err := tx.Save(&verse).Error
+			return verse.UserVerseID, err
+		}); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -272,7 +296,9 @@ func UpdateVerse(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		verse.Note = req.Note
-		if err := db.Save(&verse).Error; err != nil {
+		if err := syncWrite(db, userID, models.SyncEntityUserVerse, func(tx *gorm.DB) (uint, error) {
+			return verse.UserVerseID, tx.Save(&verse).Error
+		}); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update verse"})
 			return
 		}
@@ -456,8 +482,16 @@ func CreateVerse(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		// The app matches saved verses by the ID it sent, so it's stored as is
+		if _, _, ok := normaliseVerseReference(verse.VerseID); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid verse reference"})
+			return
+		}
 		verse.UserID = userID
-		db.Create(&verse)
+		syncWrite(db, userID, models.SyncEntityUserVerse, func(tx *gorm.DB) (uint, error) {
+			err := tx.Create(&verse).Error
+			return verse.UserVerseID, err
+		})
 		c.JSON(http.StatusOK, verse)
 	}
 }
@@ -486,7 +520,9 @@ func DeleteVerse(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		// Delete the verse
-		if err := db.Delete(&verse).Error; err != nil {
+		if err := syncDelete(db, userID, models.SyncEntityUserVerse, []uint{verse.UserVerseID}, func(tx *gorm.DB) error {
+			return tx.Delete(&verse).Error
+		}); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"theword/Backend/lib/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSaveVerseIDs(t *testing.T) {
+	db := newTestDB(t, &models.UserVerse{}, &models.SyncState{}, &models.SyncChange{})
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/verses/save", asUser, SaveVerse(db))
+	r.GET("/api/verses/saved/search", asUser, SearchSavedVerses(db))
+
+	// The bodies the app sends: ESV verses as "chapter:verse", other
+	// translations by sid.
+	tests := []struct {
+		verseID string
+		status  int
+	}{
+		{"JHN.3:JHN.3.16", http.StatusOK},
+		{"ROM.8.28", http.StatusOK},
+		{"JHN.3.17", http.StatusOK},
+		{"JHN.4:JHN.3.16", http.StatusBadRequest},
+		{"JHN.3", http.StatusBadRequest},
+		{"not a verse", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		body := `{"VerseID":"` + tt.verseID + `","Content":"For God so loved the world","Note":""}`
+		if code := send(r, http.MethodPost, 1, "/api/verses/save", body); code != tt.status {
+			t.Errorf("saving %q: %d, want %d", tt.verseID, code, tt.status)
+		}
+	}
+
+	// The app finds its saved verses by the ID it sent.
+	var ids []string
+	db.Model(&models.UserVerse{}).Order("user_verse_id").Pluck("verse_id", &ids)
+	if len(ids) != 3 || ids[0] != "JHN.3:JHN.3.16" || ids[1] != "ROM.8.28" || ids[2] != "JHN.3.17" {
+		t.Errorf("stored verse IDs = %q", ids)
+	}
+
+	var found []models.UserVerse
+	if code := getJSON(t, r, 1, "/api/verses/saved/search?q=John+3:16", &found); code != http.StatusOK ||
+		len(found) != 1 || found[0].VerseID != "JHN.3:JHN.3.16" {
+		t.Errorf("searching John 3:16: %d, %+v", code, found)
+	}
+	if code := getJSON(t, r, 1, "/api/verses/saved/search?q=John+3", &found); code != http.StatusOK || len(found) != 2 {
+		t.Errorf("searching John 3: %d, %d verses, want 2", code, len(found))
+	}
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"theword/Backend/lib/models"
+)
+
+// ResolveChurchAccess works out the user's standing in a church. Members
+// belong to the church; leaders are its admins.
+func ResolveChurchAccess(db *gorm.DB, userID, churchID uint) models.Access {
+	access := models.Access{UserID: userID, ChurchID: churchID}
+
+	var user models.User
+	if err := db.First(&user, "user_id = ?", userID).Error; err != nil {
+		return access
+	}
+
+	if churchID != 0 && user.ChurchID == churchID {
+		access.IsMember = true
+		access.IsLeader = user.IsAdmin
+	}
+	return access
+}
+
+// ResolveGroupAccess works out the user's standing in a small group. Group
+// leaders, co-leaders and the admins of the group's church count as leaders.
+// Pending and waitlisted members aren't members yet.
+func ResolveGroupAccess(db *gorm.DB, userID, groupID uint) (models.Access, error) {
+	var group models.SmallGroup
+	if err := db.First(&group, "group_id = ?", groupID).Error; err != nil {
+		return models.Access{UserID: userID, GroupID: groupID}, err
+	}
+
+	church := ResolveChurchAccess(db, userID, group.ChurchID)
+	access := models.Access{
+		UserID:   userID,
+		ChurchID: group.ChurchID,
+		GroupID:  group.GroupID,
+		IsLeader: church.IsLeader || group.LeaderID == userID,
+	}
+
+	var member models.GroupMember
+	if err := db.Where("group_id = ? AND user_id = ? AND status = ?", group.GroupID, userID, models.GroupMemberActive).First(&member).Error; err == nil {
+		access.IsMember = true
+		access.IsLeader = access.IsLeader || member.IsLeader()
+	}
+	return access, nil
+}
+
+// ChurchAccess resolves access to the church in the :id param.
+func ChurchAccess(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		churchID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid church ID"})
+			c.Abort()
+			return
+		}
+
+		var church models.Church
+		if err := db.First(&church, "church_id = ?", churchID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Church not found"})
+			c.Abort()
+			return
+		}
+
+		c.Set("access", ResolveChurchAccess(db, c.MustGet("userID").(uint), church.ChurchID))
+		c.Next()
+	}
+}
+
+// GroupAccess resolves access to the small group in the :id param.
+func GroupAccess(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		groupID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+			c.Abort()
+			return
+		}
+
+		access, err := ResolveGroupAccess(db, c.MustGet("userID").(uint), uint(groupID))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+			c.Abort()
+			return
+		}
+
+		c.Set("access", access)
+		c.Next()
+	}
+}
+
+// MembersOnly rejects users who aren't members or leaders of the board
+// resolved by ChurchAccess or GroupAccess.
+func MembersOnly(c *gin.Context) {
+	access := c.MustGet("access").(models.Access)
+	if !access.CanReadBoard() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Members only"})
+		c.Abort()
+		return
+	}
+	c.Next()
+}
@@ -7,4 +7,17 @@ type Claims struct {
 	jwt.StandardClaims
 }
 
+// Access describes what the requesting user may see on a church or group
+// board. It is resolved by the access middleware and stored as "access".
+type Access struct {
+	UserID   uint `json:"user_id"`
+	ChurchID uint `json:"church_id"`
+	GroupID  uint `json:"group_id"`
+	IsMember bool `json:"is_member"`
+	IsLeader bool `json:"is_leader"`
+}
 
+// CanReadBoard reports whether the user can read members-only content.
+func (a Access) CanReadBoard() bool {
+	return a.IsMember || a.IsLeader
+}
@@ -0,0 +1,168 @@
+package models
+
+import "time"
+
+// BibleTranslation is a translation whose text is stored in the database,
+// imported with cmd/import-bible, rather than fetched from an upstream API.
+type BibleTranslation struct {
+	TranslationID string    `gorm:"primaryKey" json:"id"` // e.g. "KJV"
+	Name          string    `json:"name"`
+	Abbreviation  string    `json:"abbreviation"`
+	Language      string    `json:"language"`      // ISO 639-3, e.g. "eng"
+	Format        string    `json:"format"`        // source format: usfm, osis or usx
+	Versification string    `json:"versification"` // verse numbering: english or hebrew
+	ImportedAt    time.Time `json:"imported_at"`
+}
+
+// BibleCatalogEntry is a translation in the catalog readers choose from,
+// stored or upstream, with its publisher's terms. Site admins curate it;
+// disabled translations are neither listed nor served.
+type BibleCatalogEntry struct {
+	TranslationID  string    `gorm:"primaryKey" json:"id"` // e.g. "ESV"
+	Name           string    `json:"name"`
+	Abbreviation   string    `json:"abbreviation"`
+	Language       string    `json:"language"` // ISO 639-3, e.g. "eng"
+	Provider       string    `json:"provider"` // local, esv or api.bible
+	Copyright      string    `json:"copyright"`
+	LicenseURL     string    `json:"license_url"`
+	MaxVerses      int       `json:"max_verses"`       // most verses shown at once, 0 for no limit
+	CacheAllowed   bool      `json:"cache_allowed"`    // text may be cached at all
+	CacheMaxVerses int       `json:"cache_max_verses"` // most verses cached at once, 0 for no limit
+	CachePersist   bool      `json:"cache_persist"`    // cached text may be kept in the database
+	Enabled        bool      `json:"enabled"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type BibleBook struct {
+	ID            uint   `gorm:"primaryKey" json:"-"`
+	TranslationID string `gorm:"uniqueIndex:idx_bible_book" json:"translation_id"`
+	BookID        string `gorm:"uniqueIndex:idx_bible_book" json:"book_id"` // USFM code
+	Name          string `json:"name"`
+	Position      int    `json:"position"`
+	Chapters      int    `json:"chapters"`
+}
+
+// BibleVerse is one verse of a stored translation, with the layout that
+// precedes it in the source text.
+type BibleVerse struct {
+	ID            uint   `gorm:"primaryKey" json:"-"`
+	TranslationID string `gorm:"uniqueIndex:idx_bible_verse;index:idx_bible_verse_chapter" json:"translation_id"`
+	VerseID       string `gorm:"uniqueIndex:idx_bible_verse" json:"verse_id"`     // e.g. "JHN.3.16"
+	ChapterID     string `gorm:"index:idx_bible_verse_chapter" json:"chapter_id"` // e.g. "JHN.3"
+	BookID        string `json:"book_id"`
+	Chapter       int    `json:"chapter"`
+	Verse         int    `json:"verse"`
+	Text          string `json:"text"`
+	Heading       string `json:"heading,omitempty"` // section heading before the verse
+	Paragraph     bool   `json:"paragraph"`         // verse starts a new paragraph
+	Indent        int    `json:"indent"`            // poetry level, 0 for prose
+	// Segments splits Text where words of Jesus, footnote calls and line
+	// breaks fall, for the verses that have any.
+	Segments  []BibleSegment `gorm:"serializer:json" json:"segments,omitempty"`
+	Footnotes []string       `gorm:"serializer:json" json:"footnotes,omitempty"`
+}
+
+// BibleSegment is a run of verse text. One with a Footnote number has no
+// text and marks where that footnote, counted from 1 within the verse, is
+// called. One with Break starts a new line: poetry of level Indent, or a
+// prose paragraph when Indent is 0.
+type BibleSegment struct {
+	Text         string `json:"text,omitempty"`
+	WordsOfJesus bool   `json:"words_of_jesus,omitempty"`
+	Footnote     int    `json:"footnote,omitempty"`
+	Break        bool   `json:"break,omitempty"`
+	Indent       int    `json:"indent,omitempty"`
+}
+
+// BibleCacheEntry is an upstream scripture response kept so it survives
+// restarts. Only responses whose licence allows storage are written here.
+type BibleCacheEntry struct {
+	CacheKey      string    `gorm:"primaryKey" json:"cache_key"` // e.g. "books:de4e12af7f28f599-02"
+	TranslationID string    `gorm:"index" json:"translation_id"`
+	Value         []byte    `json:"-"` // the response as JSON
+	Verses        int       `json:"verses"`
+	ExpiresAt     time.Time `gorm:"index" json:"expires_at"`
+}
+
+// BibleSearchVerse is a verse in the full-text search index, from a stored
+// translation or from cached upstream text. On Postgres the table also has
+// a tsvector column, search, which GORM does not manage.
+type BibleSearchVerse struct {
+	ID            uint   `gorm:"primaryKey" json:"-"`
+	TranslationID string `gorm:"index:idx_bible_search_chapter" json:"translation_id"`
+	ChapterID     string `gorm:"index:idx_bible_search_chapter" json:"chapter_id"`
+	VerseID       string `json:"verse_id"`
+	BookID        string `gorm:"index" json:"book_id"`
+	Position      int    `json:"-"` // the book's place in the canon, for ordering
+	Chapter       int    `json:"chapter"`
+	Verse         int    `json:"verse"`
+	Text          string `json:"text"`
+	// Terms is Text as stemmed words between spaces, searched where the
+	// database has no full-text search.
+	Terms     string     `json:"-"`
+	Config    string     `json:"-"` // Postgres text search configuration, e.g. "english"
+	Cached    bool       `json:"cached"`
+	ExpiresAt *time.Time `gorm:"index" json:"-"` // when cached text must be dropped
+}
+
+// BibleCrossReference links a verse to a related verse or passage, from an
+// open dataset such as OpenBible.info's cross-references. Votes is how many
+// readers found the two related.
+type BibleCrossReference struct {
+	ID        uint   `gorm:"primaryKey" json:"-"`
+	Source    string `gorm:"index" json:"source"` // e.g. "openbible"
+	BookID    string `gorm:"index:idx_bible_cross_reference_from" json:"book_id"`
+	Chapter   int    `gorm:"index:idx_bible_cross_reference_from" json:"chapter"`
+	Verse     int    `gorm:"index:idx_bible_cross_reference_from" json:"verse"`
+	Reference string `json:"reference"` // e.g. "ROM.5.8-ROM.5.9"
+	Votes     int    `json:"votes"`
+}
+
+// BibleTopic is an entry in the topical index, e.g. "Love".
+type BibleTopic struct {
+	TopicID uint   `gorm:"primaryKey" json:"topic_id"`
+	Slug    string `gorm:"uniqueIndex" json:"slug"`
+	Name    string `json:"name"`
+	Verses  int    `json:"verses"`
+}
+
+// BibleTopicVerse is a key verse or passage for a topic, ranked by Votes.
+// BookID, Chapter and Verse are where it starts.
+type BibleTopicVerse struct {
+	ID        uint   `gorm:"primaryKey" json:"-"`
+	TopicID   uint   `gorm:"index" json:"topic_id"`
+	BookID    string `gorm:"index:idx_bible_topic_verse_from" json:"book_id"`
+	Chapter   int    `gorm:"index:idx_bible_topic_verse_from" json:"chapter"`
+	Verse     int    `gorm:"index:idx_bible_topic_verse_from" json:"verse"`
+	Reference string `json:"reference"` // e.g. "1JN.4.7-1JN.4.8"
+	Votes     int    `json:"votes"`
+}
+
+// BibleWord is one original-language word of a verse, from an interlinear
+// dataset, with its Strong's number.
+type BibleWord struct {
+	ID              uint   `gorm:"primaryKey" json:"-"`
+	VerseID         string `gorm:"index" json:"verse_id"` // e.g. "JHN.3.16"
+	BookID          string `gorm:"index" json:"-"`
+	Canon           int    `json:"-"` // the book's place in the canon, for ordering
+	Chapter         int    `json:"-"`
+	Verse           int    `json:"-"`
+	Position        int    `json:"position"` // the word's place in the verse, from 1
+	Word            string `json:"word"`
+	Transliteration string `json:"transliteration"`
+	Lemma           string `json:"lemma"`
+	Strongs         string `gorm:"index" json:"strongs"` // e.g. "G25" or "H157"
+	Morphology      string `json:"morphology"`           // e.g. "V-AAI-3S"
+	Gloss           string `json:"gloss"`
+}
+
+// BibleLexiconEntry is a Strong's dictionary entry.
+type BibleLexiconEntry struct {
+	Strongs         string `gorm:"primaryKey" json:"strongs"` // e.g. "G25"
+	Lemma           string `json:"lemma"`
+	Transliteration string `json:"transliteration"`
+	Pronunciation   string `json:"pronunciation"`
+	Derivation      string `json:"derivation"`
+	Definition      string `json:"definition"`
+	KJVUsage        string `json:"kjv_usage"` // how the KJV renders it
+}
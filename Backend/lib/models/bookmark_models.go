@@ -1,12 +1,35 @@
 package models
 
+import "time"
+
 type Bookmark struct {
-	BookmarkID      uint   `gorm:"primaryKey" json:"bookmark_id"`
-	UserID          uint   `json:"user_id"`
-	ChapterID       string `json:"chapter_id"`
-	BookName        string `json:"book_name"`
-	ChapterName     string `json:"chapter_name"`
-	TranslationID   string `json:"translation_id"`
-	TranslationName string `json:"translation_name"`
-	BookID          string `json:"book_id"`
+	BookmarkID      uint      `gorm:"primaryKey" json:"bookmark_id"`
+	UserID          uint      `gorm:"index" json:"user_id"`
+	ChapterID       string    `json:"chapter_id"`
+	BookName        string    `json:"book_name"`
+	ChapterName     string    `json:"chapter_name"`
+	TranslationID   string    `json:"translation_id"`
+	TranslationName string    `json:"translation_name"`
+	BookID          string    `json:"book_id"`
+	VerseStart      int       `json:"verse_start"` // 0 bookmarks the whole chapter
+	VerseEnd        int       `json:"verse_end"`
+	Reference       string    `gorm:"-" json:"reference"` // e.g. "John 3:16–18"; may be sent instead of the fields above
+	Title           string    `json:"title"`
+	Note            string    `json:"note"`
+	Color           string    `json:"color"`
+	Tags            []string  `gorm:"serializer:json" json:"tags"`
+	FolderID        *uint     `gorm:"index" json:"folder_id"`
+	Position        int       `json:"position"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type BookmarkFolder struct {
+	FolderID  uint      `gorm:"primaryKey" json:"folder_id"`
+	UserID    uint      `gorm:"index" json:"user_id"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color"`
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -31,31 +31,100 @@ type ChurchEvent struct {
 	ChurchID    uint `gorm:"index"`
 	GroupID     uint `gorm:"index"`
 	GroupName   string
+	IsPublic    bool `gorm:"default:false"` // visible to people outside the church/group
 	CreatedBy   uint `gorm:"index"`
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 }
 
 type Message struct {
-	MessageID uint `gorm:"primaryKey"`
-	Content   string
-	Title     string
-	ChurchID  uint `gorm:"index"`
-	GroupID   uint `gorm:"index"`
-	CreatedBy uint `gorm:"index"`
-	Username  string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	MessageID       uint `gorm:"primaryKey"`
+	Content         string
+	Title           string
+	ChurchID        uint  `gorm:"index"`
+	GroupID         uint  `gorm:"index"`
+	ParentMessageID *uint `gorm:"index"`         // set on replies, nil for top-level posts
+	StudySessionID  *uint `gorm:"index"`         // set on posts in a study session's discussion
+	IsPublic        bool  `gorm:"default:false"` // visible to people outside the church/group
+	CreatedBy       uint  `gorm:"index"`
+	Username        string
+	IsPinned        bool `gorm:"default:false"`
+	PinnedBy        uint
+	PinnedAt        *time.Time
+	EditedAt        *time.Time // non-nil once the author has edited the message
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
 }
 
+type MessageReaction struct {
+	ReactionID uint      `gorm:"primaryKey" json:"reaction_id"`
+	MessageID  uint      `gorm:"index" json:"message_id"`
+	UserID     uint      `gorm:"index" json:"user_id"`
+	Emoji      string    `json:"emoji"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type ReactionCount struct {
+	Emoji   string `json:"emoji"`
+	Count   int    `json:"count"`
+	Reacted bool   `json:"reacted"` // whether the requesting user used this emoji
+}
+
+type MessageWithMeta struct {
+	Message
+	ReplyCount int             `json:"reply_count"`
+	Reactions  []ReactionCount `json:"reactions"`
+}
+
+// Prayer request statuses
+const (
+	PrayerStatusActive   = "active"
+	PrayerStatusAnswered = "answered"
+	PrayerStatusArchived = "archived"
+)
+
+// Prayer request visibility levels
+const (
+	PrayerVisibilityChurch     = "church"      // everyone who can read the board
+	PrayerVisibilityGroup      = "group"       // members of the group the request was posted in
+	PrayerVisibilityLeaders    = "leaders"     // church leaders / group leaders only
+	PrayerVisibilityPrayerTeam = "prayer_team" // the church prayer team (and leaders)
+)
+
 type PrayerRequest struct {
-	RequestID   uint `gorm:"primaryKey"`
-	Content     string
-	IsAnonymous bool
-	ChurchID    uint `gorm:"index"`
-	GroupID     uint `gorm:"index"`
-	CreatedBy   uint `gorm:"index"`
-	Username    string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	RequestID      uint `gorm:"primaryKey"`
+	Content        string
+	IsAnonymous    bool
+	ChurchID       uint `gorm:"index"`
+	GroupID        uint `gorm:"index"`
+	CreatedBy      uint `gorm:"index"`
+	Username       string
+	Visibility     string `gorm:"default:church"`
+	AuthorSalt     string `json:"-"`                           // anonymous requests identify their author by
+	AuthorToken    string `json:"-"`                           // an HMAC of the user ID instead of CreatedBy
+	TokenVersion   int    `json:"-" gorm:"not null;default:0"` // which key AuthorToken is keyed with
+	IsAuthor       bool   `gorm:"-"`                           // set per viewer when listing
+	Status         string `gorm:"index;default:active"`
+	PrayerCount    int    `gorm:"default:0"`
+	NotifyOnPrayer bool   // author wants a notification when someone prays
+	PraiseReport   string
+	AnsweredAt     *time.Time
+	ArchivedAt     *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type PrayerIntercession struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	RequestID uint      `gorm:"index" json:"request_id"`
+	UserID    uint      `gorm:"index" json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PrayerUpdate struct {
+	UpdateID  uint      `gorm:"primaryKey" json:"update_id"`
+	RequestID uint      `gorm:"index" json:"request_id"`
+	Content   string    `json:"content"`
+	CreatedBy uint      `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
 }
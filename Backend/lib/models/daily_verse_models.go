@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// DailyVerseOverride replaces the verse of the day for a church's members on
+// one date. Church leaders set it, e.g. to match Sunday's sermon.
+type DailyVerseOverride struct {
+	OverrideID uint      `gorm:"primaryKey" json:"override_id"`
+	ChurchID   uint      `gorm:"uniqueIndex:idx_daily_verse_church_date" json:"church_id"`
+	Date       string    `gorm:"uniqueIndex:idx_daily_verse_church_date" json:"date"` // YYYY-MM-DD
+	Reference  string    `json:"reference"`                                           // e.g. "JHN.3.16-JHN.3.17"
+	Note       string    `json:"note"`
+	CreatedBy  uint      `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Highlight colours a single verse for a user in one translation.
+type Highlight struct {
+	HighlightID   uint      `gorm:"primaryKey" json:"highlight_id"`
+	UserID        uint      `gorm:"uniqueIndex:idx_highlight_user_verse;index:idx_highlight_user_chapter" json:"user_id"`
+	TranslationID string    `gorm:"uniqueIndex:idx_highlight_user_verse;index:idx_highlight_user_chapter" json:"translation_id"`
+	ChapterID     string    `gorm:"index:idx_highlight_user_chapter" json:"chapter_id"`   // e.g. "JHN.3"
+	VerseID       string    `gorm:"uniqueIndex:idx_highlight_user_verse" json:"verse_id"` // e.g. "JHN.3.16"
+	Color         int       `json:"color"`                                                // same encoding as User.HighlightColor
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
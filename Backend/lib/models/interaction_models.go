@@ -20,10 +20,11 @@ type Comment struct {
 }
 
 type Notification struct {
-	NotificationID uint      `gorm:"primaryKey"`
-	UserID         uint      `gorm:"not null"`
-	Content        string    `gorm:"not null"`
-	UserVerseID    int       `gorm:"index"`
-	CommentID      *uint     `gorm:"index"`
-	CreatedAt      time.Time `gorm:"autoCreateTime"`
+	NotificationID  uint      `gorm:"primaryKey"`
+	UserID          uint      `gorm:"not null"`
+	Content         string    `gorm:"not null"`
+	UserVerseID     int       `gorm:"index"`
+	CommentID       *uint     `gorm:"index"`
+	PrayerRequestID *uint     `gorm:"index"`
+	CreatedAt       time.Time `gorm:"autoCreateTime"`
 }
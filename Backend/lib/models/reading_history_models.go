@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// ChapterRead records a user opening a chapter. Repeat visits to the same
+// chapter in one sitting update the row's position instead of adding rows.
+type ChapterRead struct {
+	ReadID          uint      `gorm:"primaryKey" json:"read_id"`
+	UserID          uint      `gorm:"index" json:"user_id"`
+	TranslationID   string    `gorm:"index" json:"translation_id"`
+	TranslationName string    `json:"translation_name"`
+	BookID          string    `gorm:"index" json:"book_id"`
+	ChapterID       string    `json:"chapter_id"`    // e.g. "JHN.3"
+	Verse           int       `json:"verse"`         // last verse in view, 0 if unknown
+	ScrollOffset    float64   `json:"scroll_offset"` // client scroll position within the chapter
+	ReadAt          time.Time `gorm:"index" json:"read_at"`
+}
+
+type BookReadingStats struct {
+	BookID        string  `json:"book_id"`
+	ChaptersRead  int     `json:"chapters_read"`
+	TotalChapters int     `json:"total_chapters"`
+	Percent       float64 `json:"percent"`
+}
+
+type ReadingStats struct {
+	ChaptersRead  int                `json:"chapters_read"`
+	TotalChapters int                `json:"total_chapters"`
+	Percent       float64            `json:"percent"`
+	TotalReads    int64              `json:"total_reads"`
+	Books         []BookReadingStats `json:"books"`
+}
@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// ReadingPlan is a schedule of daily chapter readings. Built-in plans are
+// seeded at startup; anyone can create a custom plan.
+type ReadingPlan struct {
+	PlanID      uint             `gorm:"primaryKey" json:"plan_id"`
+	Slug        string           `gorm:"index" json:"slug,omitempty"` // set on built-in plans
+	Title       string           `json:"title"`
+	Description string           `json:"description"`
+	TotalDays   int              `json:"total_days"`
+	IsBuiltIn   bool             `gorm:"default:false" json:"is_built_in"`
+	CreatedBy   uint             `gorm:"index" json:"created_by"`
+	Days        []ReadingPlanDay `gorm:"foreignKey:PlanID" json:"days,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// ReadingPlanDay holds one day's readings as chapter references, e.g. "GEN.1".
+type ReadingPlanDay struct {
+	ID         uint     `gorm:"primaryKey" json:"id"`
+	PlanID     uint     `gorm:"index" json:"plan_id"`
+	Day        int      `json:"day"`
+	References []string `gorm:"serializer:json" json:"references"`
+}
+
+// ReadingPlanEnrollment is a user following a plan. Day N is due on
+// StartDate + N-1; catching up moves StartDate forward.
+type ReadingPlanEnrollment struct {
+	EnrollmentID  uint       `gorm:"primaryKey" json:"enrollment_id"`
+	UserID        uint       `gorm:"index" json:"user_id"`
+	PlanID        uint       `gorm:"index" json:"plan_id"`
+	StartDate     time.Time  `json:"start_date"`
+	CurrentStreak int        `json:"current_streak"`
+	LongestStreak int        `json:"longest_streak"`
+	LastReadOn    *time.Time `json:"last_read_on"` // calendar day of the latest completed reading
+	CompletedAt   *time.Time `json:"completed_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type ReadingPlanProgress struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	EnrollmentID uint      `gorm:"uniqueIndex:idx_reading_progress_enrollment_day" json:"enrollment_id"`
+	Day          int       `gorm:"uniqueIndex:idx_reading_progress_enrollment_day" json:"day"`
+	CompletedAt  time.Time `json:"completed_at"`
+}
+
+type ReadingPlanSummary struct {
+	ReadingPlanEnrollment
+	Title         string `json:"title"`
+	TotalDays     int    `json:"total_days"`
+	CompletedDays int    `json:"completed_days"`
+	CurrentDay    int    `json:"current_day"` // the day scheduled for today
+	DaysBehind    int    `json:"days_behind"` // past days not yet read
+	NextDay       int    `json:"next_day"`    // first unread day, 0 once finished
+}
+
+type ReadingPlanDayStatus struct {
+	ReadingPlanDay
+	DueOn     time.Time `json:"due_on"`
+	Completed bool      `json:"completed"`
+}
+
+type FriendReadingProgress struct {
+	UserID        uint   `json:"user_id"`
+	Username      string `json:"username"`
+	AvatarURL     string `json:"avatar_url"`
+	CompletedDays int    `json:"completed_days"`
+	CurrentStreak int    `json:"current_streak"`
+	DaysBehind    int    `json:"days_behind"`
+}
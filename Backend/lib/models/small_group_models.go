@@ -10,17 +10,72 @@ type SmallGroup struct {
 	MeetingDay      string    `json:"meeting_day"`
 	MeetingTime     string    `json:"meeting_time"`
 	MeetingLocation string    `json:"meeting_location"`
+	MeetingFormat   string    `json:"meeting_format"` // "in_person", "online" or "hybrid"
+	LifeStage       string    `json:"life_stage"`     // e.g. "young_adults", "married", "parents", "seniors"
+	Topic           string    `json:"topic"`          // e.g. "bible_study", "prayer", "recovery"
+	Language        string    `json:"language"`
+	Childcare       bool      `json:"childcare"`
 	LeaderID        uint      `gorm:"index" json:"leader_id"`
 	MemberCount     int       `json:"member_count"`
-	AvatarURL       string    `json:"avatar_url"` // 🆕 <- ADD THIS
+	MaxMembers      int       `json:"max_members"` // 0 means no limit
+	RequireApproval bool      `json:"require_approval"`
+	IsPublic        bool      `gorm:"default:false" json:"is_public"` // listed to people outside the church
+	AvatarURL       string    `json:"avatar_url"`                     // 🆕 <- ADD THIS
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
 }
 
+// Group meeting formats
+const (
+	MeetingInPerson = "in_person"
+	MeetingOnline   = "online"
+	MeetingHybrid   = "hybrid"
+)
+
+// GroupPreference holds what a user is looking for in a small group. It
+// drives the "recommended for you" ranking.
+type GroupPreference struct {
+	UserID         uint      `gorm:"primaryKey" json:"user_id"`
+	LifeStage      string    `json:"life_stage"`
+	Topics         string    `json:"topics"`       // comma-separated
+	MeetingDays    string    `json:"meeting_days"` // comma-separated, e.g. "Tuesday,Thursday"
+	MeetingFormat  string    `json:"meeting_format"`
+	Language       string    `json:"language"`
+	NeedsChildcare bool      `json:"needs_childcare"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type GroupRecommendation struct {
+	SmallGroup
+	Score        float64  `json:"score"`
+	FriendsCount int      `json:"friends_count"`
+	Reasons      []string `json:"reasons"`
+}
+
+// Group member roles
+const (
+	GroupRoleMember   = "member"
+	GroupRoleCoLeader = "co_leader"
+	GroupRoleLeader   = "leader"
+)
+
+// Group member statuses
+const (
+	GroupMemberActive     = "active"
+	GroupMemberPending    = "pending"    // waiting for a leader to approve
+	GroupMemberWaitlisted = "waitlisted" // approved, waiting for a free spot
+)
+
 type GroupMember struct {
 	ID       uint      `gorm:"primaryKey" json:"id"`
-	GroupID  uint      `gorm:"index" json:"group_id"`
-	UserID   uint      `gorm:"index" json:"user_id"`
-	Role     string    `json:"role"` // e.g., "member", "leader"
+	GroupID  uint      `gorm:"uniqueIndex:idx_group_member" json:"group_id"`
+	UserID   uint      `gorm:"uniqueIndex:idx_group_member;index" json:"user_id"`
+	Role     string    `json:"role"` // e.g., "member", "co_leader", "leader"
+	Status   string    `gorm:"index;default:active" json:"status"`
 	JoinedAt time.Time `json:"joined_at"`
 }
+
+// IsLeader reports whether the member leads or co-leads the group.
+func (m GroupMember) IsLeader() bool {
+	return m.Status == GroupMemberActive && (m.Role == GroupRoleLeader || m.Role == GroupRoleCoLeader)
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// StudyPlan is a schedule of passages a small group works through together.
+type StudyPlan struct {
+	PlanID        uint           `gorm:"primaryKey" json:"plan_id"`
+	GroupID       uint           `gorm:"index" json:"group_id"`
+	Title         string         `json:"title"`
+	Description   string         `json:"description"`
+	TranslationID string         `json:"translation_id"` // default translation for reading the passages
+	CreatedBy     uint           `json:"created_by"`
+	Sessions      []StudySession `gorm:"foreignKey:PlanID" json:"sessions,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// StudySession is one meeting of a study plan. Reference uses the same
+// chapter format as GetBiblePassage, e.g. "JHN.3".
+type StudySession struct {
+	SessionID    uint      `gorm:"primaryKey" json:"session_id"`
+	PlanID       uint      `gorm:"index" json:"plan_id"`
+	GroupID      uint      `gorm:"index" json:"group_id"`
+	Position     int       `json:"position"`
+	Title        string    `json:"title"`
+	Reference    string    `json:"reference"`
+	ScheduledFor time.Time `json:"scheduled_for"`
+	Questions    []string  `gorm:"serializer:json" json:"questions"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// StudyProgress records that a member has completed a session's reading.
+type StudyProgress struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	SessionID   uint      `gorm:"uniqueIndex:idx_study_progress_session_user" json:"session_id"`
+	UserID      uint      `gorm:"uniqueIndex:idx_study_progress_session_user;index" json:"user_id"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+type StudySessionWithProgress struct {
+	StudySession
+	Completed      bool `json:"completed"`       // whether the requesting user has completed it
+	CompletedCount int  `json:"completed_count"` // members who have completed it
+	MessageCount   int  `json:"message_count"`
+}
+
+type StudyMemberProgress struct {
+	UserID    uint   `json:"user_id"`
+	Username  string `json:"username"`
+	Completed int    `json:"completed"`
+	Total     int    `json:"total"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Entities that can be synced offline
+const (
+	SyncEntityBookmark       = "bookmark"
+	SyncEntityBookmarkFolder = "bookmark_folder"
+	SyncEntityHighlight      = "highlight"
+	SyncEntityUserVerse      = "user_verse"
+)
+
+// SyncState holds a user's latest revision. Every synced write bumps it.
+type SyncState struct {
+	UserID   uint   `gorm:"primaryKey"`
+	Revision uint64 `gorm:"default:0"`
+}
+
+// SyncChange is the user's change log, compacted to the latest change per
+// entity. Deleted entities stay in the log as tombstones.
+type SyncChange struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"uniqueIndex:idx_sync_change_entity"`
+	Entity    string `gorm:"uniqueIndex:idx_sync_change_entity"`
+	EntityID  uint   `gorm:"uniqueIndex:idx_sync_change_entity"`
+	Revision  uint64 `gorm:"index"`
+	Deleted   bool   `gorm:"default:false"`
+	ChangedAt time.Time
+}
+
+// SyncItem is one entity's state in a sync delta.
+type SyncItem struct {
+	Entity   string      `json:"entity"`
+	ID       uint        `json:"id"`
+	Revision uint64      `json:"revision"`
+	Deleted  bool        `json:"deleted"`
+	Data     interface{} `json:"data,omitempty"`
+}
+
+// SyncPush is a change made on a device. BaseRevision is the revision the
+// device last saw for the entity, 0 for entities it created. BaseNote is a
+// saved verse's note as of BaseRevision, so the server can tell which sides
+// edited it.
+type SyncPush struct {
+	Entity       string          `json:"entity"`
+	ID           uint            `json:"id"`        // 0 when the device created the entity
+	ClientID     string          `json:"client_id"` // device-side key, echoed back in the result
+	BaseRevision uint64          `json:"base_revision"`
+	BaseNote     *string         `json:"base_note,omitempty"` // saved verses only
+	Deleted      bool            `json:"deleted"`
+	UpdatedAt    time.Time       `json:"updated_at"` // when the device made the change
+	Data         json.RawMessage `json:"data"`
+}
+
+// Sync push outcomes
+const (
+	SyncApplied  = "applied"  // the device's change was stored as sent
+	SyncMerged   = "merged"   // both sides' edits were combined
+	SyncConflict = "conflict" // the server's version was kept; Data holds it
+	SyncRejected = "rejected" // the change was invalid; see Error
+)
+
+type SyncResult struct {
+	Entity   string      `json:"entity"`
+	ClientID string      `json:"client_id,omitempty"`
+	ID       uint        `json:"id"`
+	Revision uint64      `json:"revision"`
+	Status   string      `json:"status"`
+	Deleted  bool        `json:"deleted"`
+	Error    string      `json:"error,omitempty"`
+	Data     interface{} `json:"data,omitempty"`
+}
@@ -26,10 +26,20 @@ type User struct {
 	TranslationId   string
 	TranslationName string
 	IsAdmin         bool `gorm:"default:false"`
+	IsPrayerTeam    bool `gorm:"default:false"`
+	IsSiteAdmin     bool `gorm:"default:false"` // curates site-wide settings such as the translation catalog
 	ChurchID        uint `gorm:"index"`
 	ResetCode       string
 	ResetCodeExpiry time.Time
 	AvatarURL       string
+
+	// Verse of the day notifications go out once DailyVerseHour has passed in
+	// TimeZone (an IANA zone, UTC when empty).
+	TimeZone         string
+	DailyVerseNotify bool   `gorm:"default:false"`
+	DailyVerseEmail  bool   `gorm:"default:false"`
+	DailyVerseHour   int    `gorm:"default:7"`
+	DailyVerseSentOn string // YYYY-MM-DD in TimeZone
 }
 
 type LoginRequest struct {
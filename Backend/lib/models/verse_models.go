@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type UserVerse struct {
 	UserVerseID uint `gorm:"primaryKey"`
 	VerseID     string
@@ -8,6 +10,7 @@ type UserVerse struct {
 	UserID      uint
 	Note        string
 	IsPublished bool `json:"is_published"` // New field added
+	UpdatedAt   time.Time
 }
 
 type UserVerseWithMeta struct {
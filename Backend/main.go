@@ -1,15 +1,20 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
+	"theword/Backend/lib/audio"
+	"theword/Backend/lib/bible"
 	"theword/Backend/lib/database"
 	"theword/Backend/lib/handlers"
 	"theword/Backend/lib/middleware"
@@ -36,13 +41,32 @@ func main() {
 		log.Fatalf("failed to connect to database: %v", err)
 	}
 
-	db.AutoMigrate(&models.Bookmark{}, &models.User{}, &models.UserVerse{}, &models.Like{}, &models.Comment{}, &models.Friend{}, &models.Notification{}, &models.Church{}, &models.SmallGroup{}, &models.ChurchEvent{}, &models.Message{}, &models.PrayerRequest{}, &models.GroupMember{})
+	handlers.RemoveDuplicateGroupMembers(db)
+	db.AutoMigrate(&models.Bookmark{}, &models.User{}, &models.UserVerse{}, &models.Like{}, &models.Comment{}, &models.Friend{}, &models.Notification{}, &models.Church{}, &models.SmallGroup{}, &models.ChurchEvent{}, &models.Message{}, &models.MessageReaction{}, &models.PrayerRequest{}, &models.PrayerIntercession{}, &models.PrayerUpdate{}, &models.GroupMember{}, &models.GroupPreference{}, &models.StudyPlan{}, &models.StudySession{}, &models.StudyProgress{}, &models.ReadingPlan{}, &models.ReadingPlanDay{}, &models.ReadingPlanEnrollment{}, &models.ReadingPlanProgress{}, &models.ChapterRead{}, &models.BookmarkFolder{}, &models.Highlight{}, &models.SyncState{}, &models.SyncChange{}, &models.BibleTranslation{}, &models.BibleBook{}, &models.BibleVerse{}, &models.BibleCacheEntry{}, &models.BibleCrossReference{}, &models.BibleTopic{}, &models.BibleTopicVerse{}, &models.BibleWord{}, &models.BibleLexiconEntry{}, &models.DailyVerseOverride{}, &models.BibleCatalogEntry{})
+	if err := bible.MigrateSearch(db); err != nil {
+		log.Printf("Failed to create the Bible search index: %v", err)
+	}
 	log.Println("Database tables created or already exist.")
 
 	// Seed the database with initial data
 	database.SeedDatabase(db)
 
 	handlers.CreateAdminUser(db)
+	handlers.SyncSiteAdmins(db, os.Getenv("SITE_ADMIN_EMAILS"))
+
+	prayerArchiveDays := 30
+	if days, err := strconv.Atoi(os.Getenv("PRAYER_ARCHIVE_DAYS")); err == nil && days > 0 {
+		prayerArchiveDays = days
+	}
+	handlers.SyncGroupMemberCounts(db)
+	handlers.AnonymizePrayerRequests(db)
+	handlers.SeedReadingPlans(db)
+	handlers.StartPrayerArchiver(db, time.Duration(prayerArchiveDays)*24*time.Hour)
+	go func() {
+		if err := bible.IndexStored(db); err != nil {
+			log.Printf("Failed to index stored Bible translations for search: %v", err)
+		}
+	}()
 
 	r := gin.Default()
 
@@ -101,14 +125,18 @@ func main() {
 
 	// Church routes
 	r.GET("/api/churches", middleware.AuthMiddleware, handlers.GetChurches(db))
-	r.GET("/api/churches/:id", middleware.AuthMiddleware, handlers.GetChurchDetails(db))
+	r.GET("/api/churches/:id", middleware.AuthMiddleware, middleware.ChurchAccess(db), handlers.GetChurchDetails(db))
 	r.POST("/api/churches", middleware.AuthMiddleware, handlers.CreateChurch(db))
 	r.PUT("/api/churches/:id", middleware.AuthMiddleware, handlers.UpdateChurch(db))
 	r.DELETE("/api/churches/:id", middleware.AuthMiddleware, handlers.DeleteChurch(db))
 
 	// Small Group routes
-	r.GET("/api/churches/:id/groups", handlers.GetChurchGroups(db))
-	r.GET("/api/groups/:id", middleware.AuthMiddleware, handlers.GetGroupDetails(db))
+	r.GET("/api/churches/:id/groups", middleware.AuthMiddleware, middleware.ChurchAccess(db), handlers.GetChurchGroups(db))
+	r.GET("/api/churches/:id/groups/search", middleware.AuthMiddleware, middleware.ChurchAccess(db), handlers.SearchChurchGroups(db))
+	r.GET("/api/churches/:id/groups/recommended", middleware.AuthMiddleware, middleware.ChurchAccess(db), handlers.GetRecommendedGroups(db))
+	r.GET("/api/user/group-preferences", middleware.AuthMiddleware, handlers.GetGroupPreferences(db))
+	r.PUT("/api/user/group-preferences", middleware.AuthMiddleware, handlers.UpdateGroupPreferences(db))
+	r.GET("/api/groups/:id", middleware.AuthMiddleware, middleware.GroupAccess(db), handlers.GetGroupDetails(db))
 	r.POST("/api/churches/:id/groups", middleware.AuthMiddleware, handlers.CreateGroup(db))
 	r.PUT("/api/groups/:id", middleware.AuthMiddleware, handlers.UpdateGroup(db))
 	r.DELETE("/api/groups/:id", middleware.AuthMiddleware, handlers.DeleteGroup(db))
@@ -124,12 +152,31 @@ func main() {
 	// Group membership routes
 	r.POST("/api/groups/:id/join", middleware.AuthMiddleware, handlers.JoinGroup(db))
 	r.POST("/api/groups/:id/leave", middleware.AuthMiddleware, handlers.LeaveGroup(db))
+	r.GET("/api/groups/:id/members", middleware.AuthMiddleware, middleware.GroupAccess(db), middleware.MembersOnly, handlers.GetGroupMembers(db))
+	r.DELETE("/api/groups/:id/members/:userId", middleware.AuthMiddleware, middleware.GroupAccess(db), handlers.RemoveGroupMember(db))
+	r.POST("/api/groups/:id/members/:userId/approve", middleware.AuthMiddleware, middleware.GroupAccess(db), handlers.ApproveGroupMember(db))
+	r.PUT("/api/groups/:id/members/:userId/role", middleware.AuthMiddleware, middleware.GroupAccess(db), handlers.UpdateGroupMemberRole(db))
+
+	// Group study plans
+	r.GET("/api/groups/:id/study-plans", middleware.AuthMiddleware, middleware.GroupAccess(db), middleware.MembersOnly, handlers.GetGroupStudyPlans(db))
+	r.POST("/api/groups/:id/study-plans", middleware.AuthMiddleware, middleware.GroupAccess(db), handlers.CreateStudyPlan(db))
+	r.GET("/api/groups/:id/study-plans/:planId", middleware.AuthMiddleware, middleware.GroupAccess(db), middleware.MembersOnly, handlers.GetStudyPlan(db))
+	r.PUT("/api/groups/:id/study-plans/:planId", middleware.AuthMiddleware, middleware.GroupAccess(db), handlers.UpdateStudyPlan(db))
+	r.DELETE("/api/groups/:id/study-plans/:planId", middleware.AuthMiddleware, middleware.GroupAccess(db), handlers.DeleteStudyPlan(db))
+	r.GET("/api/groups/:id/study-plans/:planId/progress", middleware.AuthMiddleware, middleware.GroupAccess(db), handlers.GetStudyPlanProgress(db))
+	r.POST("/api/groups/:id/study-plans/:planId/sessions", middleware.AuthMiddleware, middleware.GroupAccess(db), handlers.AddStudySession(db))
+	r.PUT("/api/groups/:id/study-sessions/:sessionId", middleware.AuthMiddleware, middleware.GroupAccess(db), handlers.UpdateStudySession(db))
+	r.DELETE("/api/groups/:id/study-sessions/:sessionId", middleware.AuthMiddleware, middleware.GroupAccess(db), handlers.DeleteStudySession(db))
+	r.GET("/api/groups/:id/study-sessions/:sessionId/messages", middleware.AuthMiddleware, middleware.GroupAccess(db), middleware.MembersOnly, handlers.GetStudySessionMessages(db))
+	r.POST("/api/groups/:id/study-sessions/:sessionId/messages", middleware.AuthMiddleware, middleware.GroupAccess(db), middleware.MembersOnly, handlers.CreateStudySessionMessage(db))
+	r.POST("/api/groups/:id/study-sessions/:sessionId/complete", middleware.AuthMiddleware, middleware.GroupAccess(db), middleware.MembersOnly, handlers.CompleteStudySession(db))
+	r.DELETE("/api/groups/:id/study-sessions/:sessionId/complete", middleware.AuthMiddleware, middleware.GroupAccess(db), middleware.MembersOnly, handlers.UncompleteStudySession(db))
 
 	// Church Events routes
-	r.GET("/api/churches/:id/events", middleware.AuthMiddleware, handlers.GetChurchEvents(db))
-	r.GET("/api/groups/:id/events", middleware.AuthMiddleware, handlers.GetGroupEvents(db))
-	r.POST("/api/groups/:id/events/create", middleware.AuthMiddleware, handlers.CreateGroupEvent(db))
-	r.POST("/api/churches/:id/events", middleware.AuthMiddleware, handlers.CreateEvent(db))
+	r.GET("/api/churches/:id/events", middleware.AuthMiddleware, middleware.ChurchAccess(db), handlers.GetChurchEvents(db))
+	r.GET("/api/groups/:id/events", middleware.AuthMiddleware, middleware.GroupAccess(db), handlers.GetGroupEvents(db))
+	r.POST("/api/groups/:id/events/create", middleware.AuthMiddleware, middleware.GroupAccess(db), handlers.CreateGroupEvent(db))
+	r.POST("/api/churches/:id/events", middleware.AuthMiddleware, middleware.ChurchAccess(db), handlers.CreateEvent(db))
 	r.PUT("/api/events/:id", middleware.AuthMiddleware, handlers.UpdateEvent(db))
 	r.DELETE("/api/events/:id", middleware.AuthMiddleware, handlers.DeleteEvent(db))
 	// Church message delete
@@ -139,16 +186,31 @@ func main() {
 	r.DELETE("/api/churches/prayers/:requestId", middleware.AuthMiddleware, handlers.DeleteChurchPrayerRequest(db))
 
 	// Messages routes
-	r.GET("/api/churches/:id/messages", middleware.AuthMiddleware, handlers.GetChurchMessages(db))
-	r.GET("/api/groups/:id/messages", middleware.AuthMiddleware, handlers.GetGroupMessages(db))
-	r.POST("/api/churches/:id/messages", middleware.AuthMiddleware, handlers.CreateMessage(db))
-	r.POST("/api/groups/:id/messages", middleware.AuthMiddleware, handlers.CreateGroupMessage(db))
+	r.GET("/api/churches/:id/messages", middleware.AuthMiddleware, middleware.ChurchAccess(db), handlers.GetChurchMessages(db))
+	r.GET("/api/groups/:id/messages", middleware.AuthMiddleware, middleware.GroupAccess(db), handlers.GetGroupMessages(db))
+	r.POST("/api/churches/:id/messages", middleware.AuthMiddleware, middleware.ChurchAccess(db), handlers.CreateMessage(db))
+	r.POST("/api/groups/:id/messages", middleware.AuthMiddleware, middleware.GroupAccess(db), handlers.CreateGroupMessage(db))
+	r.PUT("/api/messages/:id", middleware.AuthMiddleware, handlers.UpdateMessage(db))
+	r.GET("/api/messages/:id/replies", middleware.AuthMiddleware, handlers.GetMessageReplies(db))
+	r.POST("/api/messages/:id/replies", middleware.AuthMiddleware, handlers.ReplyToMessage(db))
+	r.GET("/api/messages/:id/reactions", middleware.AuthMiddleware, handlers.GetMessageReactions(db))
+	r.POST("/api/messages/:id/reactions", middleware.AuthMiddleware, handlers.ToggleMessageReaction(db))
+	r.POST("/api/messages/:id/pin", middleware.AuthMiddleware, handlers.PinMessage(db))
+	r.POST("/api/messages/:id/unpin", middleware.AuthMiddleware, handlers.UnpinMessage(db))
 
 	// Prayer Requests routes
-	r.GET("/api/churches/:id/prayers", middleware.AuthMiddleware, handlers.GetChurchPrayerRequests(db))
-	r.GET("/api/groups/:id/prayers", middleware.AuthMiddleware, handlers.GetGroupPrayerRequests(db))
+	r.GET("/api/churches/:id/prayers", middleware.AuthMiddleware, middleware.ChurchAccess(db), middleware.MembersOnly, handlers.GetChurchPrayerRequests(db))
+	r.GET("/api/groups/:id/prayers", middleware.AuthMiddleware, middleware.GroupAccess(db), middleware.MembersOnly, handlers.GetGroupPrayerRequests(db))
 	r.POST("/api/churches/:id/prayers", middleware.AuthMiddleware, handlers.CreatePrayerRequest(db))
 	r.POST("/api/groups/:id/prayers", middleware.AuthMiddleware, handlers.CreateGroupPrayerRequest(db))
+	r.PUT("/api/prayers/:id", middleware.AuthMiddleware, handlers.UpdatePrayerRequest(db))
+	r.GET("/api/churches/:id/prayer-team", middleware.AuthMiddleware, middleware.ChurchAccess(db), middleware.MembersOnly, handlers.GetPrayerTeam(db))
+	r.POST("/api/churches/:id/prayer-team/:userId", middleware.AuthMiddleware, handlers.AddPrayerTeamMember(db))
+	r.DELETE("/api/churches/:id/prayer-team/:userId", middleware.AuthMiddleware, handlers.RemovePrayerTeamMember(db))
+	r.POST("/api/prayers/:id/pray", middleware.AuthMiddleware, handlers.PrayForRequest(db))
+	r.GET("/api/prayers/:id/updates", middleware.AuthMiddleware, handlers.GetPrayerUpdates(db))
+	r.POST("/api/prayers/:id/updates", middleware.AuthMiddleware, handlers.AddPrayerUpdate(db))
+	r.POST("/api/prayers/:id/answered", middleware.AuthMiddleware, handlers.MarkPrayerAnswered(db))
 
 	// Church Leader routes
 	r.POST("/api/church-leaders", handlers.CreateChurchLeader(db))
@@ -164,10 +226,43 @@ func main() {
 	r.POST("/api/chat/stream", middleware.AuthMiddleware, handlers.StreamChatResponse(chatApiKey))
 
 	//bible routes:
-	r.GET("/api/bible/translations", handlers.GetBibleTranslations(bibleApiKey))
-	r.GET("/api/bible/:bibleId/books", handlers.GetBibleBooks(bibleApiKey))
-	r.GET("/api/bible/:bibleId/books/:bookId/chapters", handlers.GetBibleChapters(bibleApiKey))
-	r.GET("/api/passage/:translationId", handlers.GetBiblePassage(bibleApiKey, esvApiKey))
+	bibles := bible.NewProviders(db, bibleApiKey, esvApiKey, bibleCache())
+	if err := bibles.LoadCatalog(context.Background()); err != nil {
+		log.Printf("Failed to load the translation catalog: %v", err)
+	}
+	go func() {
+		if err := bibles.SyncCatalog(context.Background()); err != nil {
+			log.Printf("Failed to update the translation catalog: %v", err)
+		}
+	}()
+	r.GET("/api/bible/translations", handlers.GetBibleTranslations(bibles))
+	r.GET("/api/bible/compare", handlers.CompareBiblePassage(bibles))
+	r.GET("/api/bible/search", handlers.SearchBible(db, bibles))
+	r.GET("/api/bible/cross-references", handlers.GetCrossReferences(db, bibles))
+	r.GET("/api/bible/topics", handlers.GetBibleTopics(db))
+	r.GET("/api/bible/topics/:slug", handlers.GetBibleTopic(db, bibles))
+	r.GET("/api/bible/words", handlers.GetBibleWords(db))
+	r.GET("/api/bible/lexicon/:strongs", handlers.GetLexiconEntry(db))
+	r.GET("/api/bible/concordance/:strongs", handlers.GetConcordance(db, bibles))
+	r.GET("/api/bible/:bibleId/books", handlers.GetBibleBooks(bibles))
+	r.GET("/api/bible/:bibleId/books/:bookId/chapters", handlers.GetBibleChapters(bibles))
+	r.GET("/api/bible/:bibleId/passage", handlers.GetBiblePassageRange(bibles))
+	r.GET("/api/passage/:translationId", handlers.GetBiblePassage(bibles))
+	r.GET("/api/admin/bible/translations", middleware.AuthMiddleware, handlers.GetTranslationCatalog(db))
+	r.PUT("/api/admin/bible/translations/:id", middleware.AuthMiddleware, handlers.UpdateTranslationCatalog(db, bibles))
+
+	// Audio Bible
+	audioBible := audioLibrary()
+	r.GET("/api/bible/:bibleId/audio/:chapterId", handlers.GetChapterAudio(audioBible))
+	r.GET("/api/bible/:bibleId/audio/:chapterId/stream", handlers.StreamChapterAudio(audioBible))
+	r.HEAD("/api/bible/:bibleId/audio/:chapterId/stream", handlers.StreamChapterAudio(audioBible))
+
+	// Verse of the day
+	r.GET("/api/daily-verse", middleware.AuthMiddleware, handlers.GetDailyVerse(db, bibles))
+	r.GET("/api/churches/:id/daily-verses", middleware.AuthMiddleware, handlers.GetChurchDailyVerses(db))
+	r.PUT("/api/churches/:id/daily-verses/:date", middleware.AuthMiddleware, handlers.SetChurchDailyVerse(db))
+	r.DELETE("/api/churches/:id/daily-verses/:date", middleware.AuthMiddleware, handlers.DeleteChurchDailyVerse(db))
+	handlers.StartDailyVerseNotifier(db, bibles)
 
 	// Public profile route (new)
 	r.GET("/api/users/:id", middleware.AuthMiddleware, handlers.GetUserByID(db))
@@ -179,7 +274,42 @@ func main() {
 
 	r.POST("/api/bookmarks", middleware.AuthMiddleware, handlers.CreateBookmark(db))
 	r.GET("/api/bookmarks", middleware.AuthMiddleware, handlers.GetBookmarks(db))
+	r.PUT("/api/bookmarks/order", middleware.AuthMiddleware, handlers.ReorderBookmarks(db))
+	r.PUT("/api/bookmarks/:id", middleware.AuthMiddleware, handlers.UpdateBookmark(db))
 	r.DELETE("/api/bookmarks/:id", middleware.AuthMiddleware, handlers.DeleteBookmark(db))
+	r.GET("/api/bookmark-folders", middleware.AuthMiddleware, handlers.GetBookmarkFolders(db))
+	r.POST("/api/bookmark-folders", middleware.AuthMiddleware, handlers.CreateBookmarkFolder(db))
+	r.PUT("/api/bookmark-folders/order", middleware.AuthMiddleware, handlers.ReorderBookmarkFolders(db))
+	r.PUT("/api/bookmark-folders/:id", middleware.AuthMiddleware, handlers.UpdateBookmarkFolder(db))
+	r.DELETE("/api/bookmark-folders/:id", middleware.AuthMiddleware, handlers.DeleteBookmarkFolder(db))
+
+	r.POST("/api/highlights", middleware.AuthMiddleware, handlers.CreateHighlights(db))
+	r.DELETE("/api/highlights", middleware.AuthMiddleware, handlers.DeleteHighlights(db))
+	r.GET("/api/highlights/chapter/:chapterId", middleware.AuthMiddleware, handlers.GetChapterHighlights(db))
+
+	r.GET("/api/sync", middleware.AuthMiddleware, handlers.GetSyncChanges(db))
+	r.POST("/api/sync", middleware.AuthMiddleware, handlers.PushSyncChanges(db))
+
+	// Reading history
+	r.POST("/api/reading/history", middleware.AuthMiddleware, handlers.RecordChapterRead(db))
+	r.GET("/api/reading/history", middleware.AuthMiddleware, handlers.GetReadingHistory(db))
+	r.DELETE("/api/reading/history", middleware.AuthMiddleware, handlers.ClearReadingHistory(db))
+	r.GET("/api/reading/resume", middleware.AuthMiddleware, handlers.GetResumePositions(db))
+	r.GET("/api/reading/stats", middleware.AuthMiddleware, handlers.GetReadingStats(db))
+
+	// Reading plans
+	r.GET("/api/reading-plans", middleware.AuthMiddleware, handlers.GetReadingPlans(db))
+	r.POST("/api/reading-plans", middleware.AuthMiddleware, handlers.CreateReadingPlan(db))
+	r.GET("/api/reading-plans/:id", middleware.AuthMiddleware, handlers.GetReadingPlan(db))
+	r.DELETE("/api/reading-plans/:id", middleware.AuthMiddleware, handlers.DeleteReadingPlan(db))
+	r.POST("/api/reading-plans/:id/join", middleware.AuthMiddleware, handlers.JoinReadingPlan(db))
+	r.GET("/api/reading-plans/:id/friends", middleware.AuthMiddleware, handlers.GetReadingPlanFriends(db))
+	r.GET("/api/user/reading-plans", middleware.AuthMiddleware, handlers.GetMyReadingPlans(db))
+	r.GET("/api/user/reading-plans/:enrollmentId", middleware.AuthMiddleware, handlers.GetMyReadingPlan(db))
+	r.DELETE("/api/user/reading-plans/:enrollmentId", middleware.AuthMiddleware, handlers.LeaveReadingPlan(db))
+	r.POST("/api/user/reading-plans/:enrollmentId/days/:day/complete", middleware.AuthMiddleware, handlers.CompleteReadingDay(db))
+	r.DELETE("/api/user/reading-plans/:enrollmentId/days/:day/complete", middleware.AuthMiddleware, handlers.UncompleteReadingDay(db))
+	r.POST("/api/user/reading-plans/:enrollmentId/catch-up", middleware.AuthMiddleware, handlers.CatchUpReadingPlan(db))
 
 	r.Run()
 }
@@ -196,3 +326,56 @@ func unique(slice []uint) []uint {
 	}
 	return list
 }
+
+// bibleCache configures the upstream scripture cache from BIBLE_CACHE_MB,
+// BIBLE_CACHE_TTL and BIBLE_CACHE_STATIC_TTL (Go durations such as "2h"), and
+// BIBLE_CACHE_PERSIST=true to keep what licences allow in Postgres.
+func bibleCache() *bible.Cache {
+	var cacheDB *gorm.DB
+	if os.Getenv("BIBLE_CACHE_PERSIST") == "true" {
+		cacheDB = db
+	}
+
+	sizeMB := 64
+	if mb, err := strconv.Atoi(os.Getenv("BIBLE_CACHE_MB")); err == nil && mb >= 0 {
+		sizeMB = mb
+	}
+	cache := bible.NewCache(sizeMB<<20, cacheDB)
+
+	if ttl, err := time.ParseDuration(os.Getenv("BIBLE_CACHE_TTL")); err == nil {
+		cache.Default.TTL = ttl
+	}
+	if ttl, err := time.ParseDuration(os.Getenv("BIBLE_CACHE_STATIC_TTL")); err == nil {
+		cache.Static.TTL = ttl
+	}
+	return cache
+}
+
+// audioLibrary configures audio Bible recordings from AUDIO_SOURCE: "local"
+// reads AUDIO_DIR; "s3" reads AUDIO_BUCKET (WASABI_BUCKET by default) under
+// AUDIO_PREFIX with the Wasabi credentials. AUDIO_KEY_TEMPLATE overrides
+// audio.DefaultTemplate. Audio is off when AUDIO_SOURCE is unset.
+func audioLibrary() *audio.Library {
+	library := &audio.Library{Template: os.Getenv("AUDIO_KEY_TEMPLATE")}
+	switch os.Getenv("AUDIO_SOURCE") {
+	case "local":
+		library.Source = &audio.Local{Dir: os.Getenv("AUDIO_DIR")}
+	case "s3":
+		client, err := handlers.NewWasabiClient()
+		if err != nil {
+			log.Printf("Audio disabled: failed to connect to Wasabi: %v", err)
+			return nil
+		}
+		bucket := os.Getenv("AUDIO_BUCKET")
+		if bucket == "" {
+			bucket = os.Getenv("WASABI_BUCKET")
+		}
+		library.Source = &audio.S3{Client: client, Bucket: bucket, Prefix: os.Getenv("AUDIO_PREFIX")}
+	case "":
+		return nil
+	default:
+		log.Printf("Audio disabled: unknown AUDIO_SOURCE %q", os.Getenv("AUDIO_SOURCE"))
+		return nil
+	}
+	return library
+}